@@ -4,6 +4,10 @@ import { Login } from "./features/auth/Login";
 import { AuthorizationRequest } from "./features/auth/AuthorizationRequest";
 import { Dashboard } from "./features/dashboard/Dashboard";
 import { AccountSettings } from "./pages/AccountSettings";
+import { SignUp } from "./pages/SignUp";
+import { VerifyEmail } from "./pages/VerifyEmail";
+import { ForgotPassword } from "./pages/ForgotPassword";
+import { ResetPassword } from "./pages/ResetPassword";
 import { useEffect } from "react";
 
 const PrivateRoute: React.FC<{ children: React.ReactNode }> = ({
@@ -38,6 +42,11 @@ function App() {
       <Routes>
         <Route path="/login" element={<AuthorizationRequest />} />
         <Route path="/auth/login" element={<Login />} />
+        <Route path="/signup" element={<SignUp />} />
+        <Route path="/forgot-password" element={<ForgotPassword />} />
+        {/* メールのリンクから開く画面 */}
+        <Route path="/account/verify-email" element={<VerifyEmail />} />
+        <Route path="/account/reset-password" element={<ResetPassword />} />
         <Route
           path="/dashboard"
           element={
@@ -0,0 +1,33 @@
+import axios from "axios";
+
+const API_BASE_URL = "https://poc-authlete.local/api";
+
+export const accountApi = {
+  // ログイン中のユーザーのメールアドレスに確認のリンクを送り直す
+  sendEmailVerification: async () => {
+    await axios.post(
+      `${API_BASE_URL}/account/email-verification`,
+      {},
+      { withCredentials: true }
+    );
+  },
+
+  verifyEmail: async (token: string) => {
+    await axios.post(`${API_BASE_URL}/account/email-verification/confirm`, {
+      token,
+    });
+  },
+
+  // 登録されていないメールアドレスでも成功する
+  requestPasswordReset: async (email: string) => {
+    await axios.post(`${API_BASE_URL}/account/password-reset`, { email });
+  },
+
+  // 再設定するとすべてのセッションが終了する
+  resetPassword: async (token: string, newPassword: string) => {
+    await axios.post(`${API_BASE_URL}/account/password-reset/confirm`, {
+      token,
+      newPassword,
+    });
+  },
+};
@@ -2,6 +2,7 @@ import axios from "axios";
 import {
   LoginRequest,
   LoginResponse,
+  MFALoginRequest,
   SessionResponse,
   UserInfo,
 } from "../types/auth";
@@ -23,6 +24,14 @@ export const authApi = {
     return response.data;
   },
 
+  // ログインの2要素目を送信
+  loginMFA: async (data: MFALoginRequest): Promise<LoginResponse> => {
+    const response = await axios.post(`${API_BASE_URL}/auth/login/mfa`, data, {
+      withCredentials: true,
+    });
+    return response.data;
+  },
+
   getSession: async (): Promise<SessionResponse> => {
     const response = await axios.get(`${API_BASE_URL}/auth/session`, {
       withCredentials: true,
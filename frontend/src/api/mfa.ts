@@ -0,0 +1,61 @@
+import axios from "axios";
+
+const API_BASE_URL = "https://poc-authlete.local/api";
+
+export interface MFAStatus {
+  totpEnabled: boolean;
+  recoveryCodesRemaining: number;
+}
+
+export interface TOTPEnrollment {
+  // 認証アプリに手入力する場合のBase32の秘密鍵
+  secret: string;
+  uri: string;
+  // QRコードのPNGのdata URL
+  qrCode: string;
+}
+
+export const mfaApi = {
+  getStatus: async (): Promise<MFAStatus> => {
+    const response = await axios.get(`${API_BASE_URL}/mfa`, {
+      withCredentials: true,
+    });
+    return response.data;
+  },
+
+  // 確認コードを送るまで登録は完了しない
+  startTOTPEnrollment: async (): Promise<TOTPEnrollment> => {
+    const response = await axios.post(
+      `${API_BASE_URL}/mfa/totp`,
+      {},
+      { withCredentials: true }
+    );
+    return response.data;
+  },
+
+  // 登録を完了し、リカバリーコードを受け取る（再表示はできない）
+  confirmTOTPEnrollment: async (code: string): Promise<string[]> => {
+    const response = await axios.post(
+      `${API_BASE_URL}/mfa/totp/confirm`,
+      { code },
+      { withCredentials: true }
+    );
+    return response.data.recoveryCodes;
+  },
+
+  disableTOTP: async (code: string) => {
+    await axios.delete(`${API_BASE_URL}/mfa/totp`, {
+      data: { code },
+      withCredentials: true,
+    });
+  },
+
+  regenerateRecoveryCodes: async (code: string): Promise<string[]> => {
+    const response = await axios.post(
+      `${API_BASE_URL}/mfa/recovery-codes`,
+      { code },
+      { withCredentials: true }
+    );
+    return response.data.recoveryCodes;
+  },
+};
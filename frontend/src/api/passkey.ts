@@ -4,36 +4,33 @@ const API_BASE_URL = "https://poc-authlete.local/api";
 
 export interface Passkey {
   id: string;
-  userId: string;
-  name?: string;
+  nickname: string;
+  // AAGUIDから解決した認証器の名前（不明な場合は空文字）
+  authenticatorName: string;
   createdAt: string;
-  lastUsedAt?: string;
+  lastUsedAt: string | null;
+  backupEligible: boolean;
+  backupState: boolean;
+  transports: string[];
 }
 
 export const passkeyApi = {
-  startRegistration: async (username: string) => {
-    console.log("startRegistration: ", username);
+  // 登録するユーザーはセッションから決まる
+  startRegistration: async () => {
     const response = await axios.post(
       `${API_BASE_URL}/passkey/register/start`,
-      {
-        username,
-      }
+      {},
+      { withCredentials: true }
     );
     return response.data;
   },
 
-  completeRegistration: async (
-    userId: string,
-    credentialId: string,
-    publicKey: string,
-    attestationType: string
-  ) => {
-    await axios.post(`${API_BASE_URL}/passkey/register/complete`, {
-      userId,
-      credentialId,
-      publicKey,
-      attestationType,
-    });
+  completeRegistration: async (ceremonyId: string, credential: unknown) => {
+    await axios.post(
+      `${API_BASE_URL}/passkey/register/complete`,
+      { ceremonyId, credential },
+      { withCredentials: true }
+    );
   },
 
   startAuthentication: async (username: string) => {
@@ -46,22 +43,41 @@ export const passkeyApi = {
     return response.data;
   },
 
-  completeAuthentication: async (credentialId: string) => {
-    await axios.post(`${API_BASE_URL}/passkey/authenticate/complete`, {
-      credentialId,
-    });
+  // 認可リクエストの state を渡すと、パスワードでのログインと同じリダイレクト先が返る
+  completeAuthentication: async (
+    state: string,
+    ceremonyId: string,
+    credential: unknown
+  ): Promise<{ redirect_url: string }> => {
+    const response = await axios.post(
+      `${API_BASE_URL}/passkey/authenticate/complete`,
+      { state, ceremonyId, credential }
+    );
+    return response.data;
   },
 
-  // 登録済みパスキーの取得
-  getPasskeys: async (userId: string): Promise<Passkey[]> => {
-    const response = await axios.get(`${API_BASE_URL}/passkey/list`, {
-      params: { userId },
+  // 登録済みパスキーの取得（ログイン中のユーザーのものだけが返る）
+  getPasskeys: async (): Promise<Passkey[]> => {
+    const response = await axios.get(`${API_BASE_URL}/passkey/credentials`, {
+      withCredentials: true,
     });
-    return response.data;
+    return response.data.credentials;
+  },
+
+  // パスキーの表示名の変更
+  renamePasskey: async (passkeyId: string, nickname: string) => {
+    await axios.patch(
+      `${API_BASE_URL}/passkey/credentials/${encodeURIComponent(passkeyId)}`,
+      { nickname },
+      { withCredentials: true }
+    );
   },
 
   // パスキーの削除
   deletePasskey: async (passkeyId: string) => {
-    await axios.delete(`${API_BASE_URL}/passkey/${passkeyId}`);
+    await axios.delete(
+      `${API_BASE_URL}/passkey/credentials/${encodeURIComponent(passkeyId)}`,
+      { withCredentials: true }
+    );
   },
 };
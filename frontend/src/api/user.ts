@@ -0,0 +1,61 @@
+import axios from "axios";
+
+const API_BASE_URL = "https://poc-authlete.local/api";
+
+export interface UserProfile {
+  id: string;
+  username: string;
+  email: string;
+  emailVerified: boolean;
+  displayName: string;
+  status: "active" | "suspended" | "deleted";
+  createdAt: string;
+}
+
+export interface SignUpRequest {
+  username: string;
+  email: string;
+  password: string;
+  displayName?: string;
+}
+
+export type ProfileUpdateRequest = Partial<
+  Pick<UserProfile, "username" | "email" | "displayName">
+>;
+
+export const userApi = {
+  signUp: async (req: SignUpRequest): Promise<UserProfile> => {
+    const response = await axios.post(`${API_BASE_URL}/users`, req);
+    return response.data;
+  },
+
+  // ログイン中のユーザーのプロフィール
+  getProfile: async (): Promise<UserProfile> => {
+    const response = await axios.get(`${API_BASE_URL}/users/me`, {
+      withCredentials: true,
+    });
+    return response.data;
+  },
+
+  // 指定した項目だけを変更する
+  updateProfile: async (req: ProfileUpdateRequest): Promise<UserProfile> => {
+    const response = await axios.patch(`${API_BASE_URL}/users/me`, req, {
+      withCredentials: true,
+    });
+    return response.data;
+  },
+
+  // 直近に使ったパスワードや漏洩したパスワードには変更できない
+  changePassword: async (currentPassword: string, newPassword: string) => {
+    await axios.put(
+      `${API_BASE_URL}/users/me/password`,
+      { currentPassword, newPassword },
+      { withCredentials: true }
+    );
+  },
+
+  // 退会するとパスキーとセッションも削除される
+  deleteAccount: async () => {
+    await axios.delete(`${API_BASE_URL}/users/me`, { withCredentials: true });
+  },
+};
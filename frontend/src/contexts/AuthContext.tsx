@@ -3,7 +3,9 @@ import { AuthState } from "../types/auth";
 import { authApi } from "../api/auth";
 
 interface AuthContextType extends AuthState {
-  login: (username: string, password: string) => Promise<void>;
+  // 2要素目が必要な場合は mfa_token を返す
+  login: (username: string, password: string) => Promise<string | null>;
+  loginMFA: (mfaToken: string, code: string) => Promise<void>;
   logout: () => Promise<void>;
   checkAuth: () => Promise<void>;
 }
@@ -63,7 +65,32 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({
         throw new Error("state is not found");
       }
       const response = await authApi.login({ state, username, password });
-      window.location.replace(response.redirect_url);
+      if (response.mfa_required && response.mfa_token) {
+        setState((prev) => ({ ...prev, isLoading: false }));
+        return response.mfa_token;
+      }
+      window.location.replace(response.redirect_url!);
+      return null;
+    } catch (error) {
+      setState((prev) => ({ ...prev, isLoading: false }));
+      throw error;
+    }
+  };
+
+  const loginMFA = async (mfaToken: string, code: string) => {
+    setState((prev) => ({ ...prev, isLoading: true }));
+    try {
+      const url = new URL(window.location.href);
+      const state = url.searchParams.get("state");
+      if (!state) {
+        throw new Error("state is not found");
+      }
+      const response = await authApi.loginMFA({
+        state,
+        mfa_token: mfaToken,
+        code,
+      });
+      window.location.replace(response.redirect_url!);
     } catch (error) {
       setState((prev) => ({ ...prev, isLoading: false }));
       throw error;
@@ -87,7 +114,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({
   };
 
   return (
-    <AuthContext.Provider value={{ ...state, login, logout, checkAuth }}>
+    <AuthContext.Provider value={{ ...state, login, loginMFA, logout, checkAuth }}>
       {children}
     </AuthContext.Provider>
   );
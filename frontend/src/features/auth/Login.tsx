@@ -1,16 +1,24 @@
 import React, { useState } from "react";
+import axios from "axios";
 import { useAuth } from "../../contexts/AuthContext";
 
 export const Login: React.FC = () => {
   const [username, setUsername] = useState("");
   const [password, setPassword] = useState("");
   const [error, setError] = useState("");
-  const { login } = useAuth();
+  // パスワードを確認済みで、2要素目の入力を待っている場合に設定される
+  const [mfaToken, setMFAToken] = useState<string | null>(null);
+  const [code, setCode] = useState("");
+  const { login, loginMFA } = useAuth();
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     try {
-      await login(username, password);
+      const token = await login(username, password);
+      if (token) {
+        setError("");
+        setMFAToken(token);
+      }
     } catch (err) {
       console.error(err);
       setError(
@@ -19,6 +27,76 @@ export const Login: React.FC = () => {
     }
   };
 
+  const handleSubmitMFA = async (e: React.FormEvent) => {
+    e.preventDefault();
+    try {
+      await loginMFA(mfaToken!, code);
+    } catch (err) {
+      console.error(err);
+      if (axios.isAxiosError(err) && err.response?.data?.redirect_url) {
+        // 入力の誤りが続いた場合、ログインは失敗としてクライアントに戻る
+        window.location.replace(err.response.data.redirect_url);
+        return;
+      }
+      if (axios.isAxiosError(err) && err.response?.status === 400) {
+        // 期限切れの場合はパスワードの入力からやり直す
+        setMFAToken(null);
+        setCode("");
+        setError("有効期限が切れました。もう一度ログインしてください。");
+        return;
+      }
+      setError("確認コードが正しくありません。");
+    }
+  };
+
+  if (mfaToken) {
+    return (
+      <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
+        <div className="max-w-md w-full space-y-8">
+          <div>
+            <h2 className="mt-6 text-center text-3xl font-extrabold text-gray-900">
+              2段階認証
+            </h2>
+            <p className="mt-2 text-center text-sm text-gray-600">
+              認証アプリに表示された6桁のコード、またはリカバリーコードを入力してください。
+            </p>
+          </div>
+          <form className="mt-8 space-y-6" onSubmit={handleSubmitMFA}>
+            <div>
+              <label htmlFor="code" className="sr-only">
+                確認コード
+              </label>
+              <input
+                id="code"
+                name="code"
+                type="text"
+                autoComplete="one-time-code"
+                required
+                className="appearance-none rounded-md relative block w-full px-3 py-2 border border-gray-300 placeholder-gray-500 text-gray-900 focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm"
+                placeholder="確認コード"
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+              />
+            </div>
+
+            {error && (
+              <div className="text-red-500 text-sm text-center">{error}</div>
+            )}
+
+            <div>
+              <button
+                type="submit"
+                className="group relative w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500"
+              >
+                確認
+              </button>
+            </div>
+          </form>
+        </div>
+      </div>
+    );
+  }
+
   return (
     <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
       <div className="max-w-md w-full space-y-8">
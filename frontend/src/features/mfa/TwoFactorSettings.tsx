@@ -0,0 +1,160 @@
+import React, { useCallback, useEffect, useState } from "react";
+import { MFAStatus, TOTPEnrollment, mfaApi } from "../../api/mfa";
+
+export const TwoFactorSettings: React.FC = () => {
+  const [status, setStatus] = useState<MFAStatus | null>(null);
+  const [enrollment, setEnrollment] = useState<TOTPEnrollment | null>(null);
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+  const [code, setCode] = useState("");
+  const [error, setError] = useState<string | null>(null);
+
+  const loadStatus = useCallback(async () => {
+    try {
+      setStatus(await mfaApi.getStatus());
+    } catch (err) {
+      setError("2段階認証の状態の取得に失敗しました");
+      console.error(err);
+    }
+  }, []);
+
+  useEffect(() => {
+    loadStatus();
+  }, [loadStatus]);
+
+  const handleStart = async () => {
+    try {
+      setError(null);
+      setRecoveryCodes([]);
+      setEnrollment(await mfaApi.startTOTPEnrollment());
+    } catch (err) {
+      setError("2段階認証の登録を開始できませんでした");
+      console.error(err);
+    }
+  };
+
+  const handleConfirm = async (e: React.FormEvent) => {
+    e.preventDefault();
+    try {
+      setError(null);
+      setRecoveryCodes(await mfaApi.confirmTOTPEnrollment(code));
+      setEnrollment(null);
+      setCode("");
+      await loadStatus();
+    } catch (err) {
+      setError("確認コードが正しくありません");
+      console.error(err);
+    }
+  };
+
+  const handleRegenerate = async () => {
+    const input = window.prompt("認証アプリのコードまたはリカバリーコード");
+    if (!input) {
+      return;
+    }
+    try {
+      setError(null);
+      setRecoveryCodes(await mfaApi.regenerateRecoveryCodes(input));
+      await loadStatus();
+    } catch (err) {
+      setError("リカバリーコードの再発行に失敗しました");
+      console.error(err);
+    }
+  };
+
+  const handleDisable = async () => {
+    const input = window.prompt("認証アプリのコードまたはリカバリーコード");
+    if (!input) {
+      return;
+    }
+    try {
+      setError(null);
+      await mfaApi.disableTOTP(input);
+      setRecoveryCodes([]);
+      await loadStatus();
+    } catch (err) {
+      setError("2段階認証の無効化に失敗しました");
+      console.error(err);
+    }
+  };
+
+  return (
+    <div className="bg-white shadow rounded-lg p-6 mb-6">
+      <h2 className="text-xl font-semibold mb-4">2段階認証</h2>
+      <p className="mb-4">
+        パスワードでログインするときに、認証アプリのコードの入力を求めます。
+      </p>
+
+      {status?.totpEnabled ? (
+        <div className="space-y-2">
+          <p>
+            有効です（残りのリカバリーコード: {status.recoveryCodesRemaining}）
+          </p>
+          <div className="space-x-4">
+            <button
+              onClick={handleRegenerate}
+              className="text-blue-500 hover:text-blue-600"
+            >
+              リカバリーコードを再発行
+            </button>
+            <button
+              onClick={handleDisable}
+              className="text-red-500 hover:text-red-600"
+            >
+              無効にする
+            </button>
+          </div>
+        </div>
+      ) : (
+        !enrollment && (
+          <button
+            onClick={handleStart}
+            className="bg-blue-500 hover:bg-blue-600 text-white font-bold py-2 px-4 rounded"
+          >
+            2段階認証を設定
+          </button>
+        )
+      )}
+
+      {enrollment && (
+        <form className="space-y-4" onSubmit={handleConfirm}>
+          <p>認証アプリでQRコードを読み取り、表示されたコードを入力してください。</p>
+          <img src={enrollment.qrCode} alt="QRコード" className="w-48 h-48" />
+          <p className="text-sm text-gray-500 break-all">
+            手入力する場合: {enrollment.secret}
+          </p>
+          <input
+            type="text"
+            inputMode="numeric"
+            autoComplete="one-time-code"
+            required
+            className="block px-3 py-2 border border-gray-300 rounded"
+            placeholder="6桁のコード"
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+          />
+          <button
+            type="submit"
+            className="bg-blue-500 hover:bg-blue-600 text-white font-bold py-2 px-4 rounded"
+          >
+            確認
+          </button>
+        </form>
+      )}
+
+      {recoveryCodes.length > 0 && (
+        <div className="mt-4 p-4 bg-yellow-50 rounded">
+          <p className="mb-2">
+            認証アプリを使えないときのためのリカバリーコードです。この画面を閉じると再表示できないため、安全な場所に控えてください。各コードは1回だけ使えます。
+          </p>
+          <ul className="font-mono grid grid-cols-2 gap-1">
+            {recoveryCodes.map((recoveryCode) => (
+              <li key={recoveryCode}>{recoveryCode}</li>
+            ))}
+          </ul>
+        </div>
+      )}
+
+      {error && <p className="mt-4 text-red-500">{error}</p>}
+    </div>
+  );
+};
@@ -1,29 +1,38 @@
-import React, { useState } from "react";
-import { useAuth } from "../contexts/AuthContext";
-import { passkeyApi } from "../api/passkey";
-import { convertPublicKeyCredentialCreationOptions } from "../utils/webauthn";
-
-interface Passkey {
-  id: string;
-  userId: string;
-  name?: string;
-  createdAt: string;
-  lastUsedAt?: string;
-}
+import React, { useCallback, useEffect, useState } from "react";
+import { Passkey, passkeyApi } from "../api/passkey";
+import { TwoFactorSettings } from "../features/mfa/TwoFactorSettings";
+import {
+  convertPublicKeyCredentialCreationOptions,
+  registrationCredentialToJSON,
+} from "../utils/webauthn";
+
+const formatDate = (value: string) => new Date(value).toLocaleString("ja-JP");
 
 export const AccountSettings: React.FC = () => {
-  const { user } = useAuth();
   const [error, setError] = useState<string | null>(null);
   const [isRegistering, setIsRegistering] = useState(false);
   const [registeredPasskeys, setRegisteredPasskeys] = useState<Passkey[]>([]);
 
+  const loadPasskeys = useCallback(async () => {
+    try {
+      setRegisteredPasskeys(await passkeyApi.getPasskeys());
+    } catch (err) {
+      setError("パスキーの取得に失敗しました");
+      console.error(err);
+    }
+  }, []);
+
+  useEffect(() => {
+    loadPasskeys();
+  }, [loadPasskeys]);
+
   // パスキー登録処理
   const handleRegisterPasskey = async () => {
     try {
       setIsRegistering(true);
       setError(null);
 
-      const options = await passkeyApi.startRegistration(user?.name || "");
+      const options = await passkeyApi.startRegistration();
       console.log("options: ", options);
       const convertedOptions = convertPublicKeyCredentialCreationOptions(options.publicKey);
       const credential = (await navigator.credentials.create({
@@ -34,25 +43,13 @@ export const AccountSettings: React.FC = () => {
         throw new Error("パスキーの登録に失敗しました");
       }
 
-      const response = credential.response as AuthenticatorAttestationResponse;
-      const publicKey = response.getPublicKey();
-      if (!publicKey) {
-        throw new Error("公開鍵の取得に失敗しました");
-      }
-
-      const publicKeyStr = btoa(
-        String.fromCharCode(...new Uint8Array(publicKey))
-      );
-
+      // 公開鍵の取り出しと検証はサーバーがattestationObjectから行う
       await passkeyApi.completeRegistration(
-        user?.sub || "",
-        credential.id,
-        publicKeyStr,
-        "none"
+        options.ceremonyId,
+        registrationCredentialToJSON(credential)
       );
 
-      const passkeys = await passkeyApi.getPasskeys(user?.sub || "");
-      setRegisteredPasskeys(passkeys);
+      await loadPasskeys();
 
       alert("パスキーの登録が完了しました");
     } catch (err) {
@@ -67,14 +64,28 @@ export const AccountSettings: React.FC = () => {
   const handleDeletePasskey = async (passkeyId: string) => {
     try {
       await passkeyApi.deletePasskey(passkeyId);
-      const passkeys = await passkeyApi.getPasskeys(user?.sub || "");
-      setRegisteredPasskeys(passkeys);
+      await loadPasskeys();
     } catch (err) {
       setError("パスキーの削除に失敗しました");
       console.error(err);
     }
   };
 
+  // パスキーの表示名の変更処理
+  const handleRenamePasskey = async (passkey: Passkey) => {
+    const nickname = window.prompt("パスキーの名前", passkey.nickname);
+    if (nickname === null) {
+      return;
+    }
+    try {
+      await passkeyApi.renamePasskey(passkey.id, nickname);
+      await loadPasskeys();
+    } catch (err) {
+      setError("パスキーの名前の変更に失敗しました");
+      console.error(err);
+    }
+  };
+
   return (
     <div className="container mx-auto px-4 py-8">
       <h1 className="text-2xl font-bold mb-6">アカウント設定</h1>
@@ -103,19 +114,42 @@ export const AccountSettings: React.FC = () => {
                   key={passkey.id}
                   className="flex items-center justify-between p-3 bg-gray-50 rounded"
                 >
-                  <span>{passkey.name || "パスキー"}</span>
-                  <button
-                    onClick={() => handleDeletePasskey(passkey.id)}
-                    className="text-red-500 hover:text-red-600"
-                  >
-                    削除
-                  </button>
+                  <div>
+                    <p>
+                      {passkey.nickname ||
+                        passkey.authenticatorName ||
+                        "パスキー"}
+                      {passkey.backupState && (
+                        <span className="ml-2 text-xs text-gray-500">同期済み</span>
+                      )}
+                    </p>
+                    <p className="text-sm text-gray-500">
+                      登録: {formatDate(passkey.createdAt)} / 最終利用:{" "}
+                      {passkey.lastUsedAt ? formatDate(passkey.lastUsedAt) : "なし"}
+                    </p>
+                  </div>
+                  <div className="space-x-4">
+                    <button
+                      onClick={() => handleRenamePasskey(passkey)}
+                      className="text-blue-500 hover:text-blue-600"
+                    >
+                      名前を変更
+                    </button>
+                    <button
+                      onClick={() => handleDeletePasskey(passkey.id)}
+                      className="text-red-500 hover:text-red-600"
+                    >
+                      削除
+                    </button>
+                  </div>
                 </li>
               ))}
             </ul>
           </div>
         )}
       </div>
+
+      <TwoFactorSettings />
     </div>
   );
 };
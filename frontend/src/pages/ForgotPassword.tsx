@@ -0,0 +1,67 @@
+import React, { useState } from "react";
+import { Link } from "react-router-dom";
+import { accountApi } from "../api/account";
+
+const inputClassName =
+  "appearance-none relative block w-full px-3 py-2 border border-gray-300 placeholder-gray-500 text-gray-900 rounded-md focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm";
+
+export const ForgotPassword: React.FC = () => {
+  const [email, setEmail] = useState("");
+  const [sent, setSent] = useState(false);
+  const [error, setError] = useState("");
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError("");
+    try {
+      await accountApi.requestPasswordReset(email);
+      setSent(true);
+    } catch (err) {
+      console.error(err);
+      setError("送信に失敗しました。メールアドレスを確認してください。");
+    }
+  };
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
+      <div className="max-w-md w-full space-y-8">
+        <h2 className="mt-6 text-center text-3xl font-extrabold text-gray-900">
+          パスワードの再設定
+        </h2>
+        {sent ? (
+          <p className="text-center text-sm">
+            登録されているメールアドレスの場合、再設定のリンクを送信しました。メールを確認してください。
+          </p>
+        ) : (
+          <form className="mt-8 space-y-4" onSubmit={handleSubmit}>
+            <input
+              type="email"
+              required
+              autoComplete="email"
+              className={inputClassName}
+              placeholder="登録したメールアドレス"
+              value={email}
+              onChange={(e) => setEmail(e.target.value)}
+            />
+
+            {error && (
+              <div className="text-red-500 text-sm text-center">{error}</div>
+            )}
+
+            <button
+              type="submit"
+              className="w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500"
+            >
+              再設定のリンクを送信
+            </button>
+          </form>
+        )}
+        <div className="text-center text-sm">
+          <Link to="/login" className="text-indigo-600 hover:text-indigo-500">
+            ログインに戻る
+          </Link>
+        </div>
+      </div>
+    </div>
+  );
+};
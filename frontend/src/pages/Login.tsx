@@ -1,6 +1,11 @@
-import React, { useState } from "react";
+import React, { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import { useAuth } from "../contexts/AuthContext";
+import { passkeyApi } from "../api/passkey";
+import {
+  authenticationCredentialToJSON,
+  convertPublicKeyCredentialRequestOptions,
+} from "../utils/webauthn";
 
 export const Login: React.FC = () => {
   const [username, setUsername] = useState("");
@@ -9,6 +14,44 @@ export const Login: React.FC = () => {
   const { login } = useAuth();
   const navigate = useNavigate();
 
+  // ユーザー名欄のオートフィルにパスキーを表示する（conditional UI）
+  useEffect(() => {
+    const state = new URL(window.location.href).searchParams.get("state");
+    if (!state || !window.PublicKeyCredential?.isConditionalMediationAvailable) {
+      return;
+    }
+    const abortController = new AbortController();
+
+    (async () => {
+      if (!(await PublicKeyCredential.isConditionalMediationAvailable())) {
+        return;
+      }
+      const options = await passkeyApi.startAuthentication("");
+      const credential = (await navigator.credentials.get({
+        publicKey: convertPublicKeyCredentialRequestOptions(options.publicKey),
+        mediation: "conditional",
+        signal: abortController.signal,
+      })) as PublicKeyCredential | null;
+      if (!credential) {
+        return;
+      }
+      const response = await passkeyApi.completeAuthentication(
+        state,
+        options.ceremonyId,
+        authenticationCredentialToJSON(credential)
+      );
+      window.location.replace(response.redirect_url);
+    })().catch((err) => {
+      if (err instanceof DOMException && err.name === "AbortError") {
+        return;
+      }
+      console.error(err);
+      setError("パスキーでのログインに失敗しました");
+    });
+
+    return () => abortController.abort();
+  }, []);
+
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     try {
@@ -38,6 +81,7 @@ export const Login: React.FC = () => {
                 id="username"
                 name="username"
                 type="text"
+                autoComplete="username webauthn"
                 required
                 className="appearance-none rounded-none relative block w-full px-3 py-2 border border-gray-300 placeholder-gray-500 text-gray-900 rounded-t-md focus:outline-none focus:ring-blue-500 focus:border-blue-500 focus:z-10 sm:text-sm"
                 placeholder="ユーザー名"
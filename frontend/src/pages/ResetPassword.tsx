@@ -0,0 +1,76 @@
+import React, { useState } from "react";
+import axios from "axios";
+import { Link, useNavigate, useSearchParams } from "react-router-dom";
+import { accountApi } from "../api/account";
+
+const inputClassName =
+  "appearance-none relative block w-full px-3 py-2 border border-gray-300 placeholder-gray-500 text-gray-900 rounded-md focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm";
+
+export const ResetPassword: React.FC = () => {
+  const [searchParams] = useSearchParams();
+  const [password, setPassword] = useState("");
+  const [error, setError] = useState("");
+  const navigate = useNavigate();
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError("");
+    try {
+      await accountApi.resetPassword(searchParams.get("token") || "", password);
+      navigate("/login");
+    } catch (err) {
+      console.error(err);
+      const message = axios.isAxiosError(err) && err.response?.data?.error;
+      if (message === "invalid or expired token") {
+        setError(
+          "リンクが無効か、有効期限が切れています。もう一度再設定を依頼してください。"
+        );
+      } else {
+        setError(
+          "パスワードを設定できませんでした。8文字以上で、最近使ったものや漏洩が確認されているものは使えません。"
+        );
+      }
+    }
+  };
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
+      <div className="max-w-md w-full space-y-8">
+        <h2 className="mt-6 text-center text-3xl font-extrabold text-gray-900">
+          新しいパスワードの設定
+        </h2>
+        <form className="mt-8 space-y-4" onSubmit={handleSubmit}>
+          <input
+            type="password"
+            required
+            minLength={8}
+            autoComplete="new-password"
+            className={inputClassName}
+            placeholder="新しいパスワード（8文字以上）"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+          />
+
+          {error && (
+            <div className="text-red-500 text-sm text-center">{error}</div>
+          )}
+
+          <button
+            type="submit"
+            className="w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500"
+          >
+            設定
+          </button>
+          <div className="text-center text-sm">
+            <Link
+              to="/forgot-password"
+              className="text-indigo-600 hover:text-indigo-500"
+            >
+              再設定のリンクを送り直す
+            </Link>
+          </div>
+        </form>
+      </div>
+    </div>
+  );
+};
@@ -0,0 +1,101 @@
+import React, { useState } from "react";
+import axios from "axios";
+import { Link, useNavigate } from "react-router-dom";
+import { userApi } from "../api/user";
+
+const inputClassName =
+  "appearance-none relative block w-full px-3 py-2 border border-gray-300 placeholder-gray-500 text-gray-900 rounded-md focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm";
+
+export const SignUp: React.FC = () => {
+  const [username, setUsername] = useState("");
+  const [email, setEmail] = useState("");
+  const [password, setPassword] = useState("");
+  const [displayName, setDisplayName] = useState("");
+  const [error, setError] = useState("");
+  const navigate = useNavigate();
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError("");
+    try {
+      await userApi.signUp({ username, email, password, displayName });
+      navigate("/login");
+    } catch (err) {
+      console.error(err);
+      if (axios.isAxiosError(err) && err.response?.status === 409) {
+        setError("このユーザー名またはメールアドレスは既に登録されています。");
+      } else if (axios.isAxiosError(err) && err.response?.status === 400) {
+        setError(
+          "入力内容を確認してください。パスワードは8文字以上で、漏洩が確認されているものは使えません。"
+        );
+      } else {
+        setError("登録に失敗しました。");
+      }
+    }
+  };
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
+      <div className="max-w-md w-full space-y-8">
+        <div>
+          <h2 className="mt-6 text-center text-3xl font-extrabold text-gray-900">
+            アカウント登録
+          </h2>
+        </div>
+        <form className="mt-8 space-y-4" onSubmit={handleSubmit}>
+          <input
+            type="text"
+            required
+            autoComplete="username"
+            className={inputClassName}
+            placeholder="ユーザー名（3〜32文字の英数字）"
+            value={username}
+            onChange={(e) => setUsername(e.target.value)}
+          />
+          <input
+            type="email"
+            required
+            autoComplete="email"
+            className={inputClassName}
+            placeholder="メールアドレス"
+            value={email}
+            onChange={(e) => setEmail(e.target.value)}
+          />
+          <input
+            type="password"
+            required
+            minLength={8}
+            autoComplete="new-password"
+            className={inputClassName}
+            placeholder="パスワード（8文字以上）"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+          />
+          <input
+            type="text"
+            className={inputClassName}
+            placeholder="表示名（省略時はユーザー名）"
+            value={displayName}
+            onChange={(e) => setDisplayName(e.target.value)}
+          />
+
+          {error && (
+            <div className="text-red-500 text-sm text-center">{error}</div>
+          )}
+
+          <button
+            type="submit"
+            className="w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500"
+          >
+            登録
+          </button>
+          <div className="text-center text-sm">
+            <Link to="/login" className="text-indigo-600 hover:text-indigo-500">
+              ログインに戻る
+            </Link>
+          </div>
+        </form>
+      </div>
+    </div>
+  );
+};
@@ -0,0 +1,49 @@
+import React, { useEffect, useRef, useState } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+import { accountApi } from "../api/account";
+
+export const VerifyEmail: React.FC = () => {
+  const [searchParams] = useSearchParams();
+  const [status, setStatus] = useState<"verifying" | "verified" | "failed">(
+    "verifying"
+  );
+  // StrictModeで2回実行されてもトークンを1回だけ使う
+  const requested = useRef(false);
+
+  useEffect(() => {
+    if (requested.current) {
+      return;
+    }
+    requested.current = true;
+    const token = searchParams.get("token");
+    if (!token) {
+      setStatus("failed");
+      return;
+    }
+    accountApi
+      .verifyEmail(token)
+      .then(() => setStatus("verified"))
+      .catch((err) => {
+        console.error(err);
+        setStatus("failed");
+      });
+  }, [searchParams]);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
+      <div className="max-w-md w-full space-y-8 p-8 bg-white rounded-lg shadow text-center">
+        <h2 className="text-2xl font-bold text-gray-900">メールアドレスの確認</h2>
+        {status === "verifying" && <p>確認しています...</p>}
+        {status === "verified" && <p>メールアドレスを確認しました。</p>}
+        {status === "failed" && (
+          <p className="text-red-500">
+            リンクが無効か、有効期限が切れています。アカウント設定から確認のメールを送り直してください。
+          </p>
+        )}
+        <Link to="/dashboard" className="text-indigo-600 hover:text-indigo-500">
+          ダッシュボードへ
+        </Link>
+      </div>
+    </div>
+  );
+};
@@ -4,8 +4,18 @@ export interface LoginRequest {
   password: string;
 }
 
+// 2要素認証を有効にしているユーザーは redirect_url の代わりに mfa_token を受け取る
 export interface LoginResponse {
-  redirect_url: string;
+  redirect_url?: string;
+  mfa_required?: boolean;
+  mfa_token?: string;
+}
+
+export interface MFALoginRequest {
+  state: string;
+  mfa_token: string;
+  // TOTPの6桁のコードまたはリカバリーコード
+  code: string;
 }
 
 export interface SessionResponse {
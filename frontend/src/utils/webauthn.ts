@@ -1,6 +1,9 @@
 /* eslint-disable @typescript-eslint/no-explicit-any */
+// サーバーとはbase64url（パディング無し）でやり取りする
 export const base64ToArrayBuffer = (base64: string): ArrayBuffer => {
-  const binaryString = atob(base64);
+  const normalized = base64.replace(/-/g, "+").replace(/_/g, "/");
+  const padded = normalized.padEnd(Math.ceil(normalized.length / 4) * 4, "=");
+  const binaryString = atob(padded);
   const bytes = new Uint8Array(binaryString.length);
   for (let i = 0; i < binaryString.length; i++) {
     bytes[i] = binaryString.charCodeAt(i);
@@ -14,7 +17,7 @@ export const arrayBufferToBase64 = (buffer: ArrayBuffer): string => {
   for (let i = 0; i < bytes.byteLength; i++) {
     binary += String.fromCharCode(bytes[i]);
   }
-  return btoa(binary);
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
 };
 
 export const convertPublicKeyCredentialCreationOptions = (
@@ -46,3 +49,38 @@ export const convertPublicKeyCredentialRequestOptions = (
     })),
   };
 };
+
+// registrationCredentialToJSON は登録結果をサーバーで検証できるJSONに変換します
+export const registrationCredentialToJSON = (credential: PublicKeyCredential) => {
+  const response = credential.response as AuthenticatorAttestationResponse;
+  return {
+    id: credential.id,
+    rawId: arrayBufferToBase64(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment ?? undefined,
+    response: {
+      clientDataJSON: arrayBufferToBase64(response.clientDataJSON),
+      attestationObject: arrayBufferToBase64(response.attestationObject),
+      transports: response.getTransports?.() ?? [],
+    },
+  };
+};
+
+// authenticationCredentialToJSON は認証結果をサーバーで署名検証できるJSONに変換します
+export const authenticationCredentialToJSON = (credential: PublicKeyCredential) => {
+  const response = credential.response as AuthenticatorAssertionResponse;
+  return {
+    id: credential.id,
+    rawId: arrayBufferToBase64(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment ?? undefined,
+    response: {
+      clientDataJSON: arrayBufferToBase64(response.clientDataJSON),
+      authenticatorData: arrayBufferToBase64(response.authenticatorData),
+      signature: arrayBufferToBase64(response.signature),
+      userHandle: response.userHandle
+        ? arrayBufferToBase64(response.userHandle)
+        : undefined,
+    },
+  };
+};
@@ -0,0 +1,52 @@
+// fake-authlete はAuthleteのAPIを模倣するサーバーを起動します
+// Authleteのテナントが無くても docker-compose の構成全体を動かせるようにするためのものです
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/infrastructure/external/authlete/fake"
+)
+
+func main() {
+	addr := flag.String("addr", envOr("FAKE_AUTHLETE_ADDR", ":8080"), "listen address")
+	serviceID := flag.String("service-id", envOr("AUTHLETE_SERVICE_ID", "fake-service"), "Authlete service ID")
+	serviceToken := flag.String("service-token", envOr("AUTHLETE_ACCESS_TOKEN", "fake-service-token"), "service access token expected in the Authorization header")
+	clientID := flag.String("client-id", envOr("AUTHLETE_CLIENT_ID", "fake-client"), "client ID")
+	clientSecret := flag.String("client-secret", envOr("AUTHLETE_CLIENT_SECRET", "fake-client-secret"), "client secret")
+	redirectURIs := flag.String("redirect-uris", envOr("AUTHLETE_REDIRECT_URI", "https://poc-authlete.local/api/auth/callback"), "comma separated redirect URIs")
+	issuer := flag.String("issuer", envOr("FAKE_AUTHLETE_ISSUER", "https://fake-authlete.local"), "issuer of ID tokens")
+	accessTokenTTL := flag.Duration("access-token-ttl", time.Hour, "access token lifetime")
+	flag.Parse()
+
+	server := fake.New(fake.Config{
+		ServiceID:          *serviceID,
+		ServiceAccessToken: *serviceToken,
+		Issuer:             *issuer,
+		AccessTokenTTL:     *accessTokenTTL,
+		Clients: []fake.Client{
+			{
+				ID:           *clientID,
+				Secret:       *clientSecret,
+				RedirectURIs: strings.Split(*redirectURIs, ","),
+			},
+		},
+	})
+
+	log.Printf("fake Authlete listening on %s (service %s)", *addr, *serviceID)
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func envOr(key, defaultValue string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return defaultValue
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "errors"
+
+// ErrAccountTokenUsed はメール確認・パスワード再設定のトークンが使用済みであることを表します
+var ErrAccountTokenUsed = errors.New("account token has already been used")
+
+// Mail は送信するメールです
+type Mail struct {
+	To       string
+	Subject  string
+	HTMLBody string
+}
@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type AuthData struct {
 	CodeVerifier string
 	Ticket       string
@@ -12,14 +14,40 @@ type AuthRequest struct {
 }
 
 type AuthResponse struct {
+	AuthleteResult
 	Ticket          string `json:"ticket"`
 	ResponseContent string `json:"responseContent"`
 }
 
+// 認証方式を表す amr の値です（RFC 8176）
+const (
+	AMRPassword     = "pwd"
+	AMRHardwareKey  = "hwk"
+	AMRUserPresence = "user"
+	AMROTP          = "otp"
+	AMRMultiFactor  = "mfa"
+)
+
+// ACRPhishingResistant はフィッシング耐性のある方式で認証したことを表す acr の値です
+const ACRPhishingResistant = "phr"
+
+// AuthorizationIssueRequest は認可リクエストを許可する際にAuthleteへ渡すパラメータです
+type AuthorizationIssueRequest struct {
+	Ticket   string `json:"ticket"`
+	Subject  string `json:"subject"`
+	AuthTime int64  `json:"authTime,omitempty"`
+	ACR      string `json:"acr,omitempty"`
+	Claims   string `json:"claims,omitempty"`
+}
+
 type Tokens struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	IDToken      string `json:"id_token"`
+	// ExpiresAt はアクセストークンの有効期限です（不明な場合はゼロ値）
+	ExpiresAt time.Time `json:"expires_at"`
+	// Subject はトークンが発行されたユーザーの識別子です
+	Subject string `json:"subject,omitempty"`
 }
 
 type UserInfo struct {
@@ -31,8 +59,70 @@ type UserInfo struct {
 }
 
 type TokenResponse struct {
-	AccessToken     string `json:"accessToken"`
-	RefreshToken    string `json:"refreshToken"`
-	IdToken         string `json:"idToken"`
+	AuthleteResult
+	AccessToken string `json:"accessToken"`
+	Subject     string `json:"subject"`
+	// AccessTokenExpiresAt はアクセストークンの有効期限（UNIX時間ミリ秒）です
+	AccessTokenExpiresAt int64 `json:"accessTokenExpiresAt"`
+	// AccessTokenDuration はアクセストークンの有効期間（秒）です
+	AccessTokenDuration int64  `json:"accessTokenDuration"`
+	RefreshToken        string `json:"refreshToken"`
+	IdToken             string `json:"idToken"`
+	ResponseContent     string `json:"responseContent"`
+}
+
+// UserInfoResponse はAuthleteの /auth/userinfo のレスポンスです
+type UserInfoResponse struct {
+	AuthleteResult
+	Subject         string   `json:"subject"`
+	Scopes          []string `json:"scopes"`
+	ResponseContent string   `json:"responseContent"`
+}
+
+// UserInfoIssueResponse はAuthleteの /auth/userinfo/issue のレスポンスです
+type UserInfoIssueResponse struct {
+	AuthleteResult
 	ResponseContent string `json:"responseContent"`
 }
+
+// IntrospectionResponse はAuthleteの /auth/introspection のレスポンスです
+type IntrospectionResponse struct {
+	AuthleteResult
+	Subject         string   `json:"subject"`
+	Scopes          []string `json:"scopes"`
+	ExpiresAt       int64    `json:"expiresAt"`
+	Existent        bool     `json:"existent"`
+	Usable          bool     `json:"usable"`
+	Sufficient      bool     `json:"sufficient"`
+	ResponseContent string   `json:"responseContent"`
+}
+
+// StandardIntrospectionResponse はAuthleteの /auth/introspection/standard のレスポンスです
+// ResponseContent はRFC 7662形式のJSONです
+type StandardIntrospectionResponse struct {
+	AuthleteResult
+	ResponseContent string `json:"responseContent"`
+}
+
+// RevocationRequest はトークン失効リクエストの内容です
+// ClientID と ClientSecret は失効を求めるクライアントの認証情報で、省略できません
+type RevocationRequest struct {
+	Token         string
+	TokenTypeHint string
+	ClientID      string
+	ClientSecret  string
+}
+
+// RevocationResponse はAuthleteの /auth/revocation のレスポンスです
+type RevocationResponse struct {
+	AuthleteResult
+	ResponseContent string `json:"responseContent"`
+}
+
+// TokenIntrospection はリソースサーバー向けのアクセストークンの検証結果です
+type TokenIntrospection struct {
+	Active    bool      `json:"active"`
+	Subject   string    `json:"sub,omitempty"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
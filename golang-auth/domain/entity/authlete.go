@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Authlete APIのレスポンスに含まれるactionの値です
+const (
+	ActionInternalServerError = "INTERNAL_SERVER_ERROR"
+	ActionBadRequest          = "BAD_REQUEST"
+	ActionInvalidClient       = "INVALID_CLIENT"
+	ActionUnauthorized        = "UNAUTHORIZED"
+	ActionForbidden           = "FORBIDDEN"
+	ActionLocation            = "LOCATION"
+	ActionForm                = "FORM"
+	ActionInteraction         = "INTERACTION"
+	ActionNoInteraction       = "NO_INTERACTION"
+	ActionOK                  = "OK"
+	ActionJSON                = "JSON"
+	ActionJWT                 = "JWT"
+)
+
+// ErrAuthleteUnavailable はAuthleteの障害により呼び出しを行わなかったことを表します
+var ErrAuthleteUnavailable = errors.New("authorization server is unavailable")
+
+// AuthleteResult はAuthlete APIのレスポンスに共通して含まれる処理結果です
+type AuthleteResult struct {
+	Action        string `json:"action"`
+	ResultCode    string `json:"resultCode"`
+	ResultMessage string `json:"resultMessage"`
+}
+
+// AuthleteActionError はAuthlete APIが成功以外のactionを返したことを表すエラーです
+// ResponseContent はクライアントへそのまま返すべきレスポンスの内容です
+type AuthleteActionError struct {
+	API             string
+	Action          string
+	ResultCode      string
+	ResultMessage   string
+	ResponseContent string
+}
+
+func (e *AuthleteActionError) Error() string {
+	return fmt.Sprintf("authlete %s returned action %s: %s %s", e.API, e.Action, e.ResultCode, e.ResultMessage)
+}
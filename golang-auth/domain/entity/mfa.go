@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTOTPNotFound はTOTPが登録されていないことを表します
+	ErrTOTPNotFound = errors.New("totp is not enrolled")
+	// ErrTOTPStepUsed は同じ時間ステップのコードが既にログインに使われたことを表します
+	ErrTOTPStepUsed = errors.New("totp code has already been used")
+	// ErrRecoveryCodeNotFound はリカバリーコードが存在しない、または使用済みであることを表します
+	ErrRecoveryCodeNotFound = errors.New("recovery code not found")
+	// ErrMFAChallengeNotFound は2要素目の入力を待つログインが存在しない、期限切れ、または使用済みであることを表します
+	ErrMFAChallengeNotFound = errors.New("mfa challenge not found")
+)
+
+// TOTPCredential はユーザーのTOTPの秘密鍵です（1ユーザーにつき1つ）
+type TOTPCredential struct {
+	UserID string
+	Secret []byte
+	// ConfirmedAt はコードを入力して登録を完了した日時です
+	// ゼロ値の場合は登録の途中で、ログインの2要素目としては使いません
+	ConfirmedAt time.Time
+	// LastUsedStep は最後に受け付けたコードの時間ステップで、同じコードの再利用を防ぎます
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// Confirmed は登録が完了しているかを返します
+func (c *TOTPCredential) Confirmed() bool {
+	return !c.ConfirmedAt.IsZero()
+}
+
+// MFAChallenge はパスワードを確認した後、2要素目の入力を待っているログインです
+type MFAChallenge struct {
+	// ID はクライアントに返す推測できない値で、2要素目の入力時に受け取ります
+	ID string
+	// State は認可リクエストの state です
+	State  string
+	UserID string
+	// Attempts は誤ったコードを入力した回数です
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+// MFALoginRequest はログインの2要素目のリクエストです
+// Code はTOTPの6桁のコードかリカバリーコードです
+type MFALoginRequest struct {
+	State    string `json:"state"`
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
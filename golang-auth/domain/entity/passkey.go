@@ -1,6 +1,41 @@
 package entity
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCredentialNotFound はクレデンシャルが登録されていないことを表します
+	ErrCredentialNotFound = errors.New("credential not found")
+	// ErrCredentialAlreadyRegistered は同じクレデンシャルIDが既に登録されていることを表します
+	ErrCredentialAlreadyRegistered = errors.New("credential is already registered")
+	// ErrSignCountNotIncreased は認証器の署名カウンターが保存済みの値より増えていないことを表します
+	// クローンされた認証器が使われた可能性があります
+	ErrSignCountNotIncreased = errors.New("signature counter did not increase")
+	// ErrCeremonyNotFound はWebAuthnのセレモニーが存在しない、期限切れ、または使用済みであることを表します
+	ErrCeremonyNotFound = errors.New("webauthn ceremony not found")
+)
+
+// WebAuthnのセレモニーの種類です
+const (
+	CeremonyRegistration   = "registration"
+	CeremonyAuthentication = "authentication"
+)
+
+// WebAuthnCeremony はパスキーの登録・認証の開始から完了までサーバー側で保持する情報です
+// ブラウザには ID だけを渡し、完了時に1回限りで取り出します
+type WebAuthnCeremony struct {
+	ID        string
+	Type      string
+	Challenge []byte
+	UserID    string
+	Username  string
+	RPID      string
+	// AllowCredentials は認証を許可したクレデンシャルID（base64url）です
+	AllowCredentials []string
+	ExpiresAt        time.Time
+}
 
 // Passkey はパスキーの情報を保持する構造体です
 type Passkey struct {
@@ -29,19 +64,42 @@ type WebAuthnRegistrationRequest struct {
 
 // WebAuthnRegistrationResponse はパスキー登録開始時のレスポンスです
 type WebAuthnRegistrationResponse struct {
-	PublicKey PublicKeyCredentialCreationOptions `json:"publicKey"`
+	CeremonyID string                             `json:"ceremonyId"`
+	PublicKey  PublicKeyCredentialCreationOptions `json:"publicKey"`
 }
 
 // PublicKeyCredentialCreationOptions はWebAuthnの登録オプションです
 type PublicKeyCredentialCreationOptions struct {
-	Challenge        string            `json:"challenge"`
-	RP               RP                `json:"rp"`
-	User             WebAuthnUser      `json:"user"`
-	PubKeyCredParams []PubKeyCredParam `json:"pubKeyCredParams"`
-	Timeout          int               `json:"timeout"`
-	Attestation      string            `json:"attestation"`
+	Challenge              string                  `json:"challenge"`
+	RP                     RP                      `json:"rp"`
+	User                   WebAuthnUser            `json:"user"`
+	PubKeyCredParams       []PubKeyCredParam       `json:"pubKeyCredParams"`
+	Timeout                int                     `json:"timeout"`
+	Attestation            string                  `json:"attestation"`
+	AuthenticatorSelection *AuthenticatorSelection `json:"authenticatorSelection,omitempty"`
+}
+
+// AuthenticatorSelection は登録時に認証器に求める条件です
+type AuthenticatorSelection struct {
+	AuthenticatorAttachment string `json:"authenticatorAttachment,omitempty"`
+	// ResidentKey は discouraged、preferred、required のいずれかです
+	ResidentKey string `json:"residentKey,omitempty"`
+	// RequireResidentKey は ResidentKey に対応していないブラウザ向けに ResidentKey が required の場合に true にします
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification,omitempty"`
 }
 
+// userVerification と residentKey の値です
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+
+	ResidentKeyRequired    = "required"
+	ResidentKeyPreferred   = "preferred"
+	ResidentKeyDiscouraged = "discouraged"
+)
+
 // RP はRelying Partyの情報です
 type RP struct {
 	ID   string `json:"id"`
@@ -68,15 +126,18 @@ type WebAuthnAuthenticationRequest struct {
 
 // WebAuthnAuthenticationResponse はパスキー認証開始時のレスポンスです
 type WebAuthnAuthenticationResponse struct {
-	PublicKey PublicKeyCredentialRequestOptions `json:"publicKey"`
+	CeremonyID string                            `json:"ceremonyId"`
+	PublicKey  PublicKeyCredentialRequestOptions `json:"publicKey"`
 }
 
 // PublicKeyCredentialRequestOptions はWebAuthnの認証オプションです
+// AllowCredentials が空の場合は、認証器に保存されたパスキー（discoverable credential）から利用者が選びます
 type PublicKeyCredentialRequestOptions struct {
 	Challenge        string            `json:"challenge"`
 	Timeout          int               `json:"timeout"`
 	RPID             string            `json:"rpId"`
 	AllowCredentials []AllowCredential `json:"allowCredentials"`
+	UserVerification string            `json:"userVerification,omitempty"`
 }
 
 // AllowCredential は許可されるクレデンシャルの情報です
@@ -94,5 +155,18 @@ type Credential struct {
 	SignCount       uint32
 	Transports      []string
 	AttestationType string
-	AAGUID          []byte
+	// AttestationFormat はアテステーションステートメントの形式（packed、tpm など）です
+	AttestationFormat string
+	// AttestationTrustPath はアテステーション証明書のチェーン（DER形式、末端の証明書が先頭）です
+	AttestationTrustPath [][]byte
+	AAGUID               []byte
+	// Nickname は利用者が付けた表示名です
+	Nickname   string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	// BackupEligible と BackupState はパスキーが同期（バックアップ）可能か、実際に同期されているかを表します
+	BackupEligible bool
+	BackupState    bool
+	// AuthenticatorName はAAGUIDから解決した認証器の名前です（保存はしません）
+	AuthenticatorName string
 }
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSessionNotFound はセッションが存在しない、または期限切れであることを表します
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session はBFFがCookieで管理するログインセッションです
+type Session struct {
+	ID     string
+	UserID string
+	Tokens Tokens
+	// IPAddress と UserAgent はセッション作成時のクライアント情報です
+	IPAddress  string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// ExpiresAt を過ぎたセッションは無効です（最終アクセスから一定時間で延長されます）
+	ExpiresAt time.Time
+}
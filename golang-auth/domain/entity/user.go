@@ -1,13 +1,49 @@
 package entity
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrUserNotFound はユーザーが登録されていないことを表します
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken はユーザー名が既に使われていることを表します
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrEmailTaken はメールアドレスが既に登録されていることを表します
+	ErrEmailTaken = errors.New("email is already registered")
+)
+
+// アカウントの状態です
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	// UserStatusDeleted は退会済みで、ユーザー名・メールアドレスなどの個人情報は消去されています
+	UserStatusDeleted = "deleted"
+)
 
 // User はユーザー情報を保持する構造体です
 type User struct {
-	ID        string
-	Username  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID       string
+	Username string
+	Email    string
+	// EmailVerifiedAt はメールアドレスの所有を確認した日時です（未確認の場合はゼロ値で、メールアドレスを変更すると未確認に戻ります）
+	EmailVerifiedAt time.Time
+	DisplayName     string
+	// PasswordHash はPHC文字列形式のハッシュです（移行元から取り込んだbcryptのハッシュも含みます）
+	PasswordHash string
+	// PasswordHistory は再利用を禁止するため保持する、以前のパスワードのハッシュです（新しい順）
+	PasswordHistory   []string
+	PasswordChangedAt time.Time
+	Status            string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// IsActive はログインできる状態かを返します
+// Status が未設定のユーザーは、状態を導入する前のデータとして有効なものとして扱います
+func (u *User) IsActive() bool {
+	return u.Status == "" || u.Status == UserStatusActive
 }
 
 // UserRepository はユーザー情報を管理するリポジトリのインターフェースです
@@ -17,3 +53,18 @@ type UserRepository interface {
 	Save(user *User) error
 	Delete(id string) error
 }
+
+// SignUpRequest はユーザー登録のリクエストです
+type SignUpRequest struct {
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	DisplayName string `json:"displayName"`
+}
+
+// ProfileUpdateRequest はプロフィール変更のリクエストです（nil の項目は変更しません）
+type ProfileUpdateRequest struct {
+	Username    *string `json:"username"`
+	Email       *string `json:"email"`
+	DisplayName *string `json:"displayName"`
+}
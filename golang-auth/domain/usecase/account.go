@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+	"github.com/yamakenji24/golang-auth/pkg/mailtemplate"
+)
+
+var (
+	// ErrInvalidAccountToken はトークンが不正・期限切れであるか、発行後にメールアドレスやパスワードが変わったことを表します
+	ErrInvalidAccountToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified はメールアドレスが確認済みであることを表します
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
+// アカウントのトークンの用途です
+const (
+	accountTokenEmailVerification = "email_verification"
+	accountTokenPasswordReset     = "password_reset"
+)
+
+// メールに載せるリンクのパス（フロントエンドの画面）です
+const (
+	emailVerificationPath = "/account/verify-email"
+	passwordResetPath     = "/account/reset-password"
+)
+
+// accountToken はメール確認・パスワード再設定のリンクに含める署名付きトークンの内容です
+type accountToken struct {
+	ID      string `json:"jti"`
+	Purpose string `json:"pur"`
+	UserID  string `json:"sub"`
+	// Binding はトークンを発行したときのメールアドレスまたはパスワードのハッシュから作る値で、
+	// それらが変わると発行済みのトークンは使えなくなります
+	Binding   string `json:"bnd"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// AccountUseCase はメールアドレスの確認とパスワードの再設定を行います
+type AccountUseCase struct {
+	users     *UserUseCase
+	tokenRepo repository.AccountTokenRepository
+	mailer    repository.Mailer
+	renderer  *mailtemplate.Renderer
+	cfg       config.AccountConfig
+	secret    []byte
+	now       func() time.Time
+}
+
+// NewAccountUseCase は新しいアカウントユースケースを作成します
+// cfg.TokenSecret が空の場合は署名鍵を生成するため、再起動すると発行済みのリンクは使えなくなります
+func NewAccountUseCase(users *UserUseCase, tokenRepo repository.AccountTokenRepository, mailer repository.Mailer, renderer *mailtemplate.Renderer, cfg config.AccountConfig) *AccountUseCase {
+	secret := cfg.TokenSecret
+	if len(secret) == 0 {
+		logger.LogWarning("ACCOUNT_TOKEN_SECRET is not set; email verification and password reset links will not survive a restart")
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	return &AccountUseCase{
+		users:     users,
+		tokenRepo: tokenRepo,
+		mailer:    mailer,
+		renderer:  renderer,
+		cfg:       cfg,
+		secret:    secret,
+		now:       time.Now,
+	}
+}
+
+// SendEmailVerification はメールアドレスの確認のリンクを送信します
+// acceptLanguage はメールの言語を選ぶためのAccept-Languageヘッダーの値です
+func (u *AccountUseCase) SendEmailVerification(ctx context.Context, userID, acceptLanguage string) error {
+	user, err := u.users.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return ErrAccountInactive
+	}
+	if !user.EmailVerifiedAt.IsZero() {
+		return ErrEmailAlreadyVerified
+	}
+
+	return u.sendLink(ctx, user, acceptLanguage, mailtemplate.EmailVerification, emailVerificationPath, accountToken{
+		Purpose: accountTokenEmailVerification,
+		Binding: tokenBinding(user.Email),
+	}, u.cfg.EmailVerificationTTL)
+}
+
+// VerifyEmail はメールアドレスの確認のトークンを検証し、メールアドレスを確認済みにします
+func (u *AccountUseCase) VerifyEmail(ctx context.Context, token string) error {
+	t, user, err := u.parseToken(ctx, token, accountTokenEmailVerification)
+	if err != nil {
+		return err
+	}
+	if t.Binding != tokenBinding(user.Email) {
+		return ErrInvalidAccountToken
+	}
+	if err := u.tokenRepo.MarkUsed(t.ID, time.Unix(t.ExpiresAt, 0)); err != nil {
+		return err
+	}
+
+	user.EmailVerifiedAt = u.now()
+	return u.users.userRepo.Save(user)
+}
+
+// RequestPasswordReset はパスワードの再設定のリンクを送信します
+// 登録されていないメールアドレスかを知られないよう、該当するユーザーがいない場合もエラーを返しません
+func (u *AccountUseCase) RequestPasswordReset(ctx context.Context, email, acceptLanguage string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	user, err := u.users.userRepo.FindByEmail(email)
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return nil
+	}
+
+	return u.sendLink(ctx, user, acceptLanguage, mailtemplate.PasswordReset, passwordResetPath, accountToken{
+		Purpose: accountTokenPasswordReset,
+		Binding: tokenBinding(user.PasswordHash),
+	}, u.cfg.PasswordResetTTL)
+}
+
+// ResetPassword はパスワードの再設定のトークンを検証し、新しいパスワードに変更します
+// 変更後はすべてのセッションを終了させます。リンクを受け取れたことでメールアドレスも確認済みにします
+func (u *AccountUseCase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	t, user, err := u.parseToken(ctx, token, accountTokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return ErrAccountInactive
+	}
+	if t.Binding != tokenBinding(user.PasswordHash) {
+		return ErrInvalidAccountToken
+	}
+	// 条件を満たさないパスワードではトークンを使用済みにせず、入力し直せるようにする
+	if err := u.users.policy.check(u.users.hasher, newPassword, user); err != nil {
+		return err
+	}
+	if err := u.tokenRepo.MarkUsed(t.ID, time.Unix(t.ExpiresAt, 0)); err != nil {
+		return err
+	}
+
+	hash, err := u.users.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	now := u.now()
+	u.users.policy.setPassword(user, hash, now)
+	if user.EmailVerifiedAt.IsZero() {
+		user.EmailVerifiedAt = now
+	}
+	if err := u.users.userRepo.Save(user); err != nil {
+		return err
+	}
+	if err := u.users.sessions.DeleteUserSessions(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return nil
+}
+
+// sendLink はトークンを発行し、トークンを含むリンクをメールで送信します
+func (u *AccountUseCase) sendLink(ctx context.Context, user *entity.User, acceptLanguage, templateName, path string, t accountToken, ttl time.Duration) error {
+	expiresAt := u.now().Add(ttl)
+	t.ID = generateRandomString(16)
+	t.UserID = user.ID
+	t.ExpiresAt = expiresAt.Unix()
+	token, err := u.signToken(t)
+	if err != nil {
+		return err
+	}
+
+	subject, body, err := u.renderer.Render(templateName, acceptLanguage, mailtemplate.Data{
+		DisplayName: user.DisplayName,
+		URL:         u.cfg.BaseURL + path + "?token=" + url.QueryEscape(token),
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := u.mailer.Send(ctx, entity.Mail{To: user.Email, Subject: subject, HTMLBody: body}); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// signToken はトークンの内容をJSONにし、HMAC-SHA256の署名を付けます（<内容>.<署名> をそれぞれbase64urlで表します）
+func (u *AccountUseCase) signToken(t accountToken) (string, error) {
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(u.sign(encoded)), nil
+}
+
+func (u *AccountUseCase) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// parseToken はトークンの署名・用途・有効期限を検証し、トークンの内容と対象のユーザーを返します
+// 退会済みのユーザーのトークンは不正なものとして扱います
+func (u *AccountUseCase) parseToken(ctx context.Context, token, purpose string) (*accountToken, *entity.User, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, nil, ErrInvalidAccountToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, u.sign(encoded)) {
+		return nil, nil, ErrInvalidAccountToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, ErrInvalidAccountToken
+	}
+	var t accountToken
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return nil, nil, ErrInvalidAccountToken
+	}
+	if t.Purpose != purpose || t.ID == "" || u.now().Unix() > t.ExpiresAt {
+		return nil, nil, ErrInvalidAccountToken
+	}
+
+	user, err := u.users.GetProfile(ctx, t.UserID)
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return nil, nil, ErrInvalidAccountToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &t, user, nil
+}
+
+// tokenBinding はトークンを発行したときの値から、トークンに含める短い値を作ります
+func tokenBinding(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/mailtemplate"
+)
+
+var testAccountConfig = config.AccountConfig{
+	BaseURL:              "https://poc-authlete.local",
+	TokenSecret:          []byte("0123456789abcdef0123456789abcdef"),
+	EmailVerificationTTL: 24 * time.Hour,
+	PasswordResetTTL:     time.Hour,
+	DefaultLanguage:      "ja",
+}
+
+func newTestAccountUseCase(t *testing.T) (*AccountUseCase, *UserUseCase, *mock.MockMailer, *fakeSessionTerminator) {
+	t.Helper()
+	userUseCase, _, _, sessions := newTestUserUseCase()
+	renderer, err := mailtemplate.NewRenderer("ja")
+	require.NoError(t, err)
+	mailer := mock.NewMockMailer()
+	accountUseCase := NewAccountUseCase(userUseCase, mock.NewMockAccountTokenRepository(), mailer, renderer, testAccountConfig)
+	return accountUseCase, userUseCase, mailer, sessions
+}
+
+var linkTokenPattern = regexp.MustCompile(`token=([^"&]+)`)
+
+// linkToken は最後に送信したメールのリンクからトークンを取り出します
+func linkToken(t *testing.T, mailer *mock.MockMailer) string {
+	t.Helper()
+	require.NotEmpty(t, mailer.Sent)
+	m := linkTokenPattern.FindStringSubmatch(mailer.Sent[len(mailer.Sent)-1].HTMLBody)
+	require.NotNil(t, m)
+	token, err := url.QueryUnescape(m[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifyEmail(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	accountUseCase, userUseCase, mailer, _ := newTestAccountUseCase(t)
+	user, err := userUseCase.SignUp(ctx, entity.SignUpRequest{Username: "new-user", Email: "new@example.com", Password: "first-password"})
+	require.NoError(t, err)
+	assert.True(t, user.EmailVerifiedAt.IsZero())
+
+	// テスト実行
+	require.NoError(t, accountUseCase.SendEmailVerification(ctx, user.ID, "ja"))
+	token := linkToken(t, mailer)
+	err = accountUseCase.VerifyEmail(ctx, token)
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", mailer.Sent[0].To)
+	assert.Equal(t, "メールアドレスの確認", mailer.Sent[0].Subject)
+	assert.Contains(t, mailer.Sent[0].HTMLBody, "https://poc-authlete.local/account/verify-email?token=")
+	assert.False(t, user.EmailVerifiedAt.IsZero())
+
+	// トークンは1回だけ使える
+	assert.ErrorIs(t, accountUseCase.VerifyEmail(ctx, token), entity.ErrAccountTokenUsed)
+	assert.ErrorIs(t, accountUseCase.SendEmailVerification(ctx, user.ID, "ja"), ErrEmailAlreadyVerified)
+
+	// メールアドレスを変更すると未確認に戻り、変更前に発行したトークンは使えない
+	email := "changed@example.com"
+	updated, err := userUseCase.UpdateProfile(ctx, user.ID, entity.ProfileUpdateRequest{Email: &email})
+	require.NoError(t, err)
+	assert.True(t, updated.EmailVerifiedAt.IsZero())
+	require.NoError(t, accountUseCase.SendEmailVerification(ctx, user.ID, "ja"))
+	staleToken := linkToken(t, mailer)
+	email = "changed-again@example.com"
+	_, err = userUseCase.UpdateProfile(ctx, user.ID, entity.ProfileUpdateRequest{Email: &email})
+	require.NoError(t, err)
+	assert.ErrorIs(t, accountUseCase.VerifyEmail(ctx, staleToken), ErrInvalidAccountToken)
+}
+
+func TestAccountTokenValidation(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	accountUseCase, userUseCase, mailer, _ := newTestAccountUseCase(t)
+	user, err := userUseCase.SignUp(ctx, entity.SignUpRequest{Username: "new-user", Email: "new@example.com", Password: "first-password"})
+	require.NoError(t, err)
+	require.NoError(t, accountUseCase.SendEmailVerification(ctx, user.ID, "ja"))
+	token := linkToken(t, mailer)
+
+	other, _, _, _ := newTestAccountUseCase(t)
+	other.users = userUseCase
+	other.secret = []byte("another-secret-another-secret-00")
+
+	tests := []struct {
+		name   string
+		verify func() error
+	}{
+		{"署名の改ざん", func() error { return accountUseCase.VerifyEmail(ctx, token+"x") }},
+		{"形式が不正", func() error { return accountUseCase.VerifyEmail(ctx, "not-a-token") }},
+		{"別の鍵で署名", func() error { return other.VerifyEmail(ctx, token) }},
+		{"別の用途のトークン", func() error { return accountUseCase.ResetPassword(ctx, token, "second-password") }},
+		{"期限切れ", func() error {
+			expired := *accountUseCase
+			expired.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+			return expired.VerifyEmail(ctx, token)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テスト実行・アサーション
+			assert.ErrorIs(t, tt.verify(), ErrInvalidAccountToken)
+		})
+	}
+	assert.True(t, user.EmailVerifiedAt.IsZero())
+}
+
+func TestResetPassword(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	accountUseCase, userUseCase, mailer, sessions := newTestAccountUseCase(t)
+	user, err := userUseCase.SignUp(ctx, entity.SignUpRequest{Username: "new-user", Email: "new@example.com", Password: "first-password"})
+	require.NoError(t, err)
+
+	// 登録されていないメールアドレスでもエラーにしない
+	require.NoError(t, accountUseCase.RequestPasswordReset(ctx, "unknown@example.com", "ja"))
+	assert.Empty(t, mailer.Sent)
+	assert.ErrorIs(t, accountUseCase.RequestPasswordReset(ctx, "invalid", "ja"), ErrInvalidEmail)
+
+	// テスト実行
+	require.NoError(t, accountUseCase.RequestPasswordReset(ctx, "New@Example.com", "en-US,en;q=0.9"))
+	token := linkToken(t, mailer)
+
+	// アサーション
+	assert.Equal(t, "Reset your password", mailer.Sent[0].Subject)
+	assert.Contains(t, mailer.Sent[0].HTMLBody, "https://poc-authlete.local/account/reset-password?token=")
+
+	// 条件を満たさないパスワードではトークンを使用済みにしない
+	assert.ErrorIs(t, accountUseCase.ResetPassword(ctx, token, "short"), ErrInvalidPassword)
+	assert.ErrorIs(t, accountUseCase.ResetPassword(ctx, token, "first-password"), ErrPasswordReused)
+
+	require.NoError(t, accountUseCase.ResetPassword(ctx, token, "second-password"))
+	assert.Equal(t, []string{user.ID}, sessions.terminated)
+	assert.False(t, user.EmailVerifiedAt.IsZero())
+	_, err = NewPasswordCredentialVerifier(userUseCase.userRepo, testHasher).Verify("new@example.com", "second-password")
+	assert.NoError(t, err)
+
+	// パスワードが変わったため、同じトークンは使えない
+	assert.ErrorIs(t, accountUseCase.ResetPassword(ctx, token, "third-password"), ErrInvalidAccountToken)
+
+	// 停止中のアカウントにはリンクを送らない
+	require.NoError(t, userUseCase.SetStatus(ctx, user.ID, entity.UserStatusSuspended))
+	require.NoError(t, accountUseCase.RequestPasswordReset(ctx, "new@example.com", "ja"))
+	assert.Len(t, mailer.Sent, 1)
+}
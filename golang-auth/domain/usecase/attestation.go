@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/metadata"
+)
+
+// ErrAttestationRejected は登録された認証器がアテステーションのポリシーで拒否されたことを表します
+var ErrAttestationRejected = errors.New("attestation rejected by policy")
+
+// AttestationPolicy はパスキーの登録時に、署名を検証済みのアテステーションを受け入れるかを判断するインターフェースです
+// 証明書のチェーンがどのルートにつながるかは webauthn.VerifyTrustPath で確認できます
+type AttestationPolicy interface {
+	Evaluate(ctx context.Context, registration *webauthn.Registration) error
+}
+
+// AttestationPolicyFunc は関数を AttestationPolicy として使うためのアダプターです
+type AttestationPolicyFunc func(ctx context.Context, registration *webauthn.Registration) error
+
+func (f AttestationPolicyFunc) Evaluate(ctx context.Context, registration *webauthn.Registration) error {
+	return f(ctx, registration)
+}
+
+// NewAllowAnyAttestationPolicy はどの認証器の登録も受け入れるポリシーを作成します
+func NewAllowAnyAttestationPolicy() AttestationPolicy {
+	return AttestationPolicyFunc(func(context.Context, *webauthn.Registration) error {
+		return nil
+	})
+}
+
+// MetadataStore はAAGUIDから認証器のメタデータ（FIDO MDS）を引くインターフェースです
+type MetadataStore interface {
+	Entry(aaguid []byte) *metadata.Entry
+}
+
+type metadataAttestationPolicy struct {
+	store    MetadataStore
+	allowed  map[string]bool
+	denied   map[string]bool
+	minLevel int
+	// requireCertified は認証器の機種をMDSのルート証明書につながるアテステーションで確認するかです
+	requireCertified bool
+	now              func() time.Time
+}
+
+// NewMetadataAttestationPolicy はAAGUIDの許可・拒否リストとFIDO MDSの認定レベル・失効状況で登録を判断するポリシーを作成します
+// store が nil の場合は拒否リストだけを適用します
+func NewMetadataAttestationPolicy(store MetadataStore, cfg config.WebAuthnConfig) (AttestationPolicy, error) {
+	p := &metadataAttestationPolicy{
+		store:            store,
+		allowed:          make(map[string]bool),
+		denied:           make(map[string]bool),
+		requireCertified: cfg.RequiresCertifiedAuthenticator(),
+		now:              time.Now,
+	}
+	if p.requireCertified && store == nil {
+		return nil, errors.New("metadata store is required to check authenticator certification")
+	}
+	for _, list := range []struct {
+		aaguids []string
+		set     map[string]bool
+	}{{cfg.AllowedAAGUIDs, p.allowed}, {cfg.DeniedAAGUIDs, p.denied}} {
+		for _, aaguid := range list.aaguids {
+			normalized, err := metadata.NormalizeAAGUID(aaguid)
+			if err != nil {
+				return nil, err
+			}
+			list.set[normalized] = true
+		}
+	}
+	if cfg.MinCertificationLevel != "" {
+		level, err := metadata.ParseCertificationLevel(cfg.MinCertificationLevel)
+		if err != nil {
+			return nil, err
+		}
+		p.minLevel = level
+	}
+	return p, nil
+}
+
+func (p *metadataAttestationPolicy) Evaluate(ctx context.Context, registration *webauthn.Registration) error {
+	aaguid := hex.EncodeToString(registration.AAGUID)
+	if p.denied[aaguid] {
+		return fmt.Errorf("authenticator %s is denied", aaguid)
+	}
+
+	var entry *metadata.Entry
+	if p.store != nil {
+		entry = p.store.Entry(registration.AAGUID)
+	}
+	if entry != nil && entry.Revoked() {
+		return fmt.Errorf("authenticator %s is revoked", aaguid)
+	}
+	if !p.requireCertified {
+		return nil
+	}
+
+	// AAGUIDは認証器の自己申告のため、MDSのルート証明書につながるアテステーションで確かめる
+	if len(registration.AttestationTrustPath) == 0 {
+		return fmt.Errorf("attestation %q does not identify the authenticator", registration.AttestationType)
+	}
+	if entry == nil {
+		return fmt.Errorf("authenticator %s is not in the metadata", aaguid)
+	}
+	roots, err := entry.AttestationRoots()
+	if err != nil {
+		return err
+	}
+	if err := webauthn.VerifyTrustPath(registration.AttestationTrustPath, roots, p.now()); err != nil {
+		return err
+	}
+
+	if len(p.allowed) > 0 && !p.allowed[aaguid] {
+		return fmt.Errorf("authenticator %s is not allowed", aaguid)
+	}
+	if entry.CertificationLevel() < p.minLevel {
+		return fmt.Errorf("authenticator %s does not meet the certification level", aaguid)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/metadata"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/webauthntest"
+)
+
+const testAAGUID = "cb69481e-8ff7-4039-93ec-0a2729a154a8"
+
+// fakeMetadataStore はAAGUID（16進数）からメタデータを引くテスト用のストアです
+type fakeMetadataStore map[string]*metadata.Entry
+
+func (s fakeMetadataStore) Entry(aaguid []byte) *metadata.Entry {
+	return s[hex.EncodeToString(aaguid)]
+}
+
+// attestedRegistration は仮想認証器の packed 形式のアテステーションを検証した登録結果を返します
+func attestedRegistration(t *testing.T, authenticator *webauthntest.Authenticator) *webauthn.Registration {
+	t.Helper()
+	challenge := []byte("registration-challenge-0123456789")
+	credential, err := authenticator.Create(challenge, []byte("test-user-id"))
+	require.NoError(t, err)
+	registration, err := webauthn.VerifyRegistration(webauthn.RegistrationOptions{
+		Challenge: challenge,
+		RPID:      testRPID,
+		Origins:   []string{testOrigin},
+	}, credential)
+	require.NoError(t, err)
+	return registration
+}
+
+func TestMetadataAttestationPolicy(t *testing.T) {
+	certified := []metadata.StatusReport{{Status: metadata.StatusFIDOCertifiedL1, EffectiveDate: "2020-01-01"}}
+
+	tests := []struct {
+		name     string
+		format   string
+		reports  []metadata.StatusReport
+		otherCA  bool
+		modify   func(cfg *config.WebAuthnConfig)
+		rejected bool
+	}{
+		{name: "許可された認定済みの認証器", format: "packed", reports: certified},
+		{name: "拒否リストの認証器", format: "packed", reports: certified, modify: func(cfg *config.WebAuthnConfig) {
+			cfg.DeniedAAGUIDs = []string{testAAGUID}
+		}, rejected: true},
+		{name: "許可リストに無い認証器", format: "packed", reports: certified, modify: func(cfg *config.WebAuthnConfig) {
+			cfg.AllowedAAGUIDs = []string{"fa2b99dc-9e39-4257-8f92-4a30d23c4118"}
+		}, rejected: true},
+		{name: "失効した認証器", format: "packed", reports: append(certified, metadata.StatusReport{Status: metadata.StatusRevoked, EffectiveDate: "2021-01-01"}), rejected: true},
+		{name: "認定レベルが足りない認証器", format: "packed", reports: certified, modify: func(cfg *config.WebAuthnConfig) {
+			cfg.MinCertificationLevel = "L2"
+		}, rejected: true},
+		{name: "機種を証明しないアテステーション", format: "none", reports: certified, rejected: true},
+		{name: "MDSのルートにつながらない証明書", format: "packed", reports: certified, otherCA: true, rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テストケースの準備
+			authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+			authenticator.Format = tt.format
+			authenticator.AAGUID, _ = hex.DecodeString("cb69481e8ff7403993ec0a2729a154a8")
+			registration := attestedRegistration(t, authenticator)
+
+			ca := authenticator
+			if tt.otherCA {
+				ca = webauthntest.NewAuthenticator(testRPID, testOrigin)
+			}
+			root, err := ca.AttestationRoot()
+			require.NoError(t, err)
+			store := fakeMetadataStore{"cb69481e8ff7403993ec0a2729a154a8": {
+				AAGUID: testAAGUID,
+				MetadataStatement: &metadata.Statement{
+					AttestationRootCertificates: []string{base64.StdEncoding.EncodeToString(root.Raw)},
+				},
+				StatusReports: tt.reports,
+			}}
+
+			cfg := testWebAuthnConfig()
+			cfg.AllowedAAGUIDs = []string{testAAGUID}
+			cfg.MinCertificationLevel = "L1"
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+			policy, err := NewMetadataAttestationPolicy(store, cfg)
+			require.NoError(t, err)
+
+			// テスト実行
+			err = policy.Evaluate(context.Background(), registration)
+
+			// アサーション
+			if tt.rejected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMetadataAttestationPolicyWithoutMetadata(t *testing.T) {
+	// テストケースの準備
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	registration := attestedRegistration(t, authenticator)
+	cfg := testWebAuthnConfig()
+	cfg.DeniedAAGUIDs = []string{testAAGUID}
+	policy, err := NewMetadataAttestationPolicy(nil, cfg)
+	require.NoError(t, err)
+
+	// テスト実行・アサーション
+	// 認定を求めない場合は、アテステーションの無い認証器も受け入れる
+	assert.NoError(t, policy.Evaluate(context.Background(), registration))
+
+	cfg.MinCertificationLevel = "L1"
+	_, err = NewMetadataAttestationPolicy(nil, cfg)
+	assert.Error(t, err)
+}
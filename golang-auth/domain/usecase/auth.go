@@ -1,44 +1,66 @@
 package usecase
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/interface/repository"
 	"github.com/yamakenji24/golang-auth/pkg/config"
+	"golang.org/x/sync/singleflight"
 )
 
 type AuthUseCase interface {
-	GetAuthorizationURL() (string, error)
-	Login(req entity.AuthRequest) (string, error)
+	GetAuthorizationURL(ctx context.Context) (string, error)
+	Login(ctx context.Context, req entity.AuthRequest) (string, error)
+	LoginWithPasskey(ctx context.Context, state string, user *entity.User) (string, error)
+	LoginWithSecondFactor(ctx context.Context, req entity.MFALoginRequest) (string, error)
 	GetAuthData(state string) (entity.AuthData, bool)
-	ExchangeCodeForTokens(code, codeVerifier string) (entity.Tokens, error)
-	StoreSession(sessionID, accessToken string) error
-	GetAccessToken(sessionID string) (string, error)
-	GetUserInfo(accessToken string) (entity.UserInfo, error)
-	DeleteSession(sessionID string) error
+	ExchangeCodeForTokens(ctx context.Context, code, codeVerifier string) (entity.Tokens, error)
+	StoreSession(session *entity.Session) error
+	GetAccessToken(ctx context.Context, sessionID string) (string, error)
+	GetSessionUserID(ctx context.Context, sessionID string) (string, error)
+	RefreshSession(ctx context.Context, sessionID, staleAccessToken string) (string, error)
+	GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error)
+	DeleteSession(ctx context.Context, sessionID string) error
+	DeleteUserSessions(ctx context.Context, userID string) error
+	IntrospectToken(ctx context.Context, token string, scopes []string, subject string) (entity.TokenIntrospection, error)
+	IntrospectStandard(ctx context.Context, clientID, clientSecret string, params map[string]string) (string, error)
+	RevokeToken(ctx context.Context, req entity.RevocationRequest) error
 }
 
 type authUseCase struct {
 	authRepo       repository.AuthRepository
+	sessionRepo    repository.SessionRepository
 	authleteRepo   repository.AuthleteClient
 	config         *config.Config
 	authleteClient repository.AuthleteClient
+	verifier       CredentialVerifier
+	secondFactor   SecondFactorVerifier
+	challengeRepo  repository.MFAChallengeRepository
 	authDataMap    map[string]entity.AuthData
-	sessionMap     map[string]string
+	refreshGroup   singleflight.Group
 }
 
-func NewAuthUseCase(authRepo repository.AuthRepository, authleteRepo repository.AuthleteClient, cfg *config.Config, authleteClient repository.AuthleteClient) AuthUseCase {
+// NewAuthUseCase は新しい認証ユースケースを作成します
+// secondFactor が nil の場合は、パスワードだけでログインを完了します
+func NewAuthUseCase(authRepo repository.AuthRepository, sessionRepo repository.SessionRepository, authleteRepo repository.AuthleteClient, cfg *config.Config, authleteClient repository.AuthleteClient, verifier CredentialVerifier, secondFactor SecondFactorVerifier, challengeRepo repository.MFAChallengeRepository) AuthUseCase {
 	return &authUseCase{
 		authRepo:       authRepo,
+		sessionRepo:    sessionRepo,
 		authleteRepo:   authleteRepo,
 		config:         cfg,
 		authleteClient: authleteClient,
+		verifier:       verifier,
+		secondFactor:   secondFactor,
+		challengeRepo:  challengeRepo,
 		authDataMap:    make(map[string]entity.AuthData),
-		sessionMap:     make(map[string]string),
 	}
 }
 
@@ -60,7 +82,7 @@ func (u *authUseCase) generateCodeChallenge(codeVerifier string) string {
 	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
 }
 
-func (u *authUseCase) GetAuthorizationURL() (string, error) {
+func (u *authUseCase) GetAuthorizationURL(ctx context.Context) (string, error) {
 	codeVerifier := u.generateCodeVerifier()
 	codeChallenge := u.generateCodeChallenge(codeVerifier)
 	state := u.generateState()
@@ -74,7 +96,7 @@ func (u *authUseCase) GetAuthorizationURL() (string, error) {
 		"code_challenge_method": "S256",
 	}
 
-	resp, err := u.authleteRepo.RequestAuthorization(params)
+	resp, err := u.authleteRepo.RequestAuthorization(ctx, params)
 	if err != nil {
 		return "", err
 	}
@@ -91,23 +113,159 @@ func (u *authUseCase) GetAuthorizationURL() (string, error) {
 	return fmt.Sprintf("https://poc-authlete.local/auth/login?state=%s", state), nil
 }
 
-func (u *authUseCase) Login(req entity.AuthRequest) (string, error) {
+func (u *authUseCase) Login(ctx context.Context, req entity.AuthRequest) (string, error) {
 	authData, ok := u.authRepo.GetAuthData(req.State)
 	if !ok {
 		return "", fmt.Errorf("AuthData not found")
 	}
 
-	resp, err := u.authleteRepo.IssueAuthorization(authData.Ticket)
+	user, err := u.verifier.Verify(req.Email, req.Password)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCredentials) {
+			return "", err
+		}
+		return "", u.failAuthorization(ctx, authData.Ticket, req.State)
+	}
+
+	// 2要素認証を有効にしているユーザーは、2要素目を確認するまで認可を発行しない
+	if u.secondFactor != nil {
+		enabled, err := u.secondFactor.Enabled(ctx, user.ID)
+		if err != nil {
+			return "", err
+		}
+		if enabled {
+			return "", u.requireSecondFactor(req.State, user)
+		}
+	}
+
+	return u.issueAuthorization(ctx, authData.Ticket, req.State, user, "", []string{entity.AMRPassword})
+}
+
+// requireSecondFactor は2要素目の入力を待つログインを保存し、MFAToken を含むエラーを返す
+func (u *authUseCase) requireSecondFactor(state string, user *entity.User) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	challenge := &entity.MFAChallenge{
+		ID:        base64.RawURLEncoding.EncodeToString(b),
+		State:     state,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(u.config.MFA.ChallengeTTL),
+	}
+	if err := u.challengeRepo.Save(challenge); err != nil {
+		return err
+	}
+	return &MFARequiredError{MFAToken: challenge.ID}
+}
+
+// LoginWithSecondFactor はパスワードを確認済みのログインの2要素目を検証し、成功した場合だけ認可を発行します
+// 誤ったコードの入力が MaxAttempts 回に達した場合は、認証失敗をAuthleteに通知します
+func (u *authUseCase) LoginWithSecondFactor(ctx context.Context, req entity.MFALoginRequest) (string, error) {
+	authData, ok := u.authRepo.GetAuthData(req.State)
+	if !ok {
+		return "", fmt.Errorf("AuthData not found")
+	}
+	if u.secondFactor == nil {
+		return "", entity.ErrMFAChallengeNotFound
+	}
+
+	// 取り出したログインは削除されるため、同じ MFAToken で同時に検証することはできない
+	challenge, err := u.challengeRepo.Consume(req.MFAToken)
+	if err != nil {
+		return "", err
+	}
+	if challenge.State != req.State {
+		return "", entity.ErrMFAChallengeNotFound
+	}
+
+	user, err := u.secondFactor.Verify(ctx, challenge.UserID, req.Code)
+	switch {
+	case errors.Is(err, ErrInvalidMFACode):
+		challenge.Attempts++
+		if challenge.Attempts >= u.config.MFA.MaxAttempts {
+			return "", u.failAuthorization(ctx, authData.Ticket, req.State)
+		}
+		if err := u.challengeRepo.Save(challenge); err != nil {
+			return "", err
+		}
+		return "", ErrInvalidMFACode
+	case errors.Is(err, ErrAccountInactive), errors.Is(err, ErrTOTPNotEnabled), errors.Is(err, entity.ErrUserNotFound):
+		return "", u.failAuthorization(ctx, authData.Ticket, req.State)
+	case err != nil:
+		return "", err
+	}
+
+	amr := []string{entity.AMRPassword, entity.AMROTP, entity.AMRMultiFactor}
+	return u.issueAuthorization(ctx, authData.Ticket, req.State, user, "", amr)
+}
+
+// LoginWithPasskey はパスキーで認証済みのユーザーで認可リクエストを許可し、Login と同じリダイレクト先を返します
+func (u *authUseCase) LoginWithPasskey(ctx context.Context, state string, user *entity.User) (string, error) {
+	authData, ok := u.authRepo.GetAuthData(state)
+	if !ok {
+		return "", fmt.Errorf("AuthData not found")
+	}
+
+	amr := []string{entity.AMRHardwareKey, entity.AMRUserPresence}
+	return u.issueAuthorization(ctx, authData.Ticket, state, user, entity.ACRPhishingResistant, amr)
+}
+
+// issueAuthorization 認証済みのユーザーでAuthleteの認可を発行し、クライアントへのリダイレクト先を返す
+func (u *authUseCase) issueAuthorization(ctx context.Context, ticket, state string, user *entity.User, acr string, amr []string) (string, error) {
+	claims, err := userClaims(user, amr)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := u.authleteRepo.IssueAuthorization(ctx, entity.AuthorizationIssueRequest{
+		Ticket:   ticket,
+		Subject:  user.ID,
+		AuthTime: time.Now().Unix(),
+		ACR:      acr,
+		Claims:   claims,
+	})
 	if err != nil {
 		return "", err
 	}
 
-	fmt.Println(resp.ResponseContent)
+	return resp.ResponseContent + "&state=" + state, nil
+}
+
+// userClaims IDトークンとUserInfoに含めるユーザーのクレームをJSONで生成
+// amr は認証方式で、空の場合は含めない
+func userClaims(user *entity.User, amr []string) (string, error) {
+	claims := map[string]interface{}{}
+	if user.Username != "" {
+		claims["name"] = user.Username
+	}
+	if user.Email != "" {
+		claims["email"] = user.Email
+	}
+	if len(amr) > 0 {
+		claims["amr"] = amr
+	}
+
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal claims: %w", err)
+	}
+	return string(b), nil
+}
+
+// failAuthorization 認証失敗をAuthleteに通知し、エラーリダイレクト先を含むエラーを返す
+func (u *authUseCase) failAuthorization(ctx context.Context, ticket, state string) error {
+	resp, err := u.authleteRepo.FailAuthorization(ctx, ticket, "NOT_AUTHENTICATED")
+	if err != nil {
+		return fmt.Errorf("failed to report authentication failure: %w", err)
+	}
 
-	return resp.ResponseContent + "&state=" + req.State, nil
+	return &InvalidCredentialsError{
+		RedirectURL: resp.ResponseContent + "&state=" + state,
+	}
 }
 
-func (u *authUseCase) ExchangeCodeForTokens(code, codeVerifier string) (entity.Tokens, error) {
+func (u *authUseCase) ExchangeCodeForTokens(ctx context.Context, code, codeVerifier string) (entity.Tokens, error) {
 	params := map[string]string{
 		"grant_type":    "authorization_code",
 		"code":          code,
@@ -115,44 +273,36 @@ func (u *authUseCase) ExchangeCodeForTokens(code, codeVerifier string) (entity.T
 		"code_verifier": codeVerifier,
 	}
 
-	tokenResponse, err := u.authleteRepo.ExchangeToken(params)
+	tokenResponse, err := u.authleteRepo.ExchangeToken(ctx, params)
 	if err != nil {
 		return entity.Tokens{}, err
 	}
 
-	return entity.Tokens{
-		AccessToken:  tokenResponse.AccessToken,
-		RefreshToken: tokenResponse.RefreshToken,
-		IDToken:      tokenResponse.IdToken,
-	}, nil
-}
-
-func (u *authUseCase) GetAuthData(state string) (entity.AuthData, bool) {
-	return u.authRepo.GetAuthData(state)
-}
-
-// StoreSession セッションIDとアクセストークンを紐付けて保存
-func (u *authUseCase) StoreSession(sessionID, accessToken string) error {
-	u.sessionMap[sessionID] = accessToken
-	return nil
+	return tokensFromResponse(tokenResponse), nil
 }
 
-// GetAccessToken セッションIDからアクセストークンを取得
-func (u *authUseCase) GetAccessToken(sessionID string) (string, error) {
-	accessToken, ok := u.sessionMap[sessionID]
-	if !ok {
-		return "", fmt.Errorf("session not found")
+// tokensFromResponse トークンレスポンスからセッションに保存するトークンを生成
+func tokensFromResponse(resp *entity.TokenResponse) entity.Tokens {
+	tokens := entity.Tokens{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		IDToken:      resp.IdToken,
+		Subject:      resp.Subject,
+	}
+	switch {
+	case resp.AccessTokenExpiresAt > 0:
+		tokens.ExpiresAt = time.UnixMilli(resp.AccessTokenExpiresAt)
+	case resp.AccessTokenDuration > 0:
+		tokens.ExpiresAt = time.Now().Add(time.Duration(resp.AccessTokenDuration) * time.Second)
 	}
-	return accessToken, nil
+	return tokens
 }
 
-// GetUserInfo アクセストークンからユーザー情報を取得
-func (u *authUseCase) GetUserInfo(accessToken string) (entity.UserInfo, error) {
-	return u.authleteClient.GetUserInfo(accessToken)
+func (u *authUseCase) GetAuthData(state string) (entity.AuthData, bool) {
+	return u.authRepo.GetAuthData(state)
 }
 
-// DeleteSession セッションを削除
-func (u *authUseCase) DeleteSession(sessionID string) error {
-	delete(u.sessionMap, sessionID)
-	return nil
+// GetUserInfo アクセストークンからユーザー情報を取得
+func (u *authUseCase) GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error) {
+	return u.authleteClient.GetUserInfo(ctx, accessToken)
 }
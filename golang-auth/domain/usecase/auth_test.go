@@ -1,12 +1,16 @@
 package usecase
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/domain/usecase/mock"
 	"github.com/yamakenji24/golang-auth/pkg/config"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestGetAuthorizationURL(t *testing.T) {
@@ -25,10 +29,10 @@ func TestGetAuthorizationURL(t *testing.T) {
 	}
 
 	// ユースケースの作成
-	authUseCase := NewAuthUseCase(mockAuthRepo, mockAuthleteClient, cfg, mockAuthleteClient)
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
 
 	// テスト実行
-	url, err := authUseCase.GetAuthorizationURL()
+	url, err := authUseCase.GetAuthorizationURL(context.Background())
 
 	// アサーション
 	assert.NoError(t, err)
@@ -47,6 +51,7 @@ func TestLogin(t *testing.T) {
 	// テストケースの準備
 	mockAuthRepo := mock.NewMockAuthRepository()
 	mockAuthleteClient := mock.NewMockAuthleteClient()
+	mockUserRepo := mock.NewMockUserRepository()
 	cfg := &config.Config{}
 
 	// モックの設定
@@ -59,18 +64,65 @@ func TestLogin(t *testing.T) {
 	mockAuthleteClient.AuthResponse = &entity.AuthResponse{
 		ResponseContent: "test-response",
 	}
+	mockUserRepo.Save(newTestUser(t, "test-password"))
 
 	// ユースケースの作成
-	authUseCase := NewAuthUseCase(mockAuthRepo, mockAuthleteClient, cfg, mockAuthleteClient)
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mockUserRepo, testHasher), nil, nil)
 
 	// テスト実行
-	req := entity.AuthRequest{State: state}
-	response, err := authUseCase.Login(req)
+	req := entity.AuthRequest{State: state, Email: "test@example.com", Password: "test-password"}
+	response, err := authUseCase.Login(context.Background(), req)
 
 	// アサーション
 	assert.NoError(t, err)
 	assert.Contains(t, response, "test-response")
 	assert.Contains(t, response, state)
+	assert.Equal(t, "test-ticket", mockAuthleteClient.IssueRequest.Ticket)
+	assert.Equal(t, "test-user-id", mockAuthleteClient.IssueRequest.Subject)
+	assert.NotZero(t, mockAuthleteClient.IssueRequest.AuthTime)
+	assert.JSONEq(t, `{"name":"test-user","email":"test@example.com","amr":["pwd"]}`, mockAuthleteClient.IssueRequest.Claims)
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	// テストケースの準備
+	mockAuthRepo := mock.NewMockAuthRepository()
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	mockUserRepo := mock.NewMockUserRepository()
+	cfg := &config.Config{}
+
+	// モックの設定
+	state := "test-state"
+	mockAuthRepo.StoreAuthData(state, entity.AuthData{Ticket: "test-ticket"})
+	mockAuthleteClient.FailResponse = &entity.AuthResponse{
+		ResponseContent: "https://client.example.com/cb?error=login_required",
+	}
+	mockUserRepo.Save(newTestUser(t, "test-password"))
+
+	// ユースケースの作成
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mockUserRepo, testHasher), nil, nil)
+
+	// テスト実行
+	req := entity.AuthRequest{State: state, Email: "test@example.com", Password: "wrong-password"}
+	_, err := authUseCase.Login(context.Background(), req)
+
+	// アサーション
+	var invalidErr *InvalidCredentialsError
+	assert.ErrorAs(t, err, &invalidErr)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, "https://client.example.com/cb?error=login_required&state=test-state", invalidErr.RedirectURL)
+	assert.Equal(t, "NOT_AUTHENTICATED", mockAuthleteClient.FailReason)
+}
+
+func newTestUser(t *testing.T, password string) *entity.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	return &entity.User{
+		ID:           "test-user-id",
+		Username:     "test-user",
+		Email:        "test@example.com",
+		PasswordHash: string(hash),
+	}
 }
 
 func TestExchangeCodeForTokens(t *testing.T) {
@@ -90,10 +142,10 @@ func TestExchangeCodeForTokens(t *testing.T) {
 	}
 
 	// ユースケースの作成
-	authUseCase := NewAuthUseCase(mockAuthRepo, mockAuthleteClient, cfg, mockAuthleteClient)
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
 
 	// テスト実行
-	tokens, err := authUseCase.ExchangeCodeForTokens("test-code", "test-code-verifier")
+	tokens, err := authUseCase.ExchangeCodeForTokens(context.Background(), "test-code", "test-code-verifier")
 
 	// アサーション
 	assert.NoError(t, err)
@@ -118,10 +170,10 @@ func TestGetUserInfo(t *testing.T) {
 	}
 
 	// ユースケースの作成
-	authUseCase := NewAuthUseCase(mockAuthRepo, mockAuthleteClient, cfg, mockAuthleteClient)
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
 
 	// テスト実行
-	userInfo, err := authUseCase.GetUserInfo("test-access-token")
+	userInfo, err := authUseCase.GetUserInfo(context.Background(), "test-access-token")
 
 	// アサーション
 	assert.NoError(t, err)
@@ -131,3 +183,226 @@ func TestGetUserInfo(t *testing.T) {
 	assert.Equal(t, "https://example.com/picture.jpg", userInfo.Picture)
 	assert.Equal(t, int64(1234567890), userInfo.UpdatedAt)
 }
+
+func TestIntrospectToken(t *testing.T) {
+	// テストケースの準備
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	authUseCase := NewAuthUseCase(mock.NewMockAuthRepository(), mock.NewMockSessionRepository(), mockAuthleteClient, &config.Config{}, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+
+	// モックの設定
+	mockAuthleteClient.Introspection = &entity.IntrospectionResponse{
+		AuthleteResult: entity.AuthleteResult{Action: entity.ActionOK},
+		Subject:        "test-user-id",
+		Scopes:         []string{"openid", "profile"},
+		ExpiresAt:      1700000000000,
+		Existent:       true,
+		Usable:         true,
+		Sufficient:     true,
+	}
+
+	// テスト実行
+	result, err := authUseCase.IntrospectToken(context.Background(), "test-access-token", []string{"profile"}, "")
+
+	// アサーション
+	assert.NoError(t, err)
+	assert.True(t, result.Active)
+	assert.Equal(t, "test-user-id", result.Subject)
+	assert.Equal(t, []string{"openid", "profile"}, result.Scopes)
+	assert.Equal(t, int64(1700000000), result.ExpiresAt.Unix())
+
+	// スコープが不足している場合は無効として扱う
+	mockAuthleteClient.Introspection = &entity.IntrospectionResponse{
+		AuthleteResult: entity.AuthleteResult{Action: entity.ActionForbidden},
+		Existent:       true,
+		Usable:         true,
+	}
+	result, err = authUseCase.IntrospectToken(context.Background(), "test-access-token", []string{"admin"}, "")
+	assert.NoError(t, err)
+	assert.False(t, result.Active)
+}
+
+func TestIntrospectStandard(t *testing.T) {
+	// テストケースの準備
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	cfg := &config.Config{
+		IntrospectionClients: map[string]string{"resource-server": "rs-secret"},
+	}
+	authUseCase := NewAuthUseCase(mock.NewMockAuthRepository(), mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+
+	// モックの設定
+	mockAuthleteClient.Standard = &entity.StandardIntrospectionResponse{
+		AuthleteResult:  entity.AuthleteResult{Action: entity.ActionOK},
+		ResponseContent: `{"active":true,"sub":"test-user-id"}`,
+	}
+
+	// テスト実行
+	content, err := authUseCase.IntrospectStandard(context.Background(), "resource-server", "rs-secret", map[string]string{"token": "test-access-token"})
+
+	// アサーション
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"active":true,"sub":"test-user-id"}`, content)
+
+	// クライアント認証に失敗した場合はAuthleteに問い合わせない
+	_, err = authUseCase.IntrospectStandard(context.Background(), "resource-server", "wrong-secret", map[string]string{"token": "test-access-token"})
+	assert.ErrorIs(t, err, ErrInvalidClient)
+	_, err = authUseCase.IntrospectStandard(context.Background(), "unknown", "rs-secret", map[string]string{"token": "test-access-token"})
+	assert.ErrorIs(t, err, ErrInvalidClient)
+}
+
+func TestDeleteSessionRevokesTokens(t *testing.T) {
+	// テストケースの準備
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	cfg := &config.Config{AuthleteClientID: "bff-client", AuthleteClientSecret: "bff-secret"}
+	authUseCase := NewAuthUseCase(mock.NewMockAuthRepository(), mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+	authUseCase.StoreSession(&entity.Session{
+		ID: "test-session-id",
+		Tokens: entity.Tokens{
+			AccessToken:  "test-access-token",
+			RefreshToken: "test-refresh-token",
+		},
+	})
+
+	// テスト実行
+	err := authUseCase.DeleteSession(context.Background(), "test-session-id")
+
+	// アサーション
+	assert.NoError(t, err)
+	assert.Len(t, mockAuthleteClient.Revoked, 2)
+	assert.Equal(t, "test-refresh-token", mockAuthleteClient.Revoked[0].Token)
+	assert.Equal(t, "refresh_token", mockAuthleteClient.Revoked[0].TokenTypeHint)
+	assert.Equal(t, "test-access-token", mockAuthleteClient.Revoked[1].Token)
+	// ログアウト時はBFF自身のクライアント認証情報で失効させる
+	assert.Equal(t, "bff-client", mockAuthleteClient.Revoked[0].ClientID)
+	assert.Equal(t, "bff-secret", mockAuthleteClient.Revoked[1].ClientSecret)
+
+	_, err = authUseCase.GetAccessToken(context.Background(), "test-session-id")
+	assert.Error(t, err)
+}
+
+func TestGetAccessTokenRefreshesExpiringToken(t *testing.T) {
+	// テストケースの準備
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	authUseCase := NewAuthUseCase(mock.NewMockAuthRepository(), mock.NewMockSessionRepository(), mockAuthleteClient, &config.Config{}, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+	authUseCase.StoreSession(&entity.Session{
+		ID: "test-session-id",
+		Tokens: entity.Tokens{
+			AccessToken:  "old-access-token",
+			RefreshToken: "old-refresh-token",
+			IDToken:      "test-id-token",
+			ExpiresAt:    time.Now().Add(10 * time.Second),
+		},
+	})
+
+	// モックの設定
+	mockAuthleteClient.TokenResponse = &entity.TokenResponse{
+		AccessToken:          "new-access-token",
+		RefreshToken:         "new-refresh-token",
+		AccessTokenExpiresAt: time.Now().Add(time.Hour).UnixMilli(),
+	}
+
+	// テスト実行（同時リクエストでもリフレッシュは1回）
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			accessToken, err := authUseCase.GetAccessToken(context.Background(), "test-session-id")
+			assert.NoError(t, err)
+			results[i] = accessToken
+		}(i)
+	}
+	wg.Wait()
+
+	// アサーション
+	for _, accessToken := range results {
+		assert.Equal(t, "new-access-token", accessToken)
+	}
+	assert.Len(t, mockAuthleteClient.TokenRequests, 1)
+	assert.Equal(t, "refresh_token", mockAuthleteClient.TokenRequests[0]["grant_type"])
+	assert.Equal(t, "old-refresh-token", mockAuthleteClient.TokenRequests[0]["refresh_token"])
+
+	// リフレッシュ後のアクセストークンは更新不要
+	accessToken, err := authUseCase.GetAccessToken(context.Background(), "test-session-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-access-token", accessToken)
+	assert.Len(t, mockAuthleteClient.TokenRequests, 1)
+}
+
+func TestGetAccessTokenInvalidRefreshToken(t *testing.T) {
+	// テストケースの準備
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	authUseCase := NewAuthUseCase(mock.NewMockAuthRepository(), mock.NewMockSessionRepository(), mockAuthleteClient, &config.Config{}, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+	authUseCase.StoreSession(&entity.Session{
+		ID: "test-session-id",
+		Tokens: entity.Tokens{
+			AccessToken:  "old-access-token",
+			RefreshToken: "old-refresh-token",
+			ExpiresAt:    time.Now().Add(-time.Second),
+		},
+	})
+
+	// モックの設定
+	mockAuthleteClient.Error = &entity.AuthleteActionError{API: "/auth/token", Action: entity.ActionBadRequest}
+
+	// テスト実行
+	_, err := authUseCase.GetAccessToken(context.Background(), "test-session-id")
+
+	// アサーション
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	_, err = authUseCase.GetAccessToken(context.Background(), "test-session-id")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestStoreSession(t *testing.T) {
+	// テストケースの準備
+	mockSessionRepo := mock.NewMockSessionRepository()
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	authUseCase := NewAuthUseCase(mock.NewMockAuthRepository(), mockSessionRepo, mockAuthleteClient, &config.Config{}, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+
+	// テスト実行
+	err := authUseCase.StoreSession(&entity.Session{
+		ID:        "test-session-id",
+		Tokens:    entity.Tokens{AccessToken: "test-access-token", Subject: "test-user-id"},
+		IPAddress: "192.0.2.1",
+		UserAgent: "test-agent",
+	})
+
+	// アサーション
+	assert.NoError(t, err)
+	sessions, err := mockSessionRepo.ListByUser("test-user-id")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "192.0.2.1", sessions[0].IPAddress)
+
+	accessToken, err := authUseCase.GetAccessToken(context.Background(), "test-session-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-access-token", accessToken)
+	assert.False(t, mockSessionRepo.Sessions["test-session-id"].LastSeenAt.IsZero())
+}
+
+func TestLoginWithPasskey(t *testing.T) {
+	// テストケースの準備
+	mockAuthRepo := mock.NewMockAuthRepository()
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	mockAuthRepo.StoreAuthData("test-state", entity.AuthData{CodeVerifier: "test-code-verifier", Ticket: "test-ticket"})
+	mockAuthleteClient.AuthResponse = &entity.AuthResponse{
+		ResponseContent: "https://client.example.com/cb?code=test-code",
+	}
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, &config.Config{}, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+
+	// テスト実行
+	response, err := authUseCase.LoginWithPasskey(context.Background(), "test-state", &entity.User{ID: "test-user-id", Username: "test-user"})
+
+	// アサーション
+	assert.NoError(t, err)
+	assert.Equal(t, "https://client.example.com/cb?code=test-code&state=test-state", response)
+	assert.Equal(t, "test-ticket", mockAuthleteClient.IssueRequest.Ticket)
+	assert.Equal(t, "test-user-id", mockAuthleteClient.IssueRequest.Subject)
+	assert.Equal(t, entity.ACRPhishingResistant, mockAuthleteClient.IssueRequest.ACR)
+	assert.JSONEq(t, `{"name":"test-user","amr":["hwk","user"]}`, mockAuthleteClient.IssueRequest.Claims)
+
+	// 不明な state では認可を発行しない
+	_, err = authUseCase.LoginWithPasskey(context.Background(), "unknown-state", &entity.User{ID: "test-user-id"})
+	assert.Error(t, err)
+}
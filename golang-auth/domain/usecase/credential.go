@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+	"github.com/yamakenji24/golang-auth/pkg/password"
+)
+
+// ErrInvalidCredentials はメールアドレスまたはパスワードが一致しないことを表します
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// InvalidCredentialsError はログイン時の認証失敗を表すエラーです
+// RedirectURL にはAuthleteが生成したクライアント向けのエラーリダイレクト先が入ります
+type InvalidCredentialsError struct {
+	RedirectURL string
+}
+
+func (e *InvalidCredentialsError) Error() string {
+	return ErrInvalidCredentials.Error()
+}
+
+func (e *InvalidCredentialsError) Unwrap() error {
+	return ErrInvalidCredentials
+}
+
+// CredentialVerifier はログイン時の認証情報を検証するインターフェースです
+type CredentialVerifier interface {
+	Verify(email, password string) (*entity.User, error)
+}
+
+type passwordCredentialVerifier struct {
+	userRepo repository.UserRepository
+	hasher   *password.Hasher
+	// dummyHash はユーザーが存在しない場合にも比較処理を行い、応答時間の差を抑えるためのハッシュです
+	dummyHash string
+}
+
+// NewPasswordCredentialVerifier はユーザーリポジトリのパスワードハッシュで検証するCredentialVerifierを作成します
+// 保存済みのハッシュが古い形式・パラメータの場合は、ログインに成功したときに hasher のパラメータで作り直します
+func NewPasswordCredentialVerifier(userRepo repository.UserRepository, hasher *password.Hasher) CredentialVerifier {
+	// 乱数の生成に失敗した場合のみエラーになり、その場合は比較処理を省略する
+	dummyHash, _ := hasher.Hash("dummy-password")
+	return &passwordCredentialVerifier{
+		userRepo:  userRepo,
+		hasher:    hasher,
+		dummyHash: dummyHash,
+	}
+}
+
+func (v *passwordCredentialVerifier) Verify(email, plaintext string) (*entity.User, error) {
+	if email == "" || plaintext == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	// 登録時と同じ形に揃えてから検索する（形式が正しくない場合は存在しないユーザーと同じ扱い）
+	var user *entity.User
+	email, err := normalizeEmail(email)
+	if err == nil {
+		user, err = v.userRepo.FindByEmail(email)
+	}
+	if err != nil || user == nil || user.PasswordHash == "" {
+		v.hasher.Verify(plaintext, v.dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+
+	rehash, err := v.hasher.Verify(plaintext, user.PasswordHash)
+	if err != nil {
+		if !errors.Is(err, password.ErrMismatch) {
+			logger.LogWarning("failed to verify password hash of user %s: %v", user.ID, err)
+		}
+		return nil, ErrInvalidCredentials
+	}
+	// 停止中のアカウントかどうかは、パスワードが一致した場合も区別せずに拒否する
+	if !user.IsActive() {
+		return nil, ErrInvalidCredentials
+	}
+
+	// ハッシュの作り直しに失敗してもログインは続ける（次回のログインで再び試みる）
+	if rehash {
+		if hash, err := v.hasher.Hash(plaintext); err != nil {
+			logger.LogWarning("failed to rehash password of user %s: %v", user.ID, err)
+		} else {
+			user.PasswordHash = hash
+			if err := v.userRepo.Save(user); err != nil {
+				logger.LogWarning("failed to save rehashed password of user %s: %v", user.ID, err)
+			}
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// ErrInvalidClient はリソースサーバーのクライアント認証に失敗したことを表します
+var ErrInvalidClient = errors.New("invalid client")
+
+// IntrospectToken アクセストークンが有効か、要求するスコープとsubjectを満たすかを検証
+func (u *authUseCase) IntrospectToken(ctx context.Context, token string, scopes []string, subject string) (entity.TokenIntrospection, error) {
+	resp, err := u.authleteClient.Introspect(ctx, token, scopes, subject)
+	if err != nil {
+		return entity.TokenIntrospection{}, err
+	}
+
+	if resp.Action != entity.ActionOK || !resp.Usable || !resp.Sufficient {
+		return entity.TokenIntrospection{Active: false}, nil
+	}
+
+	return entity.TokenIntrospection{
+		Active:    true,
+		Subject:   resp.Subject,
+		Scopes:    resp.Scopes,
+		ExpiresAt: time.UnixMilli(resp.ExpiresAt),
+	}, nil
+}
+
+// IntrospectStandard リソースサーバーを認証した上で、RFC 7662形式のイントロスペクションレスポンスを生成
+func (u *authUseCase) IntrospectStandard(ctx context.Context, clientID, clientSecret string, params map[string]string) (string, error) {
+	if !u.authenticateResourceServer(clientID, clientSecret) {
+		return "", ErrInvalidClient
+	}
+
+	resp, err := u.authleteClient.IntrospectStandard(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	return resp.ResponseContent, nil
+}
+
+// authenticateResourceServer は設定されたリソースサーバーのクライアントIDとシークレットを検証
+func (u *authUseCase) authenticateResourceServer(clientID, clientSecret string) bool {
+	expected, ok := u.config.IntrospectionClients[clientID]
+	if !ok || clientID == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(clientSecret), []byte(expected)) == 1
+}
@@ -0,0 +1,306 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/totp"
+)
+
+var (
+	// ErrInvalidMFACode はTOTPのコードまたはリカバリーコードが正しくない、または使用済みであることを表します
+	ErrInvalidMFACode = errors.New("invalid verification code")
+	// ErrTOTPAlreadyEnabled はTOTPが既に有効であることを表します
+	ErrTOTPAlreadyEnabled = errors.New("totp is already enabled")
+	// ErrTOTPNotEnabled はTOTPが有効でない（登録を始めていない）ことを表します
+	ErrTOTPNotEnabled = errors.New("totp is not enabled")
+	// ErrMFARequired はパスワードの確認が済み、2要素目の入力が必要であることを表します
+	ErrMFARequired = errors.New("second factor required")
+)
+
+// recoveryCodeCount は一度に発行するリカバリーコードの数です
+const recoveryCodeCount = 10
+
+// recoveryCodeSize はリカバリーコードのバイト数です（Base32で16文字、80ビット）
+const recoveryCodeSize = 10
+
+// MFARequiredError はパスワードを確認した後、2要素目の入力を求めるエラーです
+// MFAToken を2要素目のリクエストと一緒に送ってもらいます
+type MFARequiredError struct {
+	MFAToken string
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// SecondFactorVerifier はログインの2要素目を検証するインターフェースです（MFAUseCase が実装します）
+type SecondFactorVerifier interface {
+	// Enabled はユーザーが2要素認証を有効にしているかを返します
+	Enabled(ctx context.Context, userID string) (bool, error)
+	// Verify はTOTPのコードまたはリカバリーコードを検証し、ログインするユーザーを返します
+	Verify(ctx context.Context, userID, code string) (*entity.User, error)
+}
+
+// TOTPEnrollment は登録を始めたTOTPを認証アプリに登録するための情報です
+type TOTPEnrollment struct {
+	// Secret は手入力用のBase32の秘密鍵です
+	Secret string
+	URI    string
+	// QRCode は URI のQRコードのPNG画像です
+	QRCode []byte
+}
+
+// MFAStatus はユーザーの2要素認証の状態です
+type MFAStatus struct {
+	TOTPEnabled            bool
+	RecoveryCodesRemaining int
+}
+
+// MFAUseCase はTOTPによる2要素認証の登録と検証を行います
+type MFAUseCase struct {
+	userRepo repository.UserRepository
+	totpRepo repository.TOTPRepository
+	cfg      config.MFAConfig
+	now      func() time.Time
+}
+
+// NewMFAUseCase は新しい2要素認証ユースケースを作成します
+func NewMFAUseCase(userRepo repository.UserRepository, totpRepo repository.TOTPRepository, cfg config.MFAConfig) *MFAUseCase {
+	return &MFAUseCase{
+		userRepo: userRepo,
+		totpRepo: totpRepo,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// StartTOTPEnrollment は新しい秘密鍵を生成してTOTPの登録を始めます
+// 登録は ConfirmTOTPEnrollment でコードを確認するまで完了せず、ログインには使いません
+func (u *MFAUseCase) StartTOTPEnrollment(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	user, err := u.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential, err := u.totpRepo.Get(userID); err == nil && credential.Confirmed() {
+		return nil, ErrTOTPAlreadyEnabled
+	} else if err != nil && !errors.Is(err, entity.ErrTOTPNotFound) {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	// 登録の途中の秘密鍵は上書きする
+	if err := u.totpRepo.Save(&entity.TOTPCredential{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: u.now(),
+	}); err != nil {
+		return nil, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+	uri := totp.URI(secret, u.cfg.TOTPIssuer, account)
+	qrCode, err := totp.QRCode(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    uri,
+		QRCode: qrCode,
+	}, nil
+}
+
+// ConfirmTOTPEnrollment は認証アプリのコードを確認してTOTPの登録を完了し、リカバリーコードを発行します
+// リカバリーコードは平文ではこのときしか返さないため、ユーザーに控えてもらいます
+func (u *MFAUseCase) ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	if _, err := u.activeUser(userID); err != nil {
+		return nil, err
+	}
+	credential, err := u.totpRepo.Get(userID)
+	if errors.Is(err, entity.ErrTOTPNotFound) {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if credential.Confirmed() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(credential.Secret, code, u.now(), u.cfg.TOTPSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	// 確認に使ったコードはログインに使えないようにする
+	credential.ConfirmedAt = u.now()
+	credential.LastUsedStep = step
+	if err := u.totpRepo.Save(credential); err != nil {
+		return nil, err
+	}
+	return u.issueRecoveryCodes(userID)
+}
+
+// DisableTOTP はTOTPのコードまたはリカバリーコードを確認して、TOTPとリカバリーコードを削除します
+func (u *MFAUseCase) DisableTOTP(ctx context.Context, userID, code string) error {
+	if _, err := u.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return u.totpRepo.Delete(userID)
+}
+
+// RegenerateRecoveryCodes はTOTPのコードまたはリカバリーコードを確認して、リカバリーコードを発行し直します
+// 以前のリカバリーコードは使えなくなります
+func (u *MFAUseCase) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if _, err := u.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	return u.issueRecoveryCodes(userID)
+}
+
+// Status はユーザーの2要素認証の状態を返します
+func (u *MFAUseCase) Status(ctx context.Context, userID string) (*MFAStatus, error) {
+	enabled, err := u.Enabled(ctx, userID)
+	if err != nil || !enabled {
+		return &MFAStatus{}, err
+	}
+	remaining, err := u.totpRepo.CountRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAStatus{TOTPEnabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// Enabled はユーザーがTOTPの登録を完了しているかを返します
+func (u *MFAUseCase) Enabled(ctx context.Context, userID string) (bool, error) {
+	credential, err := u.totpRepo.Get(userID)
+	if errors.Is(err, entity.ErrTOTPNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return credential.Confirmed(), nil
+}
+
+// Verify は6桁の数字をTOTPのコード、それ以外をリカバリーコードとして検証します
+// TOTPのコードは受け付けた時間ステップを記録し、リカバリーコードは削除するため、どちらも一度しか使えません
+func (u *MFAUseCase) Verify(ctx context.Context, userID, code string) (*entity.User, error) {
+	user, err := u.activeUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := u.totpRepo.Get(userID)
+	if errors.Is(err, entity.ErrTOTPNotFound) {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !credential.Confirmed() {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		step, ok := totp.Validate(credential.Secret, code, u.now(), u.cfg.TOTPSkew)
+		if !ok {
+			return nil, ErrInvalidMFACode
+		}
+		if err := u.totpRepo.UpdateLastUsedStep(userID, step); err != nil {
+			if errors.Is(err, entity.ErrTOTPStepUsed) {
+				return nil, ErrInvalidMFACode
+			}
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if err := u.totpRepo.UseRecoveryCode(userID, hashRecoveryCode(code)); err != nil {
+		if errors.Is(err, entity.ErrRecoveryCodeNotFound) {
+			return nil, ErrInvalidMFACode
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// activeUser はログインできる状態のユーザーを返します
+func (u *MFAUseCase) activeUser(userID string) (*entity.User, error) {
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, ErrAccountInactive
+	}
+	return user, nil
+}
+
+// issueRecoveryCodes はリカバリーコードを生成し、ハッシュだけを保存して平文を返します
+func (u *MFAUseCase) issueRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+	if err := u.totpRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// isTOTPCode は code が6桁の数字かを返します
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCode は「xxxx-xxxx-xxxx-xxxx」形式のリカバリーコードを生成します
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	encoded := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+	return encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16], nil
+}
+
+// hashRecoveryCode は区切り文字と大文字・小文字の違いを無視してリカバリーコードのハッシュを返します
+// リカバリーコードは十分なエントロピーを持つため、パスワードと違い低速なハッシュは使いません
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/totp"
+)
+
+var testMFAConfig = config.MFAConfig{
+	TOTPIssuer:   "Passkey Demo",
+	TOTPSkew:     1,
+	ChallengeTTL: 5 * time.Minute,
+	MaxAttempts:  3,
+}
+
+// newTestMFAUseCase はTOTPの登録を完了したユーザーとリカバリーコードを用意します
+func newTestMFAUseCase(t *testing.T, userRepo *mock.MockUserRepository) (*MFAUseCase, *mock.MockTOTPRepository, []string) {
+	t.Helper()
+	totpRepo := mock.NewMockTOTPRepository()
+	mfaUseCase := NewMFAUseCase(userRepo, totpRepo, testMFAConfig)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mfaUseCase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	_, err := mfaUseCase.StartTOTPEnrollment(ctx, "test-user-id")
+	require.NoError(t, err)
+	secret := totpRepo.Credentials["test-user-id"].Secret
+	codes, err := mfaUseCase.ConfirmTOTPEnrollment(ctx, "test-user-id", totp.Code(secret, totp.Step(now)))
+	require.NoError(t, err)
+
+	// 以降のコードは次の時間ステップで入力する
+	now = now.Add(totp.Period)
+	return mfaUseCase, totpRepo, codes
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	userRepo := mock.NewMockUserRepository()
+	userRepo.Save(newTestUser(t, "test-password"))
+	totpRepo := mock.NewMockTOTPRepository()
+	mfaUseCase := NewMFAUseCase(userRepo, totpRepo, testMFAConfig)
+
+	// テスト実行
+	enrollment, err := mfaUseCase.StartTOTPEnrollment(ctx, "test-user-id")
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Len(t, enrollment.Secret, 32)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Passkey%20Demo:test@example.com?")
+	assert.Equal(t, []byte("\x89PNG"), enrollment.QRCode[:4])
+
+	// 登録を完了するまではログインの2要素目に使わない
+	enabled, err := mfaUseCase.Enabled(ctx, "test-user-id")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	_, err = mfaUseCase.ConfirmTOTPEnrollment(ctx, "test-user-id", "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// テスト実行
+	secret := totpRepo.Credentials["test-user-id"].Secret
+	codes, err := mfaUseCase.ConfirmTOTPEnrollment(ctx, "test-user-id", totp.Code(secret, totp.Step(time.Now())))
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+	assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, codes[0])
+	status, err := mfaUseCase.Status(ctx, "test-user-id")
+	require.NoError(t, err)
+	assert.Equal(t, &MFAStatus{TOTPEnabled: true, RecoveryCodesRemaining: 10}, status)
+
+	// 有効なTOTPは登録し直せない
+	_, err = mfaUseCase.StartTOTPEnrollment(ctx, "test-user-id")
+	assert.ErrorIs(t, err, ErrTOTPAlreadyEnabled)
+}
+
+func TestMFAVerify(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	userRepo := mock.NewMockUserRepository()
+	userRepo.Save(newTestUser(t, "test-password"))
+	mfaUseCase, totpRepo, codes := newTestMFAUseCase(t, userRepo)
+	credential := totpRepo.Credentials["test-user-id"]
+	code := totp.Code(credential.Secret, totp.Step(mfaUseCase.now()))
+
+	// テスト実行
+	user, err := mfaUseCase.Verify(ctx, "test-user-id", code)
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-id", user.ID)
+
+	// 同じコードは再び使えない
+	_, err = mfaUseCase.Verify(ctx, "test-user-id", code)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// リカバリーコードは区切り文字や大文字・小文字を問わず、一度だけ使える
+	_, err = mfaUseCase.Verify(ctx, "test-user-id", "ABCD-EFGH-IJKL-MNOP")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = mfaUseCase.Verify(ctx, "test-user-id", " "+codes[0][:9]+" "+codes[0][10:]+" ")
+	require.NoError(t, err)
+	_, err = mfaUseCase.Verify(ctx, "test-user-id", codes[0])
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	status, err := mfaUseCase.Status(ctx, "test-user-id")
+	require.NoError(t, err)
+	assert.Equal(t, 9, status.RecoveryCodesRemaining)
+
+	// 停止中のアカウントは拒否する
+	suspended := newTestUser(t, "test-password")
+	suspended.Status = entity.UserStatusSuspended
+	userRepo.Save(suspended)
+	_, err = mfaUseCase.Verify(ctx, "test-user-id", codes[1])
+	assert.ErrorIs(t, err, ErrAccountInactive)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	userRepo := mock.NewMockUserRepository()
+	userRepo.Save(newTestUser(t, "test-password"))
+	mfaUseCase, totpRepo, codes := newTestMFAUseCase(t, userRepo)
+
+	// テスト実行
+	assert.ErrorIs(t, mfaUseCase.DisableTOTP(ctx, "test-user-id", "000000"), ErrInvalidMFACode)
+	err := mfaUseCase.DisableTOTP(ctx, "test-user-id", codes[0])
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Empty(t, totpRepo.Credentials)
+	assert.Empty(t, totpRepo.RecoveryCodes)
+	_, err = mfaUseCase.Verify(ctx, "test-user-id", codes[1])
+	assert.ErrorIs(t, err, ErrTOTPNotEnabled)
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	userRepo := mock.NewMockUserRepository()
+	userRepo.Save(newTestUser(t, "test-password"))
+	mfaUseCase, totpRepo, codes := newTestMFAUseCase(t, userRepo)
+	code := totp.Code(totpRepo.Credentials["test-user-id"].Secret, totp.Step(mfaUseCase.now()))
+
+	// テスト実行
+	regenerated, err := mfaUseCase.RegenerateRecoveryCodes(ctx, "test-user-id", code)
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Len(t, regenerated, 10)
+	assert.NotEqual(t, codes, regenerated)
+	// 以前のリカバリーコードは使えない
+	_, err = mfaUseCase.Verify(ctx, "test-user-id", codes[1])
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestLoginRequiresSecondFactor(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	mockAuthRepo := mock.NewMockAuthRepository()
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	mockUserRepo := mock.NewMockUserRepository()
+	mockUserRepo.Save(newTestUser(t, "test-password"))
+	mfaUseCase, totpRepo, codes := newTestMFAUseCase(t, mockUserRepo)
+	challengeRepo := mock.NewMockMFAChallengeRepository()
+	cfg := &config.Config{MFA: testMFAConfig}
+
+	state := "test-state"
+	mockAuthRepo.StoreAuthData(state, entity.AuthData{CodeVerifier: "test-code-verifier", Ticket: "test-ticket"})
+	mockAuthleteClient.AuthResponse = &entity.AuthResponse{
+		ResponseContent: "https://client.example.com/cb?code=test-code",
+	}
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mockUserRepo, testHasher), mfaUseCase, challengeRepo)
+
+	// テスト実行
+	_, err := authUseCase.Login(ctx, entity.AuthRequest{State: state, Email: "test@example.com", Password: "test-password"})
+
+	// アサーション
+	var mfaErr *MFARequiredError
+	require.ErrorAs(t, err, &mfaErr)
+	assert.NotEmpty(t, mfaErr.MFAToken)
+	// 2要素目を確認するまで認可を発行しない
+	assert.Empty(t, mockAuthleteClient.IssueRequest.Ticket)
+
+	// 誤ったコードでは発行せず、同じ MFAToken でやり直せる
+	_, err = authUseCase.LoginWithSecondFactor(ctx, entity.MFALoginRequest{State: state, MFAToken: mfaErr.MFAToken, Code: "000000"})
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	assert.Equal(t, 1, challengeRepo.Challenges[mfaErr.MFAToken].Attempts)
+
+	// 別の認可リクエストの state では使えない
+	_, err = authUseCase.LoginWithSecondFactor(ctx, entity.MFALoginRequest{State: "other-state", MFAToken: mfaErr.MFAToken, Code: codes[0]})
+	assert.Error(t, err)
+
+	// テスト実行
+	code := totp.Code(totpRepo.Credentials["test-user-id"].Secret, totp.Step(mfaUseCase.now()))
+	response, err := authUseCase.LoginWithSecondFactor(ctx, entity.MFALoginRequest{State: state, MFAToken: mfaErr.MFAToken, Code: code})
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "https://client.example.com/cb?code=test-code&state=test-state", response)
+	assert.Equal(t, "test-user-id", mockAuthleteClient.IssueRequest.Subject)
+	assert.JSONEq(t, `{"name":"test-user","email":"test@example.com","amr":["pwd","otp","mfa"]}`, mockAuthleteClient.IssueRequest.Claims)
+
+	// 使用済みの MFAToken は使えない
+	_, err = authUseCase.LoginWithSecondFactor(ctx, entity.MFALoginRequest{State: state, MFAToken: mfaErr.MFAToken, Code: codes[0]})
+	assert.ErrorIs(t, err, entity.ErrMFAChallengeNotFound)
+}
+
+func TestLoginWithSecondFactorTooManyAttempts(t *testing.T) {
+	// テストケースの準備
+	ctx := context.Background()
+	mockAuthRepo := mock.NewMockAuthRepository()
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	mockUserRepo := mock.NewMockUserRepository()
+	mockUserRepo.Save(newTestUser(t, "test-password"))
+	mfaUseCase, _, codes := newTestMFAUseCase(t, mockUserRepo)
+	challengeRepo := mock.NewMockMFAChallengeRepository()
+	cfg := &config.Config{MFA: testMFAConfig}
+
+	state := "test-state"
+	mockAuthRepo.StoreAuthData(state, entity.AuthData{Ticket: "test-ticket"})
+	mockAuthleteClient.FailResponse = &entity.AuthResponse{
+		ResponseContent: "https://client.example.com/cb?error=login_required",
+	}
+	authUseCase := NewAuthUseCase(mockAuthRepo, mock.NewMockSessionRepository(), mockAuthleteClient, cfg, mockAuthleteClient, NewPasswordCredentialVerifier(mockUserRepo, testHasher), mfaUseCase, challengeRepo)
+	_, err := authUseCase.Login(ctx, entity.AuthRequest{State: state, Email: "test@example.com", Password: "test-password"})
+	var mfaErr *MFARequiredError
+	require.ErrorAs(t, err, &mfaErr)
+	req := entity.MFALoginRequest{State: state, MFAToken: mfaErr.MFAToken, Code: "000000"}
+
+	// テスト実行
+	_, err = authUseCase.LoginWithSecondFactor(ctx, req)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = authUseCase.LoginWithSecondFactor(ctx, req)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = authUseCase.LoginWithSecondFactor(ctx, req)
+
+	// アサーション
+	var invalidErr *InvalidCredentialsError
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "https://client.example.com/cb?error=login_required&state=test-state", invalidErr.RedirectURL)
+	assert.Equal(t, "NOT_AUTHENTICATED", mockAuthleteClient.FailReason)
+
+	// 失敗したログインでは正しいコードも受け付けない
+	req.Code = codes[0]
+	_, err = authUseCase.LoginWithSecondFactor(ctx, req)
+	assert.ErrorIs(t, err, entity.ErrMFAChallengeNotFound)
+}
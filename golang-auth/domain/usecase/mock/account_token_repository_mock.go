@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type MockAccountTokenRepository struct {
+	Used map[string]time.Time
+}
+
+func NewMockAccountTokenRepository() *MockAccountTokenRepository {
+	return &MockAccountTokenRepository{
+		Used: make(map[string]time.Time),
+	}
+}
+
+func (m *MockAccountTokenRepository) MarkUsed(id string, expiresAt time.Time) error {
+	if _, ok := m.Used[id]; ok {
+		return entity.ErrAccountTokenUsed
+	}
+	m.Used[id] = expiresAt
+	return nil
+}
@@ -1,44 +1,90 @@
 package mock
 
 import (
+	"context"
+	"sync"
+
 	"github.com/yamakenji24/golang-auth/domain/entity"
 )
 
 type MockAuthleteClient struct {
 	AuthResponse  *entity.AuthResponse
+	FailResponse  *entity.AuthResponse
+	FailReason    string
+	IssueRequest  entity.AuthorizationIssueRequest
 	TokenResponse *entity.TokenResponse
+	TokenRequests []map[string]string
 	UserInfo      *entity.UserInfo
+	Introspection *entity.IntrospectionResponse
+	Standard      *entity.StandardIntrospectionResponse
+	Revoked       []entity.RevocationRequest
 	Error         error
+
+	mu sync.Mutex
 }
 
 func NewMockAuthleteClient() *MockAuthleteClient {
 	return &MockAuthleteClient{}
 }
 
-func (m *MockAuthleteClient) RequestAuthorization(params map[string]string) (*entity.AuthResponse, error) {
+func (m *MockAuthleteClient) RequestAuthorization(ctx context.Context, params map[string]string) (*entity.AuthResponse, error) {
 	if m.Error != nil {
 		return nil, m.Error
 	}
 	return m.AuthResponse, nil
 }
 
-func (m *MockAuthleteClient) IssueAuthorization(ticket string) (*entity.AuthResponse, error) {
+func (m *MockAuthleteClient) IssueAuthorization(ctx context.Context, req entity.AuthorizationIssueRequest) (*entity.AuthResponse, error) {
 	if m.Error != nil {
 		return nil, m.Error
 	}
+	m.IssueRequest = req
 	return m.AuthResponse, nil
 }
 
-func (m *MockAuthleteClient) ExchangeToken(params map[string]string) (*entity.TokenResponse, error) {
+func (m *MockAuthleteClient) FailAuthorization(ctx context.Context, ticket, reason string) (*entity.AuthResponse, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	m.FailReason = reason
+	return m.FailResponse, nil
+}
+
+func (m *MockAuthleteClient) ExchangeToken(ctx context.Context, params map[string]string) (*entity.TokenResponse, error) {
+	m.mu.Lock()
+	m.TokenRequests = append(m.TokenRequests, params)
+	m.mu.Unlock()
 	if m.Error != nil {
 		return nil, m.Error
 	}
 	return m.TokenResponse, nil
 }
 
-func (m *MockAuthleteClient) GetUserInfo(accessToken string) (entity.UserInfo, error) {
+func (m *MockAuthleteClient) GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error) {
 	if m.Error != nil {
 		return entity.UserInfo{}, m.Error
 	}
 	return *m.UserInfo, nil
 }
+
+func (m *MockAuthleteClient) Introspect(ctx context.Context, token string, scopes []string, subject string) (*entity.IntrospectionResponse, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Introspection, nil
+}
+
+func (m *MockAuthleteClient) IntrospectStandard(ctx context.Context, params map[string]string) (*entity.StandardIntrospectionResponse, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Standard, nil
+}
+
+func (m *MockAuthleteClient) Revoke(ctx context.Context, req entity.RevocationRequest) (*entity.RevocationResponse, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	m.Revoked = append(m.Revoked, req)
+	return &entity.RevocationResponse{AuthleteResult: entity.AuthleteResult{Action: entity.ActionOK}}, nil
+}
@@ -0,0 +1,30 @@
+package mock
+
+import (
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type MockCeremonyRepository struct {
+	Ceremonies map[string]*entity.WebAuthnCeremony
+}
+
+func NewMockCeremonyRepository() *MockCeremonyRepository {
+	return &MockCeremonyRepository{
+		Ceremonies: make(map[string]*entity.WebAuthnCeremony),
+	}
+}
+
+func (m *MockCeremonyRepository) Save(ceremony *entity.WebAuthnCeremony) error {
+	stored := *ceremony
+	m.Ceremonies[ceremony.ID] = &stored
+	return nil
+}
+
+func (m *MockCeremonyRepository) Consume(id string) (*entity.WebAuthnCeremony, error) {
+	ceremony, ok := m.Ceremonies[id]
+	if !ok {
+		return nil, entity.ErrCeremonyNotFound
+	}
+	delete(m.Ceremonies, id)
+	return ceremony, nil
+}
@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// MockMailer は送信したメールを保持します
+type MockMailer struct {
+	Sent    []entity.Mail
+	SendErr error
+}
+
+func NewMockMailer() *MockMailer {
+	return &MockMailer{}
+}
+
+func (m *MockMailer) Send(ctx context.Context, mail entity.Mail) error {
+	if m.SendErr != nil {
+		return m.SendErr
+	}
+	m.Sent = append(m.Sent, mail)
+	return nil
+}
@@ -0,0 +1,30 @@
+package mock
+
+import (
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type MockMFAChallengeRepository struct {
+	Challenges map[string]*entity.MFAChallenge
+}
+
+func NewMockMFAChallengeRepository() *MockMFAChallengeRepository {
+	return &MockMFAChallengeRepository{
+		Challenges: make(map[string]*entity.MFAChallenge),
+	}
+}
+
+func (m *MockMFAChallengeRepository) Save(challenge *entity.MFAChallenge) error {
+	stored := *challenge
+	m.Challenges[challenge.ID] = &stored
+	return nil
+}
+
+func (m *MockMFAChallengeRepository) Consume(id string) (*entity.MFAChallenge, error) {
+	challenge, ok := m.Challenges[id]
+	if !ok {
+		return nil, entity.ErrMFAChallengeNotFound
+	}
+	delete(m.Challenges, id)
+	return challenge, nil
+}
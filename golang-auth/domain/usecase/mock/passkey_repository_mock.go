@@ -0,0 +1,91 @@
+package mock
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type MockPasskeyRepository struct {
+	Credentials map[string]*entity.Credential
+}
+
+func NewMockPasskeyRepository() *MockPasskeyRepository {
+	return &MockPasskeyRepository{
+		Credentials: make(map[string]*entity.Credential),
+	}
+}
+
+func (m *MockPasskeyRepository) SaveCredential(credential *entity.Credential) error {
+	if _, exists := m.Credentials[credential.ID]; exists {
+		return entity.ErrCredentialAlreadyRegistered
+	}
+	m.Credentials[credential.ID] = credential
+	return nil
+}
+
+func (m *MockPasskeyRepository) GetCredential(id string) (*entity.Credential, error) {
+	return m.Credentials[id], nil
+}
+
+func (m *MockPasskeyRepository) GetCredentialsByUsername(username string) ([]*entity.Credential, error) {
+	var credentials []*entity.Credential
+	for _, credential := range m.Credentials {
+		if credential.Username == username {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (m *MockPasskeyRepository) GetCredentialsByUserHandle(userHandle []byte) ([]*entity.Credential, error) {
+	var credentials []*entity.Credential
+	for _, credential := range m.Credentials {
+		if bytes.Equal(credential.UserHandle, userHandle) {
+			credentials = append(credentials, credential)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].ID < credentials[j].ID })
+	return credentials, nil
+}
+
+func (m *MockPasskeyRepository) UpdateNickname(id, nickname string) error {
+	credential, ok := m.Credentials[id]
+	if !ok {
+		return entity.ErrCredentialNotFound
+	}
+	credential.Nickname = nickname
+	return nil
+}
+
+func (m *MockPasskeyRepository) UpdateLastUsed(id string, usedAt time.Time, backupState bool) error {
+	credential, ok := m.Credentials[id]
+	if !ok {
+		return entity.ErrCredentialNotFound
+	}
+	credential.LastUsedAt = usedAt
+	credential.BackupState = backupState
+	return nil
+}
+
+func (m *MockPasskeyRepository) DeleteCredential(id string) error {
+	if _, ok := m.Credentials[id]; !ok {
+		return entity.ErrCredentialNotFound
+	}
+	delete(m.Credentials, id)
+	return nil
+}
+
+func (m *MockPasskeyRepository) UpdateSignCount(id string, signCount uint32) error {
+	credential, ok := m.Credentials[id]
+	if !ok {
+		return entity.ErrCredentialNotFound
+	}
+	if signCount <= credential.SignCount && !(signCount == 0 && credential.SignCount == 0) {
+		return entity.ErrSignCountNotIncreased
+	}
+	credential.SignCount = signCount
+	return nil
+}
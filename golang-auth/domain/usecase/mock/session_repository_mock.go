@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type MockSessionRepository struct {
+	Sessions map[string]*entity.Session
+	mu       sync.Mutex
+}
+
+func NewMockSessionRepository() *MockSessionRepository {
+	return &MockSessionRepository{
+		Sessions: make(map[string]*entity.Session),
+	}
+}
+
+func (m *MockSessionRepository) Create(session *entity.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *session
+	m.Sessions[session.ID] = &stored
+	return nil
+}
+
+func (m *MockSessionRepository) Get(id string) (*entity.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.Sessions[id]
+	if !ok {
+		return nil, entity.ErrSessionNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (m *MockSessionRepository) Touch(id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.Sessions[id]
+	if !ok {
+		return entity.ErrSessionNotFound
+	}
+	session.LastSeenAt = at
+	return nil
+}
+
+func (m *MockSessionRepository) UpdateTokens(id string, tokens entity.Tokens) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.Sessions[id]
+	if !ok {
+		return entity.ErrSessionNotFound
+	}
+	session.Tokens = tokens
+	return nil
+}
+
+func (m *MockSessionRepository) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Sessions, id)
+	return nil
+}
+
+func (m *MockSessionRepository) ListByUser(userID string) ([]*entity.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sessions []*entity.Session
+	for _, session := range m.Sessions {
+		if session.UserID == userID {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
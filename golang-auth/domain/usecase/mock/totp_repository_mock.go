@@ -0,0 +1,71 @@
+package mock
+
+import (
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type MockTOTPRepository struct {
+	Credentials   map[string]*entity.TOTPCredential
+	RecoveryCodes map[string]map[string]bool
+}
+
+func NewMockTOTPRepository() *MockTOTPRepository {
+	return &MockTOTPRepository{
+		Credentials:   make(map[string]*entity.TOTPCredential),
+		RecoveryCodes: make(map[string]map[string]bool),
+	}
+}
+
+func (m *MockTOTPRepository) Get(userID string) (*entity.TOTPCredential, error) {
+	credential, ok := m.Credentials[userID]
+	if !ok {
+		return nil, entity.ErrTOTPNotFound
+	}
+	found := *credential
+	return &found, nil
+}
+
+func (m *MockTOTPRepository) Save(credential *entity.TOTPCredential) error {
+	stored := *credential
+	m.Credentials[credential.UserID] = &stored
+	return nil
+}
+
+func (m *MockTOTPRepository) Delete(userID string) error {
+	delete(m.Credentials, userID)
+	delete(m.RecoveryCodes, userID)
+	return nil
+}
+
+func (m *MockTOTPRepository) UpdateLastUsedStep(userID string, step int64) error {
+	credential, ok := m.Credentials[userID]
+	if !ok {
+		return entity.ErrTOTPNotFound
+	}
+	if step <= credential.LastUsedStep {
+		return entity.ErrTOTPStepUsed
+	}
+	credential.LastUsedStep = step
+	return nil
+}
+
+func (m *MockTOTPRepository) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	codes := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		codes[hash] = true
+	}
+	m.RecoveryCodes[userID] = codes
+	return nil
+}
+
+func (m *MockTOTPRepository) UseRecoveryCode(userID, hash string) error {
+	if !m.RecoveryCodes[userID][hash] {
+		return entity.ErrRecoveryCodeNotFound
+	}
+	delete(m.RecoveryCodes[userID], hash)
+	return nil
+}
+
+func (m *MockTOTPRepository) CountRecoveryCodes(userID string) (int, error) {
+	return len(m.RecoveryCodes[userID]), nil
+}
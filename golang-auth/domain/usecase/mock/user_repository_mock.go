@@ -0,0 +1,50 @@
+package mock
+
+import (
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type MockUserRepository struct {
+	Users map[string]*entity.User
+}
+
+func NewMockUserRepository() *MockUserRepository {
+	return &MockUserRepository{
+		Users: make(map[string]*entity.User),
+	}
+}
+
+func (m *MockUserRepository) FindByID(id string) (*entity.User, error) {
+	if user, ok := m.Users[id]; ok {
+		return user, nil
+	}
+	return nil, entity.ErrUserNotFound
+}
+
+func (m *MockUserRepository) FindByUsername(username string) (*entity.User, error) {
+	for _, user := range m.Users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, entity.ErrUserNotFound
+}
+
+func (m *MockUserRepository) FindByEmail(email string) (*entity.User, error) {
+	for _, user := range m.Users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, entity.ErrUserNotFound
+}
+
+func (m *MockUserRepository) Save(user *entity.User) error {
+	m.Users[user.ID] = user
+	return nil
+}
+
+func (m *MockUserRepository) Delete(id string) error {
+	delete(m.Users, id)
+	return nil
+}
@@ -1,141 +1,367 @@
 package usecase
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/interface/repository"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
 )
 
+var (
+	// ErrRegistrationNotStarted は登録のセレモニーが見つからない（期限切れ・使用済みを含む）ことを表します
+	ErrRegistrationNotStarted = errors.New("passkey registration was not started")
+	// ErrAuthenticationNotStarted は認証のセレモニーが見つからない（期限切れ・使用済みを含む）ことを表します
+	ErrAuthenticationNotStarted = errors.New("passkey authentication was not started")
+	// ErrCredentialAlreadyRegistered は同じクレデンシャルIDが既に登録されていることを表します
+	ErrCredentialAlreadyRegistered = entity.ErrCredentialAlreadyRegistered
+	// ErrNoPasskeys はユーザーにパスキーが登録されていないことを表します
+	ErrNoPasskeys = errors.New("no passkeys registered")
+	// ErrInvalidNickname はパスキーの表示名が空、または長すぎることを表します
+	ErrInvalidNickname = errors.New("nickname must be 1 to 64 characters")
+)
+
+// maxNicknameLength はパスキーの表示名の最大文字数です
+const maxNicknameLength = 64
+
 // PasskeyUseCase はパスキー認証のユースケースを実装します
 type PasskeyUseCase struct {
-	passkeyRepo repository.PasskeyRepository
-	userRepo    repository.UserRepository
+	passkeyRepo  repository.PasskeyRepository
+	userRepo     repository.UserRepository
+	ceremonyRepo repository.CeremonyRepository
+	config       config.WebAuthnConfig
+	policy       AttestationPolicy
+	now          func() time.Time
 }
 
 // NewPasskeyUseCase は新しいパスキーユースケースを作成します
-func NewPasskeyUseCase(passkeyRepo repository.PasskeyRepository, userRepo repository.UserRepository) *PasskeyUseCase {
+// cfg は config.LoadConfig で検証済みのRelying Partyの設定で、policy が nil の場合はどの認証器も受け入れます
+func NewPasskeyUseCase(passkeyRepo repository.PasskeyRepository, userRepo repository.UserRepository, ceremonyRepo repository.CeremonyRepository, cfg config.WebAuthnConfig, policy AttestationPolicy) *PasskeyUseCase {
+	if policy == nil {
+		policy = NewAllowAnyAttestationPolicy()
+	}
 	return &PasskeyUseCase{
-		passkeyRepo: passkeyRepo,
-		userRepo:    userRepo,
+		passkeyRepo:  passkeyRepo,
+		userRepo:     userRepo,
+		ceremonyRepo: ceremonyRepo,
+		config:       cfg,
+		policy:       policy,
+		now:          time.Now,
+	}
+}
+
+// beginCeremony はチャレンジを生成し、セレモニーとして timeout の間だけ保存します
+func (u *PasskeyUseCase) beginCeremony(ceremony *entity.WebAuthnCeremony, timeout time.Duration) error {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return err
+	}
+
+	ceremony.ID = generateRandomString(32)
+	ceremony.Challenge = challenge
+	ceremony.RPID = u.config.RPID
+	ceremony.ExpiresAt = u.now().Add(timeout)
+	return u.ceremonyRepo.Save(ceremony)
+}
+
+// consumeCeremony は指定した種類のセレモニーを1回限りで取り出します
+func (u *PasskeyUseCase) consumeCeremony(ceremonyID, ceremonyType string, notStarted error) (*entity.WebAuthnCeremony, error) {
+	ceremony, err := u.ceremonyRepo.Consume(ceremonyID)
+	if errors.Is(err, entity.ErrCeremonyNotFound) {
+		return nil, notStarted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.Type != ceremonyType {
+		return nil, notStarted
 	}
+	return ceremony, nil
 }
 
-// StartRegistration はパスキー登録を開始します
-func (u *PasskeyUseCase) StartRegistration(username string) (*entity.WebAuthnRegistrationResponse, error) {
+// StartRegistration はログイン中のユーザーのパスキー登録を開始します
+func (u *PasskeyUseCase) StartRegistration(ctx context.Context, userID string) (*entity.WebAuthnRegistrationResponse, error) {
 	// ユーザーの存在確認
-	user, err := u.userRepo.FindByUsername(username)
+	user, err := u.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	if !user.IsActive() {
+		return nil, ErrAccountInactive
+	}
 
-	// チャレンジの生成
-	challenge := make([]byte, 32)
-	if _, err := rand.Read(challenge); err != nil {
+	ceremony := &entity.WebAuthnCeremony{
+		Type:     entity.CeremonyRegistration,
+		UserID:   user.ID,
+		Username: user.Username,
+	}
+	if err := u.beginCeremony(ceremony, u.config.RegistrationTimeout); err != nil {
 		return nil, err
 	}
 
+	pubKeyCredParams := make([]entity.PubKeyCredParam, 0, len(u.config.Algorithms))
+	for _, alg := range u.config.Algorithms {
+		pubKeyCredParams = append(pubKeyCredParams, entity.PubKeyCredParam{
+			Type: "public-key",
+			Alg:  alg,
+		})
+	}
+
 	// 登録オプションの生成
 	options := &entity.WebAuthnRegistrationResponse{
+		CeremonyID: ceremony.ID,
 		PublicKey: entity.PublicKeyCredentialCreationOptions{
-			Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+			Challenge: base64.RawURLEncoding.EncodeToString(ceremony.Challenge),
 			RP: entity.RP{
-				ID:   "localhost",
-				Name: "Passkey Demo",
+				ID:   ceremony.RPID,
+				Name: u.config.RPName,
 			},
 			User: entity.WebAuthnUser{
-				ID:          user.ID,
+				// user.id はブラウザでバイト列として扱われるためbase64urlで渡す
+				ID:          webauthn.EncodeBase64([]byte(user.ID)),
 				Name:        user.Username,
 				DisplayName: user.Username,
 			},
-			PubKeyCredParams: []entity.PubKeyCredParam{
-				{
-					Type: "public-key",
-					Alg:  -7, // ES256
-				},
+			PubKeyCredParams: pubKeyCredParams,
+			Timeout:          int(u.config.RegistrationTimeout.Milliseconds()),
+			Attestation:      u.config.Attestation,
+			// residentKey が required の場合、ユーザー名を入力せずにログインできるよう認証器にパスキーを保存させる
+			AuthenticatorSelection: &entity.AuthenticatorSelection{
+				ResidentKey:        u.config.ResidentKey,
+				RequireResidentKey: u.config.ResidentKey == entity.ResidentKeyRequired,
+				UserVerification:   u.config.UserVerification,
 			},
-			Timeout:     60000,
-			Attestation: "none",
 		},
 	}
 
 	return options, nil
 }
 
-// CompleteRegistration はパスキー登録を完了します
-func (u *PasskeyUseCase) CompleteRegistration(userID string, credentialID string, publicKey string, attestationType string) error {
-	credential := &entity.Credential{
-		ID:              generateRandomString(32),
-		Username:        userID, // ユーザーIDをユーザー名として使用
-		PublicKey:       []byte(publicKey),
-		UserHandle:      []byte(userID),
-		SignCount:       0,
-		Transports:      []string{"internal"},
-		AttestationType: attestationType,
-		AAGUID:          []byte{}, // 必要に応じて設定
+// CompleteRegistration はブラウザから受け取った登録レスポンスを検証し、パスキーを保存します
+// 登録を開始したユーザー以外のセッションでは完了できません
+func (u *PasskeyUseCase) CompleteRegistration(ctx context.Context, userID, ceremonyID string, credential webauthn.RegistrationCredential) error {
+	// セレモニーは検証結果にかかわらず1回限り
+	ceremony, err := u.consumeCeremony(ceremonyID, entity.CeremonyRegistration, ErrRegistrationNotStarted)
+	if err != nil {
+		return err
+	}
+	if ceremony.UserID != userID {
+		return ErrRegistrationNotStarted
+	}
+
+	registration, err := webauthn.VerifyRegistration(webauthn.RegistrationOptions{
+		Challenge:               ceremony.Challenge,
+		RPID:                    ceremony.RPID,
+		Origins:                 u.config.AllowedOrigins(),
+		RequireUserVerification: u.config.UserVerification == entity.UserVerificationRequired,
+		Algorithms:              u.config.Algorithms,
+	}, credential)
+	if err != nil {
+		return err
+	}
+	if err := u.policy.Evaluate(ctx, registration); err != nil {
+		return fmt.Errorf("%w: %v", ErrAttestationRejected, err)
 	}
 
-	return u.passkeyRepo.SaveCredential(credential)
+	// クレデンシャルIDはクライアントが決めるため、登録済みのIDは保存時に拒否する（上書きしない）
+	credentialID := webauthn.EncodeBase64(registration.CredentialID)
+	return u.passkeyRepo.SaveCredential(&entity.Credential{
+		ID:                   credentialID,
+		Username:             ceremony.Username,
+		PublicKey:            registration.PublicKey,
+		UserHandle:           []byte(ceremony.UserID),
+		SignCount:            registration.SignCount,
+		Transports:           registration.Transports,
+		AttestationType:      registration.AttestationType,
+		AttestationFormat:    registration.AttestationFmt,
+		AttestationTrustPath: registration.AttestationTrustPath,
+		AAGUID:               registration.AAGUID,
+		CreatedAt:            u.now(),
+		BackupEligible:       registration.BackupEligible,
+		BackupState:          registration.BackupState,
+	})
 }
 
 // StartAuthentication はパスキー認証を開始します
-func (u *PasskeyUseCase) StartAuthentication(username string) (*entity.WebAuthnAuthenticationResponse, error) {
-	// ユーザーの存在確認
-	if _, err := u.userRepo.FindByUsername(username); err != nil {
-		return nil, errors.New("user not found")
-	}
+// username が空の場合は allowCredentials を指定せず、認証器に保存されたパスキー（discoverable credential）で認証します
+// この場合は conditional UI（オートフィル）からの利用を想定し、ユーザー検証を必須にします
+func (u *PasskeyUseCase) StartAuthentication(ctx context.Context, username string) (*entity.WebAuthnAuthenticationResponse, error) {
+	ceremony := &entity.WebAuthnCeremony{Type: entity.CeremonyAuthentication}
+	allowCredentials := []entity.AllowCredential{}
+	userVerification := entity.UserVerificationRequired
 
-	// ユーザーのパスキーを取得
-	credentials, err := u.passkeyRepo.GetCredentialsByUsername(username)
-	if err != nil {
-		return nil, errors.New("no passkeys found")
+	if username != "" {
+		// ユーザーの存在確認
+		user, err := u.userRepo.FindByUsername(username)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+
+		// ユーザーのパスキーを取得（ユーザー名は変更できるため、ユーザーIDのuser handleで探す）
+		credentials, err := u.passkeyRepo.GetCredentialsByUserHandle([]byte(user.ID))
+		if err != nil {
+			return nil, err
+		}
+		// allowCredentials が空だと任意のパスキーを受け付けてしまうため、開始しない
+		if len(credentials) == 0 {
+			return nil, ErrNoPasskeys
+		}
+
+		for _, credential := range credentials {
+			allowCredentials = append(allowCredentials, entity.AllowCredential{
+				Type:       "public-key",
+				ID:         credential.ID,
+				Transports: credential.Transports,
+			})
+			ceremony.AllowCredentials = append(ceremony.AllowCredentials, credential.ID)
+		}
+		ceremony.UserID = user.ID
+		ceremony.Username = user.Username
+		userVerification = u.config.UserVerification
 	}
 
-	// チャレンジの生成
-	challenge := make([]byte, 32)
-	if _, err := rand.Read(challenge); err != nil {
+	if err := u.beginCeremony(ceremony, u.config.AuthenticationTimeout); err != nil {
 		return nil, err
 	}
 
 	// 認証オプションの生成
-	allowCredentials := make([]entity.AllowCredential, len(credentials))
-	for i, credential := range credentials {
-		allowCredentials[i] = entity.AllowCredential{
-			Type:       "public-key",
-			ID:         credential.ID,
-			Transports: credential.Transports,
-		}
-	}
-
 	options := &entity.WebAuthnAuthenticationResponse{
+		CeremonyID: ceremony.ID,
 		PublicKey: entity.PublicKeyCredentialRequestOptions{
-			Challenge:        base64.RawURLEncoding.EncodeToString(challenge),
-			Timeout:          60000,
-			RPID:             "localhost",
+			Challenge:        base64.RawURLEncoding.EncodeToString(ceremony.Challenge),
+			Timeout:          int(u.config.AuthenticationTimeout.Milliseconds()),
+			RPID:             ceremony.RPID,
 			AllowCredentials: allowCredentials,
+			UserVerification: userVerification,
 		},
 	}
 
 	return options, nil
 }
 
-// CompleteAuthentication はパスキー認証を完了します
-func (u *PasskeyUseCase) CompleteAuthentication(credentialID string) error {
+// CompleteAuthentication はブラウザから受け取った認証レスポンスを検証し、認証されたユーザーを返します
+func (u *PasskeyUseCase) CompleteAuthentication(ctx context.Context, ceremonyID string, assertion webauthn.AuthenticationCredential) (*entity.User, error) {
+	// セレモニーは検証結果にかかわらず1回限り
+	ceremony, err := u.consumeCeremony(ceremonyID, entity.CeremonyAuthentication, ErrAuthenticationNotStarted)
+	if err != nil {
+		return nil, err
+	}
+
 	// クレデンシャルの取得
-	credential, err := u.passkeyRepo.GetCredential(credentialID)
+	credential, err := u.passkeyRepo.GetCredential(webauthn.EncodeBase64(assertion.RawID))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if credential == nil {
-		return errors.New("credential not found")
+		return nil, entity.ErrCredentialNotFound
+	}
+
+	allowCredentials := make([][]byte, 0, len(ceremony.AllowCredentials))
+	for _, id := range ceremony.AllowCredentials {
+		if decoded, err := webauthn.DecodeBase64(id); err == nil {
+			allowCredentials = append(allowCredentials, decoded)
+		}
 	}
 
 	// クレデンシャルの検証
-	// TODO: 実際の検証ロジックを実装
+	// ユーザー名なしで開始した場合は userHandle でユーザーを特定するため、設定にかかわらずユーザー検証を必須にする
+	result, err := webauthn.VerifyAssertion(webauthn.AssertionOptions{
+		Challenge:               ceremony.Challenge,
+		RPID:                    ceremony.RPID,
+		Origins:                 u.config.AllowedOrigins(),
+		RequireUserVerification: ceremony.UserID == "" || u.config.UserVerification == entity.UserVerificationRequired,
+		AllowCredentials:        allowCredentials,
+	}, webauthn.StoredCredential{
+		ID:         assertion.RawID,
+		PublicKey:  credential.PublicKey,
+		UserHandle: credential.UserHandle,
+	}, assertion)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	// 署名カウンターが増えていなければ、認証器が複製された可能性があるため拒否する
+	if err := u.passkeyRepo.UpdateSignCount(credential.ID, result.SignCount); err != nil {
+		if errors.Is(err, entity.ErrSignCountNotIncreased) {
+			logger.LogWarning("possible cloned authenticator: credential=%s stored=%d received=%d", credential.ID, credential.SignCount, result.SignCount)
+		}
+		return nil, err
+	}
+	// 同期の状態は利用のたびに変わり得るため、最終利用日時と合わせて記録する
+	if err := u.passkeyRepo.UpdateLastUsed(credential.ID, u.now(), result.BackupState); err != nil {
+		return nil, err
+	}
+
+	// クレデンシャルに紐づくユーザーをAuthleteのsubjectとして使えるよう解決する
+	user, err := u.userRepo.FindByID(string(credential.UserHandle))
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.IsActive() {
+		return nil, ErrAccountInactive
+	}
+
+	return user, nil
+}
+
+// WebAuthnOrigins は /.well-known/webauthn で公開する、パスキーを利用できるオリジンの一覧を返します
+// RPIDとは別のドメイン（related origins）から利用する場合、ブラウザはこの一覧でオリジンを確認します
+func (u *PasskeyUseCase) WebAuthnOrigins() []string {
+	return u.config.AllowedOrigins()
+}
+
+// ListCredentials はユーザーが登録したパスキーを登録日時の順に返します
+func (u *PasskeyUseCase) ListCredentials(ctx context.Context, userID string) ([]*entity.Credential, error) {
+	credentials, err := u.passkeyRepo.GetCredentialsByUserHandle([]byte(userID))
+	if err != nil {
+		return nil, err
+	}
+	for _, credential := range credentials {
+		credential.AuthenticatorName = webauthn.AuthenticatorName(credential.AAGUID)
+	}
+	return credentials, nil
+}
+
+// RenameCredential はパスキーの表示名を変更します
+func (u *PasskeyUseCase) RenameCredential(ctx context.Context, userID, credentialID, nickname string) error {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" || utf8.RuneCountInString(nickname) > maxNicknameLength {
+		return ErrInvalidNickname
+	}
+	if _, err := u.ownedCredential(userID, credentialID); err != nil {
+		return err
+	}
+	return u.passkeyRepo.UpdateNickname(credentialID, nickname)
+}
+
+// DeleteCredential はパスキーを削除します
+func (u *PasskeyUseCase) DeleteCredential(ctx context.Context, userID, credentialID string) error {
+	if _, err := u.ownedCredential(userID, credentialID); err != nil {
+		return err
+	}
+	return u.passkeyRepo.DeleteCredential(credentialID)
+}
+
+// ownedCredential はユーザー本人のクレデンシャルを取得します
+// 他のユーザーのクレデンシャルは存在を知らせないよう、未登録と同じ entity.ErrCredentialNotFound を返します
+func (u *PasskeyUseCase) ownedCredential(userID, credentialID string) (*entity.Credential, error) {
+	credential, err := u.passkeyRepo.GetCredential(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil || string(credential.UserHandle) != userID {
+		return nil, entity.ErrCredentialNotFound
+	}
+	return credential, nil
 }
 
 // generateRandomString はランダムな文字列を生成します
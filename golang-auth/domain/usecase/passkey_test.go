@@ -0,0 +1,484 @@
+package usecase
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/webauthntest"
+)
+
+// startRegistration は登録を開始し、発行されたチャレンジで仮想認証器に登録レスポンスを生成させます
+func startRegistration(t *testing.T, passkeyUseCase *PasskeyUseCase, authenticator *webauthntest.Authenticator, userID string) (string, webauthn.RegistrationCredential) {
+	t.Helper()
+	options, err := passkeyUseCase.StartRegistration(context.Background(), userID)
+	require.NoError(t, err)
+
+	challenge, err := webauthn.DecodeBase64(options.PublicKey.Challenge)
+	require.NoError(t, err)
+	userHandle, err := webauthn.DecodeBase64(options.PublicKey.User.ID)
+	require.NoError(t, err)
+
+	credential, err := authenticator.Create(challenge, userHandle)
+	require.NoError(t, err)
+	return options.CeremonyID, credential
+}
+
+// テスト用のRelying Partyです
+const (
+	testRPID   = "poc-authlete.local"
+	testOrigin = "https://poc-authlete.local"
+)
+
+func testWebAuthnConfig() config.WebAuthnConfig {
+	return config.WebAuthnConfig{
+		RPID:                  testRPID,
+		RPName:                "Passkey Demo",
+		Origins:               []string{testOrigin},
+		RegistrationTimeout:   5 * time.Minute,
+		AuthenticationTimeout: 5 * time.Minute,
+		Algorithms:            []int{webauthn.AlgES256},
+		Attestation:           "none",
+		UserVerification:      entity.UserVerificationPreferred,
+		ResidentKey:           entity.ResidentKeyRequired,
+	}
+}
+
+func newTestPasskeyUseCase() (*PasskeyUseCase, *mock.MockPasskeyRepository) {
+	return newTestPasskeyUseCaseWithConfig(testWebAuthnConfig())
+}
+
+func newTestPasskeyUseCaseWithConfig(cfg config.WebAuthnConfig) (*PasskeyUseCase, *mock.MockPasskeyRepository) {
+	mockUserRepo := mock.NewMockUserRepository()
+	mockUserRepo.Users["test-user-id"] = &entity.User{ID: "test-user-id", Username: "test-user"}
+	mockPasskeyRepo := mock.NewMockPasskeyRepository()
+	return NewPasskeyUseCase(mockPasskeyRepo, mockUserRepo, mock.NewMockCeremonyRepository(), cfg, nil), mockPasskeyRepo
+}
+
+func TestCompleteRegistration(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	ceremonyID, credential := startRegistration(t, passkeyUseCase, authenticator, "test-user-id")
+
+	// テスト実行
+	err := passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", ceremonyID, credential)
+
+	// アサーション
+	require.NoError(t, err)
+	saved := mockPasskeyRepo.Credentials[credential.ID]
+	require.NotNil(t, saved)
+	assert.Equal(t, "test-user", saved.Username)
+	assert.Equal(t, []byte("test-user-id"), saved.UserHandle)
+	assert.Equal(t, webauthn.AttestationTypeNone, saved.AttestationType)
+	assert.Len(t, saved.AAGUID, 16)
+
+	publicKey, err := webauthn.ParsePublicKey(saved.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, webauthn.AlgES256, publicKey.Algorithm)
+
+	// セレモニーは1回限り
+	err = passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", ceremonyID, credential)
+	assert.ErrorIs(t, err, ErrRegistrationNotStarted)
+}
+
+func TestCompleteRegistrationInvalidChallenge(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	options, err := passkeyUseCase.StartRegistration(context.Background(), "test-user-id")
+	require.NoError(t, err)
+	credential, err := authenticator.Create([]byte("forged-challenge"), []byte("test-user-id"))
+	require.NoError(t, err)
+
+	// テスト実行
+	err = passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", options.CeremonyID, credential)
+
+	// アサーション
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+	assert.Empty(t, mockPasskeyRepo.Credentials)
+}
+
+func TestCompleteRegistrationDuplicateCredential(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	ceremonyID, credential := startRegistration(t, passkeyUseCase, authenticator, "test-user-id")
+	mockPasskeyRepo.Credentials[credential.ID] = &entity.Credential{ID: credential.ID, Username: "another-user"}
+
+	// テスト実行
+	err := passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", ceremonyID, credential)
+
+	// アサーション
+	assert.ErrorIs(t, err, ErrCredentialAlreadyRegistered)
+	assert.Equal(t, "another-user", mockPasskeyRepo.Credentials[credential.ID].Username)
+}
+
+func TestCompleteRegistrationOtherUser(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	ceremonyID, credential := startRegistration(t, passkeyUseCase, authenticator, "test-user-id")
+
+	// テスト実行
+	err := passkeyUseCase.CompleteRegistration(context.Background(), "another-user-id", ceremonyID, credential)
+
+	// アサーション
+	assert.ErrorIs(t, err, ErrRegistrationNotStarted)
+	assert.Empty(t, mockPasskeyRepo.Credentials)
+}
+
+// registerPasskey は仮想認証器のパスキーを登録し、クレデンシャルIDを返します
+func registerPasskey(t *testing.T, passkeyUseCase *PasskeyUseCase, authenticator *webauthntest.Authenticator) []byte {
+	t.Helper()
+	ceremonyID, credential := startRegistration(t, passkeyUseCase, authenticator, "test-user-id")
+	require.NoError(t, passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", ceremonyID, credential))
+	return credential.RawID
+}
+
+// startAuthentication は認証を開始し、発行されたチャレンジで仮想認証器に認証レスポンスを生成させます
+func startAuthentication(t *testing.T, passkeyUseCase *PasskeyUseCase, authenticator *webauthntest.Authenticator, credentialID []byte) (string, webauthn.AuthenticationCredential) {
+	t.Helper()
+	options, err := passkeyUseCase.StartAuthentication(context.Background(), "test-user")
+	require.NoError(t, err)
+	challenge, err := webauthn.DecodeBase64(options.PublicKey.Challenge)
+	require.NoError(t, err)
+
+	assertion, err := authenticator.Get(credentialID, challenge)
+	require.NoError(t, err)
+	return options.CeremonyID, assertion
+}
+
+func TestCompleteAuthentication(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+	ceremonyID, assertion := startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+
+	// テスト実行
+	user, err := passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-id", user.ID)
+	assert.Equal(t, uint32(1), mockPasskeyRepo.Credentials[webauthn.EncodeBase64(credentialID)].SignCount)
+
+	// 同じレスポンスの再送はセレモニーが使用済みのため拒否される
+	_, err = passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+	assert.ErrorIs(t, err, ErrAuthenticationNotStarted)
+}
+
+func TestCompleteAuthenticationInvalidSignature(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+	ceremonyID, assertion := startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+	assertion.Response.Signature[len(assertion.Response.Signature)-1] ^= 0xff
+
+	// テスト実行
+	_, err := passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+
+	// アサーション
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+	assert.Zero(t, mockPasskeyRepo.Credentials[webauthn.EncodeBase64(credentialID)].SignCount)
+}
+
+func TestCompleteAuthenticationDetectsClonedAuthenticator(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, _ := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+	ceremonyID, assertion := startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+	_, err := passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+	require.NoError(t, err)
+
+	// 複製された認証器は古いカウンターから署名する
+	authenticator.Credential(credentialID).SignCount = 0
+	ceremonyID, assertion = startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+
+	// テスト実行
+	_, err = passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+
+	// アサーション
+	assert.ErrorIs(t, err, entity.ErrSignCountNotIncreased)
+}
+
+func TestCompleteAuthenticationUnknownCredential(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+	ceremonyID, assertion := startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+	delete(mockPasskeyRepo.Credentials, webauthn.EncodeBase64(credentialID))
+
+	// テスト実行
+	_, err := passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+
+	// アサーション
+	assert.ErrorIs(t, err, entity.ErrCredentialNotFound)
+}
+
+func TestCompleteAuthenticationRejectsRegistrationCeremony(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, _ := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+	_, assertion := startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+	options, err := passkeyUseCase.StartRegistration(context.Background(), "test-user-id")
+	require.NoError(t, err)
+
+	// テスト実行
+	_, err = passkeyUseCase.CompleteAuthentication(context.Background(), options.CeremonyID, assertion)
+
+	// アサーション
+	assert.ErrorIs(t, err, ErrAuthenticationNotStarted)
+}
+
+func TestCompleteAuthenticationDiscoverableCredential(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, _ := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+
+	// ユーザー名なしで開始する
+	options, err := passkeyUseCase.StartAuthentication(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, options.PublicKey.AllowCredentials)
+	assert.Equal(t, entity.UserVerificationRequired, options.PublicKey.UserVerification)
+	challenge, err := webauthn.DecodeBase64(options.PublicKey.Challenge)
+	require.NoError(t, err)
+	assertion, err := authenticator.Get(credentialID, challenge)
+	require.NoError(t, err)
+
+	// テスト実行
+	user, err := passkeyUseCase.CompleteAuthentication(context.Background(), options.CeremonyID, assertion)
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "test-user-id", user.ID)
+}
+
+func TestCompleteAuthenticationDiscoverableCredentialRequiresUserHandle(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, _ := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+	options, err := passkeyUseCase.StartAuthentication(context.Background(), "")
+	require.NoError(t, err)
+	challenge, err := webauthn.DecodeBase64(options.PublicKey.Challenge)
+	require.NoError(t, err)
+	assertion, err := authenticator.Get(credentialID, challenge)
+	require.NoError(t, err)
+	assertion.Response.UserHandle = nil
+
+	// テスト実行
+	_, err = passkeyUseCase.CompleteAuthentication(context.Background(), options.CeremonyID, assertion)
+
+	// アサーション
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestStartRegistrationRequiresResidentKey(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, _ := newTestPasskeyUseCase()
+
+	// テスト実行
+	options, err := passkeyUseCase.StartRegistration(context.Background(), "test-user-id")
+
+	// アサーション
+	require.NoError(t, err)
+	require.NotNil(t, options.PublicKey.AuthenticatorSelection)
+	assert.Equal(t, entity.ResidentKeyRequired, options.PublicKey.AuthenticatorSelection.ResidentKey)
+	assert.True(t, options.PublicKey.AuthenticatorSelection.RequireResidentKey)
+}
+
+func TestStartRegistrationUsesConfig(t *testing.T) {
+	// テストケースの準備
+	cfg := testWebAuthnConfig()
+	cfg.RPName = "Example"
+	cfg.RegistrationTimeout = 2 * time.Minute
+	cfg.Algorithms = []int{webauthn.AlgEdDSA, webauthn.AlgES256}
+	cfg.Attestation = "direct"
+	cfg.UserVerification = entity.UserVerificationRequired
+	cfg.ResidentKey = entity.ResidentKeyPreferred
+	passkeyUseCase, _ := newTestPasskeyUseCaseWithConfig(cfg)
+
+	// テスト実行
+	options, err := passkeyUseCase.StartRegistration(context.Background(), "test-user-id")
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, entity.RP{ID: testRPID, Name: "Example"}, options.PublicKey.RP)
+	assert.Equal(t, 120000, options.PublicKey.Timeout)
+	assert.Equal(t, "direct", options.PublicKey.Attestation)
+	require.Len(t, options.PublicKey.PubKeyCredParams, 2)
+	assert.Equal(t, webauthn.AlgEdDSA, options.PublicKey.PubKeyCredParams[0].Alg)
+	assert.Equal(t, entity.UserVerificationRequired, options.PublicKey.AuthenticatorSelection.UserVerification)
+	assert.Equal(t, entity.ResidentKeyPreferred, options.PublicKey.AuthenticatorSelection.ResidentKey)
+	assert.False(t, options.PublicKey.AuthenticatorSelection.RequireResidentKey)
+}
+
+func TestCompleteRegistrationRejectsDisallowedAlgorithm(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	authenticator.Algorithm = webauthn.AlgEdDSA
+	ceremonyID, credential := startRegistration(t, passkeyUseCase, authenticator, "test-user-id")
+
+	// テスト実行
+	err := passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", ceremonyID, credential)
+
+	// アサーション
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+	assert.Empty(t, mockPasskeyRepo.Credentials)
+}
+
+func TestCompleteRegistrationAttestationPolicy(t *testing.T) {
+	// テストケースの準備
+	var evaluated *webauthn.Registration
+	policy := AttestationPolicyFunc(func(ctx context.Context, registration *webauthn.Registration) error {
+		evaluated = registration
+		return nil
+	})
+	mockPasskeyRepo := mock.NewMockPasskeyRepository()
+	mockUserRepo := mock.NewMockUserRepository()
+	mockUserRepo.Users["test-user-id"] = &entity.User{ID: "test-user-id", Username: "test-user"}
+	passkeyUseCase := NewPasskeyUseCase(mockPasskeyRepo, mockUserRepo, mock.NewMockCeremonyRepository(), testWebAuthnConfig(), policy)
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	authenticator.Format = "packed"
+	ceremonyID, credential := startRegistration(t, passkeyUseCase, authenticator, "test-user-id")
+
+	// テスト実行
+	err := passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", ceremonyID, credential)
+
+	// アサーション
+	require.NoError(t, err)
+	require.NotNil(t, evaluated)
+	assert.Equal(t, "packed", evaluated.AttestationFmt)
+	saved := mockPasskeyRepo.Credentials[credential.ID]
+	require.NotNil(t, saved)
+	assert.Equal(t, webauthn.AttestationTypeBasic, saved.AttestationType)
+	assert.Equal(t, "packed", saved.AttestationFormat)
+	assert.Equal(t, evaluated.AttestationTrustPath, saved.AttestationTrustPath)
+	assert.Len(t, saved.AttestationTrustPath, 1)
+}
+
+func TestCompleteRegistrationAttestationRejected(t *testing.T) {
+	// テストケースの準備
+	policy := AttestationPolicyFunc(func(ctx context.Context, registration *webauthn.Registration) error {
+		return errors.New("authenticator is not allowed")
+	})
+	mockPasskeyRepo := mock.NewMockPasskeyRepository()
+	mockUserRepo := mock.NewMockUserRepository()
+	mockUserRepo.Users["test-user-id"] = &entity.User{ID: "test-user-id", Username: "test-user"}
+	passkeyUseCase := NewPasskeyUseCase(mockPasskeyRepo, mockUserRepo, mock.NewMockCeremonyRepository(), testWebAuthnConfig(), policy)
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	ceremonyID, credential := startRegistration(t, passkeyUseCase, authenticator, "test-user-id")
+
+	// テスト実行
+	err := passkeyUseCase.CompleteRegistration(context.Background(), "test-user-id", ceremonyID, credential)
+
+	// アサーション
+	assert.ErrorIs(t, err, ErrAttestationRejected)
+	assert.Empty(t, mockPasskeyRepo.Credentials)
+}
+
+func TestCompleteAuthenticationRelatedOrigin(t *testing.T) {
+	// テストケースの準備
+	cfg := testWebAuthnConfig()
+	cfg.RelatedOrigins = []string{"https://shop.example.com"}
+	passkeyUseCase, _ := newTestPasskeyUseCaseWithConfig(cfg)
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+
+	// テスト実行
+	// RPIDとは別のドメインのページから、同じパスキーで認証する
+	authenticator.Origin = "https://shop.example.com"
+	ceremonyID, assertion := startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+	_, errRelated := passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+	authenticator.Origin = "https://evil.example.com"
+	ceremonyID, assertion = startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+	_, errUnrelated := passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+
+	// アサーション
+	assert.NoError(t, errRelated)
+	assert.ErrorIs(t, errUnrelated, webauthn.ErrVerification)
+	assert.Equal(t, []string{testOrigin, "https://shop.example.com"}, passkeyUseCase.WebAuthnOrigins())
+}
+
+func TestListCredentials(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	passkeyUseCase.now = func() time.Time { return now }
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	authenticator.Flags |= webauthn.FlagBackupEligible | webauthn.FlagBackupState
+	authenticator.AAGUID, _ = hex.DecodeString("ea9b8d664d011d213ce4b6b48cb575d4")
+	credentialID := registerPasskey(t, passkeyUseCase, authenticator)
+	mockPasskeyRepo.Credentials["other"] = &entity.Credential{ID: "other", UserHandle: []byte("other-user-id")}
+
+	now = now.Add(time.Hour)
+	ceremonyID, assertion := startAuthentication(t, passkeyUseCase, authenticator, credentialID)
+	_, err := passkeyUseCase.CompleteAuthentication(context.Background(), ceremonyID, assertion)
+	require.NoError(t, err)
+
+	// テスト実行
+	credentials, err := passkeyUseCase.ListCredentials(context.Background(), "test-user-id")
+
+	// アサーション
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, webauthn.EncodeBase64(credentialID), credentials[0].ID)
+	assert.Equal(t, "Google Password Manager", credentials[0].AuthenticatorName)
+	assert.Equal(t, now.Add(-time.Hour), credentials[0].CreatedAt)
+	assert.Equal(t, now, credentials[0].LastUsedAt)
+	assert.True(t, credentials[0].BackupEligible)
+	assert.True(t, credentials[0].BackupState)
+}
+
+func TestRenameCredential(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	id := webauthn.EncodeBase64(registerPasskey(t, passkeyUseCase, authenticator))
+
+	// テスト実行
+	err := passkeyUseCase.RenameCredential(context.Background(), "test-user-id", id, "  仕事用のMacBook  ")
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "仕事用のMacBook", mockPasskeyRepo.Credentials[id].Nickname)
+	assert.ErrorIs(t, passkeyUseCase.RenameCredential(context.Background(), "test-user-id", id, " "), ErrInvalidNickname)
+	assert.ErrorIs(t, passkeyUseCase.RenameCredential(context.Background(), "test-user-id", id, strings.Repeat("あ", 65)), ErrInvalidNickname)
+	assert.ErrorIs(t, passkeyUseCase.RenameCredential(context.Background(), "other-user-id", id, "盗用"), entity.ErrCredentialNotFound)
+	assert.Equal(t, "仕事用のMacBook", mockPasskeyRepo.Credentials[id].Nickname)
+}
+
+func TestDeleteCredential(t *testing.T) {
+	// テストケースの準備
+	passkeyUseCase, mockPasskeyRepo := newTestPasskeyUseCase()
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	id := webauthn.EncodeBase64(registerPasskey(t, passkeyUseCase, authenticator))
+
+	// テスト実行
+	errOther := passkeyUseCase.DeleteCredential(context.Background(), "other-user-id", id)
+	err := passkeyUseCase.DeleteCredential(context.Background(), "test-user-id", id)
+
+	// アサーション
+	// 他のユーザーのパスキーは削除できない
+	assert.ErrorIs(t, errOther, entity.ErrCredentialNotFound)
+	require.NoError(t, err)
+	assert.Empty(t, mockPasskeyRepo.Credentials)
+	assert.ErrorIs(t, passkeyUseCase.DeleteCredential(context.Background(), "test-user-id", id), entity.ErrCredentialNotFound)
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/password"
+)
+
+var (
+	// ErrPasswordBreached は漏洩したパスワードの一覧に含まれるパスワードであることを表します
+	ErrPasswordBreached = errors.New("password has appeared in a data breach")
+	// ErrPasswordReused は直近に使ったパスワードを再び使おうとしたことを表します
+	ErrPasswordReused = errors.New("password was used recently")
+)
+
+// BreachedPasswordChecker は漏洩したパスワードかを確認するインターフェースです（password.BreachedList が実装します）
+type BreachedPasswordChecker interface {
+	Contains(password string) (bool, error)
+}
+
+// PasswordPolicy は新しいパスワードに求める条件です
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// History は再利用を禁止する直近のパスワードの数です（現在のパスワードを含みます）
+	History int
+	// Breached が nil の場合は漏洩したパスワードかを確認しません
+	Breached BreachedPasswordChecker
+}
+
+// NewPasswordPolicy は設定からパスワードの条件を作成します
+func NewPasswordPolicy(cfg config.PasswordConfig, breached BreachedPasswordChecker) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: cfg.MinLength,
+		MaxLength: cfg.MaxLength,
+		History:   cfg.History,
+		Breached:  breached,
+	}
+}
+
+// check は新しいパスワードが条件を満たすかを確認します
+// user が nil でない場合は、現在と以前のパスワードの再利用も確認します
+func (p PasswordPolicy) check(hasher *password.Hasher, newPassword string, user *entity.User) error {
+	if n := utf8.RuneCountInString(newPassword); n < p.MinLength || n > p.MaxLength {
+		return fmt.Errorf("%w: must be %d to %d characters", ErrInvalidPassword, p.MinLength, p.MaxLength)
+	}
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(newPassword)
+		if err != nil {
+			return fmt.Errorf("failed to check breached passwords: %w", err)
+		}
+		if breached {
+			return ErrPasswordBreached
+		}
+	}
+	if user == nil || p.History == 0 {
+		return nil
+	}
+	for _, hash := range recentPasswordHashes(user, p.History) {
+		if _, err := hasher.Verify(newPassword, hash); err == nil {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// recentPasswordHashes は現在のパスワードを含む直近 n 件のパスワードのハッシュを返します
+func recentPasswordHashes(user *entity.User, n int) []string {
+	var hashes []string
+	for _, hash := range append([]string{user.PasswordHash}, user.PasswordHistory...) {
+		if len(hashes) >= n {
+			break
+		}
+		if hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// setPassword はユーザーのパスワードを新しいハッシュに置き換え、以前のハッシュを履歴に残します
+// 履歴は現在のパスワードと合わせて History 件になるよう古いものから削除します
+func (p PasswordPolicy) setPassword(user *entity.User, hash string, now time.Time) {
+	var history []string
+	if p.History > 1 {
+		history = recentPasswordHashes(user, p.History-1)
+	}
+	user.PasswordHistory = history
+	user.PasswordHash = hash
+	user.PasswordChangedAt = now
+}
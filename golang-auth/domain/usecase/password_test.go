@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/pkg/password"
+)
+
+// testHasher はテストを速くするための軽いパラメータのHasherです
+var testHasher, _ = password.NewHasher(password.Params{Memory: 64, Iterations: 1, Parallelism: 1})
+
+var testPasswordPolicy = PasswordPolicy{MinLength: 8, MaxLength: 128, History: 3}
+
+// fakeBreachedList は漏洩したパスワードの一覧です
+type fakeBreachedList map[string]bool
+
+func (l fakeBreachedList) Contains(password string) (bool, error) {
+	return l[password], nil
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := testPasswordPolicy
+	policy.Breached = fakeBreachedList{"password123": true}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{"条件を満たすパスワード", "correct horse battery staple", nil},
+		{"マルチバイト文字は1文字として数える", "パスワード八文字", nil},
+		{"短いパスワード", "short", ErrInvalidPassword},
+		{"長すぎるパスワード", strings.Repeat("a", 129), ErrInvalidPassword},
+		{"漏洩したパスワード", "password123", ErrPasswordBreached},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テスト実行
+			err := policy.check(testHasher, tt.password, nil)
+
+			// アサーション
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	// テストケースの準備
+	userUseCase, userRepo, _, _ := newTestUserUseCase()
+	user, err := userUseCase.SignUp(context.Background(), entity.SignUpRequest{Username: "new-user", Email: "new@example.com", Password: "first-password"})
+	require.NoError(t, err)
+
+	// テスト実行・アサーション
+	// 現在のパスワードが一致しない場合は変更できない
+	err = userUseCase.ChangePassword(context.Background(), user.ID, "wrong-password", "second-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.NoError(t, userUseCase.ChangePassword(context.Background(), user.ID, "first-password", "second-password"))
+	require.NoError(t, userUseCase.ChangePassword(context.Background(), user.ID, "second-password", "third-password"))
+	assert.Len(t, userRepo.Users[user.ID].PasswordHistory, 2)
+
+	// 直近3件のパスワードは再利用できない
+	for _, reused := range []string{"first-password", "second-password", "third-password"} {
+		err = userUseCase.ChangePassword(context.Background(), user.ID, "third-password", reused)
+		assert.ErrorIs(t, err, ErrPasswordReused, reused)
+	}
+	require.NoError(t, userUseCase.ChangePassword(context.Background(), user.ID, "third-password", "fourth-password"))
+	// 4件前のパスワードは履歴から消えるため再び使える
+	assert.NoError(t, userUseCase.ChangePassword(context.Background(), user.ID, "fourth-password", "first-password"))
+
+	_, err = NewPasswordCredentialVerifier(userRepo, testHasher).Verify("new@example.com", "first-password")
+	assert.NoError(t, err)
+}
+
+func TestVerifyRehashesLegacyPassword(t *testing.T) {
+	// テストケースの準備
+	userRepo := mock.NewMockUserRepository()
+	userRepo.Save(newTestUser(t, "test-password"))
+	verifier := NewPasswordCredentialVerifier(userRepo, testHasher)
+
+	// テスト実行
+	user, err := verifier.Verify("test@example.com", "test-password")
+
+	// アサーション
+	// 移行元のbcryptのハッシュはログインに成功したときにargon2idで作り直す
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"))
+	assert.True(t, strings.HasPrefix(userRepo.Users["test-user-id"].PasswordHash, "$argon2id$"))
+
+	_, err = verifier.Verify("test@example.com", "test-password")
+	assert.NoError(t, err)
+	_, err = verifier.Verify("test@example.com", "wrong-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestVerifyNormalizesEmail(t *testing.T) {
+	// テストケースの準備
+	userUseCase, userRepo, _, _ := newTestUserUseCase()
+	_, err := userUseCase.SignUp(context.Background(), entity.SignUpRequest{Username: "new-user", Email: " New@Example.com", Password: "first-password"})
+	require.NoError(t, err)
+	verifier := NewPasswordCredentialVerifier(userRepo, testHasher)
+
+	// テスト実行・アサーション
+	// 登録時と同様に前後の空白と大文字・小文字の違いを無視する
+	for _, email := range []string{"new@example.com", "NEW@example.com", " New@Example.com "} {
+		user, err := verifier.Verify(email, "first-password")
+		require.NoError(t, err, email)
+		assert.Equal(t, "new@example.com", user.Email)
+	}
+	_, err = verifier.Verify("not-an-email", "first-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
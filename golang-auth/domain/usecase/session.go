@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+)
+
+var (
+	// ErrSessionNotFound はセッションが存在しないことを表します
+	ErrSessionNotFound = entity.ErrSessionNotFound
+	// ErrSessionExpired はトークンの期限が切れ、リフレッシュもできなかったことを表します
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// StoreSession ログイン後のセッションを保存
+func (u *authUseCase) StoreSession(session *entity.Session) error {
+	if session.UserID == "" {
+		session.UserID = session.Tokens.Subject
+	}
+	return u.sessionRepo.Create(session)
+}
+
+// GetAccessToken セッションIDからアクセストークンを取得
+// アクセストークンの期限切れが近い場合はリフレッシュトークンで更新してから返す
+func (u *authUseCase) GetAccessToken(ctx context.Context, sessionID string) (string, error) {
+	session, err := u.sessionRepo.Get(sessionID)
+	if err != nil {
+		return "", err
+	}
+	if err := u.sessionRepo.Touch(sessionID, time.Now()); err != nil {
+		return "", err
+	}
+
+	tokens := session.Tokens
+	if !u.needsRefresh(tokens) {
+		return tokens.AccessToken, nil
+	}
+
+	accessToken, err := u.RefreshSession(ctx, sessionID, tokens.AccessToken)
+	if err != nil {
+		// 期限切れ前であれば、リフレッシュに失敗しても現在のトークンを使い続ける
+		if !errors.Is(err, ErrSessionExpired) && !errors.Is(err, ErrSessionNotFound) && time.Now().Before(tokens.ExpiresAt) {
+			logger.LogWarning("failed to refresh access token: %v", err)
+			return tokens.AccessToken, nil
+		}
+		return "", err
+	}
+	return accessToken, nil
+}
+
+// GetSessionUserID セッションIDからログイン中のユーザーIDを取得
+// アクセストークンを使わない自前のAPIで、セッションを認証に使うためのものです
+func (u *authUseCase) GetSessionUserID(ctx context.Context, sessionID string) (string, error) {
+	session, err := u.sessionRepo.Get(sessionID)
+	if err != nil {
+		return "", err
+	}
+	if err := u.sessionRepo.Touch(sessionID, time.Now()); err != nil {
+		return "", err
+	}
+	return session.UserID, nil
+}
+
+// needsRefresh アクセストークンが期限切れ、または期限切れ間近かを判定
+func (u *authUseCase) needsRefresh(tokens entity.Tokens) bool {
+	if tokens.ExpiresAt.IsZero() || tokens.RefreshToken == "" {
+		return false
+	}
+	leeway := u.config.TokenRefreshLeeway
+	if leeway == 0 {
+		leeway = config.DefaultTokenRefreshLeeway
+	}
+	return time.Now().Add(leeway).After(tokens.ExpiresAt)
+}
+
+// RefreshSession リフレッシュトークンでセッションのアクセストークンを更新
+// staleAccessToken は呼び出し元が無効と判断したアクセストークンで、既に別のリクエストで更新済みの場合は新しいトークンをそのまま返す
+// 同じセッションへの同時リクエストでリフレッシュが重複しないよう、セッション単位で1回にまとめる
+func (u *authUseCase) RefreshSession(ctx context.Context, sessionID, staleAccessToken string) (string, error) {
+	v, err, _ := u.refreshGroup.Do(sessionID, func() (interface{}, error) {
+		session, err := u.sessionRepo.Get(sessionID)
+		if err != nil {
+			return "", err
+		}
+		tokens := session.Tokens
+		if tokens.AccessToken != staleAccessToken {
+			return tokens.AccessToken, nil
+		}
+		if tokens.RefreshToken == "" {
+			return "", ErrSessionExpired
+		}
+
+		// 先頭の呼び出し元のキャンセルで待機中の他のリクエストまで失敗しないようにする
+		refreshed, err := u.refreshTokens(context.WithoutCancel(ctx), tokens)
+		if err != nil {
+			var actionErr *entity.AuthleteActionError
+			if errors.As(err, &actionErr) && actionErr.Action == entity.ActionBadRequest {
+				// リフレッシュトークンが無効な場合はセッションを破棄する
+				if err := u.sessionRepo.Delete(sessionID); err != nil {
+					logger.LogWarning("failed to delete session: %v", err)
+				}
+				return "", fmt.Errorf("%w: %v", ErrSessionExpired, err)
+			}
+			return "", err
+		}
+
+		if err := u.sessionRepo.UpdateTokens(sessionID, refreshed); err != nil {
+			return "", err
+		}
+		return refreshed.AccessToken, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// refreshTokens grant_type=refresh_token でトークンを更新
+func (u *authUseCase) refreshTokens(ctx context.Context, tokens entity.Tokens) (entity.Tokens, error) {
+	resp, err := u.authleteClient.ExchangeToken(ctx, map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": tokens.RefreshToken,
+	})
+	if err != nil {
+		return entity.Tokens{}, err
+	}
+
+	refreshed := tokensFromResponse(resp)
+	if refreshed.Subject == "" {
+		refreshed.Subject = tokens.Subject
+	}
+	// リフレッシュトークンやIDトークンが再発行されない場合は既存のものを引き継ぐ
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = tokens.RefreshToken
+	}
+	if refreshed.IDToken == "" {
+		refreshed.IDToken = tokens.IDToken
+	}
+	return refreshed, nil
+}
+
+// DeleteSession セッションを削除し、セッションに紐付くトークンをAuthleteで失効させる
+// ローカルのセッションは失効の成否にかかわらず削除する
+func (u *authUseCase) DeleteSession(ctx context.Context, sessionID string) error {
+	session, err := u.sessionRepo.Get(sessionID)
+	if errors.Is(err, entity.ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := u.sessionRepo.Delete(sessionID); err != nil {
+		return err
+	}
+
+	return u.revokeTokens(ctx, session.Tokens)
+}
+
+// DeleteUserSessions はユーザーの全セッションを削除し、トークンを失効させます
+// 失効に失敗してもセッションは削除済みのため、警告を記録して続けます
+func (u *authUseCase) DeleteUserSessions(ctx context.Context, userID string) error {
+	sessions, err := u.sessionRepo.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := u.sessionRepo.Delete(session.ID); err != nil && !errors.Is(err, entity.ErrSessionNotFound) {
+			return err
+		}
+		if err := u.revokeTokens(ctx, session.Tokens); err != nil {
+			logger.LogWarning("failed to revoke tokens of session %s: %v", session.ID, err)
+		}
+	}
+	return nil
+}
+
+// revokeTokens リフレッシュトークンとアクセストークンを失効させる
+// トークンはこのBFFが発行を受けたものなので、BFF自身のクライアント認証情報で失効させる
+func (u *authUseCase) revokeTokens(ctx context.Context, tokens entity.Tokens) error {
+	var errs []error
+	if tokens.RefreshToken != "" {
+		if _, err := u.authleteClient.Revoke(ctx, entity.RevocationRequest{
+			Token:         tokens.RefreshToken,
+			TokenTypeHint: "refresh_token",
+			ClientID:      u.config.AuthleteClientID,
+			ClientSecret:  u.config.AuthleteClientSecret,
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to revoke refresh token: %w", err))
+		}
+	}
+	if tokens.AccessToken != "" {
+		if _, err := u.authleteClient.Revoke(ctx, entity.RevocationRequest{
+			Token:         tokens.AccessToken,
+			TokenTypeHint: "access_token",
+			ClientID:      u.config.AuthleteClientID,
+			ClientSecret:  u.config.AuthleteClientSecret,
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to revoke access token: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RevokeToken クライアントからのRFC 7009のトークン失効リクエストを処理
+// クライアント認証はAuthleteが行い、認証情報が無いリクエストは ErrInvalidClient とする
+func (u *authUseCase) RevokeToken(ctx context.Context, req entity.RevocationRequest) error {
+	if req.ClientID == "" {
+		return ErrInvalidClient
+	}
+	_, err := u.authleteClient.Revoke(ctx, req)
+	return err
+}
@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+	"github.com/yamakenji24/golang-auth/pkg/password"
+)
+
+var (
+	// ErrInvalidUsername はユーザー名が3〜32文字の英数字・「_」「.」「-」でないことを表します
+	ErrInvalidUsername = errors.New("username must be 3 to 32 characters of letters, digits, '_', '.' or '-'")
+	// ErrInvalidEmail はメールアドレスの形式が正しくないことを表します
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrInvalidPassword はパスワードが短すぎる、または長すぎることを表します
+	ErrInvalidPassword = errors.New("invalid password length")
+	// ErrInvalidDisplayName は表示名が長すぎることを表します
+	ErrInvalidDisplayName = errors.New("display name must be at most 64 characters")
+	// ErrUsernameTaken はユーザー名が既に使われていることを表します
+	ErrUsernameTaken = entity.ErrUsernameTaken
+	// ErrEmailTaken はメールアドレスが既に登録されていることを表します
+	ErrEmailTaken = entity.ErrEmailTaken
+	// ErrAccountInactive は停止中・退会済みのアカウントであることを表します
+	ErrAccountInactive = errors.New("account is not active")
+)
+
+// ユーザー情報の制限です
+const (
+	maxDisplayNameLength = 64
+	maxEmailLength       = 254
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// SessionTerminator はユーザーの全セッションを終了させるインターフェースです（AuthUseCase が実装します）
+type SessionTerminator interface {
+	DeleteUserSessions(ctx context.Context, userID string) error
+}
+
+// UserUseCase はユーザー登録とアカウントの管理を行います
+type UserUseCase struct {
+	userRepo    repository.UserRepository
+	passkeyRepo repository.PasskeyRepository
+	totpRepo    repository.TOTPRepository
+	sessions    SessionTerminator
+	hasher      *password.Hasher
+	policy      PasswordPolicy
+	now         func() time.Time
+}
+
+// NewUserUseCase は新しいユーザーユースケースを作成します
+func NewUserUseCase(userRepo repository.UserRepository, passkeyRepo repository.PasskeyRepository, totpRepo repository.TOTPRepository, sessions SessionTerminator, hasher *password.Hasher, policy PasswordPolicy) *UserUseCase {
+	return &UserUseCase{
+		userRepo:    userRepo,
+		passkeyRepo: passkeyRepo,
+		totpRepo:    totpRepo,
+		sessions:    sessions,
+		hasher:      hasher,
+		policy:      policy,
+		now:         time.Now,
+	}
+}
+
+// SignUp は新しいユーザーを登録します
+// 表示名が空の場合はユーザー名を使います
+func (u *UserUseCase) SignUp(ctx context.Context, req entity.SignUpRequest) (*entity.User, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if !usernamePattern.MatchString(req.Username) {
+		return nil, ErrInvalidUsername
+	}
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" {
+		displayName = req.Username
+	}
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+		return nil, ErrInvalidDisplayName
+	}
+
+	if err := u.policy.check(u.hasher, req.Password, nil); err != nil {
+		return nil, err
+	}
+	if err := u.checkAvailable("", req.Username, email); err != nil {
+		return nil, err
+	}
+
+	hash, err := u.hasher.Hash(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &entity.User{
+		ID:          generateRandomString(16),
+		Username:    req.Username,
+		Email:       email,
+		DisplayName: displayName,
+		Status:      entity.UserStatusActive,
+	}
+	u.policy.setPassword(user, hash, u.now())
+	if err := u.userRepo.Save(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetProfile はユーザーのプロフィールを返します（退会済みの場合は entity.ErrUserNotFound）
+func (u *UserUseCase) GetProfile(ctx context.Context, userID string) (*entity.User, error) {
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == entity.UserStatusDeleted {
+		return nil, entity.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile はユーザー名・メールアドレス・表示名のうち指定された項目を変更します
+func (u *UserUseCase) UpdateProfile(ctx context.Context, userID string, req entity.ProfileUpdateRequest) (*entity.User, error) {
+	user, err := u.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, ErrAccountInactive
+	}
+
+	updated := *user
+	if req.Username != nil {
+		if !usernamePattern.MatchString(*req.Username) {
+			return nil, ErrInvalidUsername
+		}
+		updated.Username = *req.Username
+	}
+	if req.Email != nil {
+		if updated.Email, err = normalizeEmail(*req.Email); err != nil {
+			return nil, err
+		}
+		// 新しいメールアドレスは確認し直す
+		if updated.Email != user.Email {
+			updated.EmailVerifiedAt = time.Time{}
+		}
+	}
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if displayName == "" {
+			displayName = updated.Username
+		}
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return nil, ErrInvalidDisplayName
+		}
+		updated.DisplayName = displayName
+	}
+
+	if err := u.checkAvailable(userID, updated.Username, updated.Email); err != nil {
+		return nil, err
+	}
+	if err := u.userRepo.Save(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ChangePassword は現在のパスワードを確認し、新しいパスワードに変更します
+// 現在のパスワードが一致しない場合は ErrInvalidCredentials を返します
+func (u *UserUseCase) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	user, err := u.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return ErrAccountInactive
+	}
+	if _, err := u.hasher.Verify(currentPassword, user.PasswordHash); err != nil {
+		return ErrInvalidCredentials
+	}
+	if err := u.policy.check(u.hasher, newPassword, user); err != nil {
+		return err
+	}
+
+	hash, err := u.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	u.policy.setPassword(user, hash, u.now())
+	return u.userRepo.Save(user)
+}
+
+// DeleteAccount は退会処理を行います
+// セッション、パスキー、TOTPとリカバリーコードを削除し、ユーザーは個人情報を消去した退会済みの状態で残します（IDの再利用を防ぐため）
+func (u *UserUseCase) DeleteAccount(ctx context.Context, userID string) error {
+	user, err := u.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.sessions.DeleteUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	credentials, err := u.passkeyRepo.GetCredentialsByUserHandle([]byte(userID))
+	if err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		if err := u.passkeyRepo.DeleteCredential(credential.ID); err != nil && !errors.Is(err, entity.ErrCredentialNotFound) {
+			return fmt.Errorf("failed to delete passkey: %w", err)
+		}
+	}
+	if err := u.totpRepo.Delete(userID); err != nil {
+		return fmt.Errorf("failed to delete totp: %w", err)
+	}
+
+	user.Username = ""
+	user.Email = ""
+	user.EmailVerifiedAt = time.Time{}
+	user.DisplayName = ""
+	user.PasswordHash = ""
+	user.PasswordHistory = nil
+	user.Status = entity.UserStatusDeleted
+	return u.userRepo.Save(user)
+}
+
+// SetStatus はアカウントを停止、または停止を解除します
+// 運用者向けの操作で、HTTPのエンドポイントは公開していません
+func (u *UserUseCase) SetStatus(ctx context.Context, userID, status string) error {
+	if status != entity.UserStatusActive && status != entity.UserStatusSuspended {
+		return fmt.Errorf("invalid account status %q", status)
+	}
+	user, err := u.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	user.Status = status
+	if err := u.userRepo.Save(user); err != nil {
+		return err
+	}
+	// 停止したアカウントはログイン中のセッションも終了させる
+	if status == entity.UserStatusSuspended {
+		return u.sessions.DeleteUserSessions(ctx, userID)
+	}
+	return nil
+}
+
+// checkAvailable はユーザー名とメールアドレスが userID 以外のユーザーに使われていないかを確認します
+func (u *UserUseCase) checkAvailable(userID, username, email string) error {
+	existing, err := u.userRepo.FindByUsername(username)
+	if err == nil && existing.ID != userID {
+		return ErrUsernameTaken
+	}
+	if err != nil && !errors.Is(err, entity.ErrUserNotFound) {
+		return err
+	}
+
+	existing, err = u.userRepo.FindByEmail(email)
+	if err == nil && existing.ID != userID {
+		return ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, entity.ErrUserNotFound) {
+		return err
+	}
+	return nil
+}
+
+// normalizeEmail はメールアドレスを検証し、前後の空白を除いて小文字にします
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || len(email) > maxEmailLength {
+		return "", ErrInvalidEmail
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
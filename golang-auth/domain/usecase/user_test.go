@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/webauthntest"
+)
+
+// fakeSessionTerminator は終了させたユーザーのセッションを記録します
+type fakeSessionTerminator struct {
+	terminated []string
+}
+
+func (f *fakeSessionTerminator) DeleteUserSessions(ctx context.Context, userID string) error {
+	f.terminated = append(f.terminated, userID)
+	return nil
+}
+
+func newTestUserUseCase() (*UserUseCase, *mock.MockUserRepository, *mock.MockPasskeyRepository, *fakeSessionTerminator) {
+	userRepo := mock.NewMockUserRepository()
+	passkeyRepo := mock.NewMockPasskeyRepository()
+	sessions := &fakeSessionTerminator{}
+	return NewUserUseCase(userRepo, passkeyRepo, mock.NewMockTOTPRepository(), sessions, testHasher, testPasswordPolicy), userRepo, passkeyRepo, sessions
+}
+
+func TestSignUp(t *testing.T) {
+	// テストケースの準備
+	userUseCase, userRepo, _, _ := newTestUserUseCase()
+
+	// テスト実行
+	user, err := userUseCase.SignUp(context.Background(), entity.SignUpRequest{
+		Username: "new-user",
+		Email:    " New.User@Example.com ",
+		Password: "correct horse battery staple",
+	})
+
+	// アサーション
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.ID)
+	assert.Equal(t, "new.user@example.com", user.Email)
+	assert.Equal(t, "new-user", user.DisplayName)
+	assert.Equal(t, entity.UserStatusActive, user.Status)
+	_, err = testHasher.Verify("correct horse battery staple", user.PasswordHash)
+	assert.NoError(t, err)
+	assert.False(t, user.PasswordChangedAt.IsZero())
+	assert.Same(t, user, userRepo.Users[user.ID])
+
+	// 登録したメールアドレスとパスワードでログインできる
+	verified, err := NewPasswordCredentialVerifier(userRepo, testHasher).Verify("new.user@example.com", "correct horse battery staple")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, verified.ID)
+}
+
+func TestSignUpValidation(t *testing.T) {
+	valid := entity.SignUpRequest{Username: "new-user", Email: "new@example.com", Password: "password123"}
+
+	tests := []struct {
+		name    string
+		modify  func(req *entity.SignUpRequest)
+		wantErr error
+	}{
+		{"短いユーザー名", func(req *entity.SignUpRequest) { req.Username = "ab" }, ErrInvalidUsername},
+		{"使えない文字を含むユーザー名", func(req *entity.SignUpRequest) { req.Username = "new user" }, ErrInvalidUsername},
+		{"不正なメールアドレス", func(req *entity.SignUpRequest) { req.Email = "not-an-email" }, ErrInvalidEmail},
+		{"名前付きのメールアドレス", func(req *entity.SignUpRequest) { req.Email = "New <new@example.com>" }, ErrInvalidEmail},
+		{"短いパスワード", func(req *entity.SignUpRequest) { req.Password = "short" }, ErrInvalidPassword},
+		{"長すぎるパスワード", func(req *entity.SignUpRequest) { req.Password = strings.Repeat("a", 129) }, ErrInvalidPassword},
+		{"長すぎる表示名", func(req *entity.SignUpRequest) { req.DisplayName = strings.Repeat("あ", 65) }, ErrInvalidDisplayName},
+		{"使用済みのユーザー名", func(req *entity.SignUpRequest) { req.Username = "test-user" }, ErrUsernameTaken},
+		{"登録済みのメールアドレス", func(req *entity.SignUpRequest) { req.Email = "TEST@example.com" }, ErrEmailTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テストケースの準備
+			userUseCase, userRepo, _, _ := newTestUserUseCase()
+			userRepo.Save(&entity.User{ID: "test-user-id", Username: "test-user", Email: "test@example.com"})
+			req := valid
+			tt.modify(&req)
+
+			// テスト実行
+			_, err := userUseCase.SignUp(context.Background(), req)
+
+			// アサーション
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Len(t, userRepo.Users, 1)
+		})
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	// テストケースの準備
+	userUseCase, userRepo, _, _ := newTestUserUseCase()
+	userRepo.Save(&entity.User{ID: "test-user-id", Username: "test-user", Email: "test@example.com", DisplayName: "Test", Status: entity.UserStatusActive})
+	userRepo.Save(&entity.User{ID: "other-user-id", Username: "other-user", Email: "other@example.com", Status: entity.UserStatusActive})
+	displayName := "テストユーザー"
+	email := "Renamed@Example.com"
+
+	// テスト実行
+	user, err := userUseCase.UpdateProfile(context.Background(), "test-user-id", entity.ProfileUpdateRequest{
+		Email:       &email,
+		DisplayName: &displayName,
+	})
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "test-user", user.Username)
+	assert.Equal(t, "renamed@example.com", user.Email)
+	assert.Equal(t, "テストユーザー", user.DisplayName)
+
+	// 他のユーザーのユーザー名には変更できないが、自分の現在のユーザー名は指定できる
+	taken := "other-user"
+	_, err = userUseCase.UpdateProfile(context.Background(), "test-user-id", entity.ProfileUpdateRequest{Username: &taken})
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+	same := "test-user"
+	_, err = userUseCase.UpdateProfile(context.Background(), "test-user-id", entity.ProfileUpdateRequest{Username: &same})
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed@example.com", userRepo.Users["test-user-id"].Email)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	// テストケースの準備
+	userUseCase, userRepo, passkeyRepo, sessions := newTestUserUseCase()
+	userRepo.Save(newTestUser(t, "test-password"))
+	passkeyRepo.SaveCredential(&entity.Credential{ID: "credential-1", UserHandle: []byte("test-user-id")})
+	passkeyRepo.SaveCredential(&entity.Credential{ID: "credential-2", UserHandle: []byte("other-user-id")})
+	totpRepo := userUseCase.totpRepo.(*mock.MockTOTPRepository)
+	for _, userID := range []string{"test-user-id", "other-user-id"} {
+		totpRepo.Save(&entity.TOTPCredential{UserID: userID, Secret: []byte("secret"), ConfirmedAt: time.Now()})
+		totpRepo.ReplaceRecoveryCodes(userID, []string{"recovery-code-hash"})
+	}
+
+	// テスト実行
+	err := userUseCase.DeleteAccount(context.Background(), "test-user-id")
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test-user-id"}, sessions.terminated)
+	assert.NotContains(t, passkeyRepo.Credentials, "credential-1")
+	assert.Contains(t, passkeyRepo.Credentials, "credential-2")
+	assert.NotContains(t, totpRepo.Credentials, "test-user-id")
+	assert.NotContains(t, totpRepo.RecoveryCodes, "test-user-id")
+	assert.Contains(t, totpRepo.Credentials, "other-user-id")
+	assert.Contains(t, totpRepo.RecoveryCodes, "other-user-id")
+
+	deleted := userRepo.Users["test-user-id"]
+	assert.Equal(t, entity.UserStatusDeleted, deleted.Status)
+	assert.Empty(t, deleted.Email)
+	assert.Empty(t, deleted.PasswordHash)
+
+	_, err = userUseCase.GetProfile(context.Background(), "test-user-id")
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+	// 退会したユーザーのメールアドレスは再び登録できる
+	_, err = userUseCase.SignUp(context.Background(), entity.SignUpRequest{Username: "test-user", Email: "test@example.com", Password: "password123"})
+	assert.NoError(t, err)
+}
+
+func TestPasskeySignInAfterUsernameChange(t *testing.T) {
+	// テストケースの準備
+	userUseCase, userRepo, passkeyRepo, _ := newTestUserUseCase()
+	userRepo.Save(newTestUser(t, "test-password"))
+	passkeyUseCase := NewPasskeyUseCase(passkeyRepo, userRepo, mock.NewMockCeremonyRepository(), testWebAuthnConfig(), nil)
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credentialID := webauthn.EncodeBase64(registerPasskey(t, passkeyUseCase, authenticator))
+	renamed := "renamed-user"
+	_, err := userUseCase.UpdateProfile(context.Background(), "test-user-id", entity.ProfileUpdateRequest{Username: &renamed})
+	require.NoError(t, err)
+
+	// テスト実行
+	options, err := passkeyUseCase.StartAuthentication(context.Background(), "renamed-user")
+
+	// アサーション
+	// 変更後のユーザー名で登録済みのパスキーを使える
+	require.NoError(t, err)
+	require.Len(t, options.PublicKey.AllowCredentials, 1)
+	assert.Equal(t, credentialID, options.PublicKey.AllowCredentials[0].ID)
+
+	// 以前のユーザー名を別のユーザーが使っても、前の持ち主のパスキーは許可しない
+	_, err = userUseCase.SignUp(context.Background(), entity.SignUpRequest{Username: "test-user", Email: "other@example.com", Password: "password123"})
+	require.NoError(t, err)
+	_, err = passkeyUseCase.StartAuthentication(context.Background(), "test-user")
+	assert.ErrorIs(t, err, ErrNoPasskeys)
+}
+
+func TestSuspendedUserCannotSignIn(t *testing.T) {
+	// テストケースの準備
+	userUseCase, userRepo, passkeyRepo, sessions := newTestUserUseCase()
+	userRepo.Save(newTestUser(t, "test-password"))
+
+	// テスト実行
+	err := userUseCase.SetStatus(context.Background(), "test-user-id", entity.UserStatusSuspended)
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test-user-id"}, sessions.terminated)
+
+	_, err = NewPasswordCredentialVerifier(userRepo, testHasher).Verify("test@example.com", "test-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	passkeyUseCase := NewPasskeyUseCase(passkeyRepo, userRepo, mock.NewMockCeremonyRepository(), testWebAuthnConfig(), nil)
+	_, err = passkeyUseCase.StartRegistration(context.Background(), "test-user-id")
+	assert.ErrorIs(t, err, ErrAccountInactive)
+
+	// 停止を解除すると再びログインできる
+	require.NoError(t, userUseCase.SetStatus(context.Background(), "test-user-id", entity.UserStatusActive))
+	_, err = NewPasswordCredentialVerifier(userRepo, testHasher).Verify("test@example.com", "test-password")
+	assert.NoError(t, err)
+}
+
+func TestDeleteUserSessions(t *testing.T) {
+	// テストケースの準備
+	mockAuthleteClient := mock.NewMockAuthleteClient()
+	authUseCase := NewAuthUseCase(mock.NewMockAuthRepository(), mock.NewMockSessionRepository(), mockAuthleteClient, &config.Config{}, mockAuthleteClient, NewPasswordCredentialVerifier(mock.NewMockUserRepository(), testHasher), nil, nil)
+	authUseCase.StoreSession(&entity.Session{ID: "session-1", UserID: "test-user-id", Tokens: entity.Tokens{AccessToken: "access-1"}})
+	authUseCase.StoreSession(&entity.Session{ID: "session-2", UserID: "test-user-id", Tokens: entity.Tokens{AccessToken: "access-2"}})
+	authUseCase.StoreSession(&entity.Session{ID: "session-3", UserID: "other-user-id", Tokens: entity.Tokens{AccessToken: "access-3"}})
+
+	// テスト実行
+	err := authUseCase.DeleteUserSessions(context.Background(), "test-user-id")
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Len(t, mockAuthleteClient.Revoked, 2)
+	_, err = authUseCase.GetSessionUserID(context.Background(), "session-1")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	userID, err := authUseCase.GetSessionUserID(context.Background(), "session-3")
+	require.NoError(t, err)
+	assert.Equal(t, "other-user-id", userID)
+}
@@ -0,0 +1,96 @@
+package authlete
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker はAuthleteの障害が続いている間、呼び出しを即座に失敗させます
+// 連続失敗が threshold に達すると open になり、cooldown 経過後に1件だけ試行を許可します
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow は呼び出しを行ってよいかを判定し、open 中であればエラーを返します
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return &AuthleteError{Code: "CIRCUIT_OPEN", Message: "Authlete calls are suspended", Err: entity.ErrAuthleteUnavailable}
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		// 試行中の呼び出しの結果が出るまで他の呼び出しは止める
+		return &AuthleteError{Code: "CIRCUIT_OPEN", Message: "Authlete calls are suspended", Err: entity.ErrAuthleteUnavailable}
+	default:
+		return nil
+	}
+}
+
+// success は呼び出しの成功を記録し、closed に戻します
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+// failure はAuthleteの障害による失敗を記録します
+func (b *circuitBreaker) failure() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release は障害とも成功とも判定できない結果で試行を終えた場合に、half-open の試行枠を戻します
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+		b.openedAt = b.now().Add(-b.cooldown)
+	}
+}
@@ -2,11 +2,14 @@ package authlete
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/pkg/config"
@@ -18,17 +21,42 @@ type client struct {
 	httpClient interface {
 		Do(req *http.Request) (*http.Response, error)
 	}
+	retry   retryPolicy
+	breaker *circuitBreaker
+	metrics *Metrics
 }
 
 func NewClient(cfg *config.Config) *client {
-	return &client{
-		config:     cfg,
-		httpClient: &http.Client{},
+	c := &client{
+		config: cfg,
+		retry: retryPolicy{
+			maxRetries: cfg.AuthleteMaxRetries,
+			baseDelay:  cfg.AuthleteRetryBaseDelay,
+			maxDelay:   cfg.AuthleteRetryMaxDelay,
+		},
+		breaker: newCircuitBreaker(cfg.AuthleteBreakerThreshold, cfg.AuthleteBreakerCooldown),
+		metrics: newMetrics(),
 	}
+	// contextの期限とは別に、トランスポート層でも呼び出しが無期限に滞留しないようにする
+	c.httpClient = &http.Client{Timeout: c.requestTimeout()}
+	return c
 }
 
-func (c *client) sendRequest(method, url string, body []byte) ([]byte, error) {
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+// Metrics はAuthlete API呼び出しの統計を返します
+func (c *client) Metrics() *Metrics {
+	return c.metrics
+}
+
+// requestTimeout はAuthlete API呼び出し1回あたりの期限を返します
+func (c *client) requestTimeout() time.Duration {
+	if c.config.AuthleteRequestTimeout > 0 {
+		return c.config.AuthleteRequestTimeout
+	}
+	return config.DefaultAuthleteRequestTimeout
+}
+
+func (c *client) sendRequest(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, &AuthleteError{Code: "REQUEST_ERROR", Message: "Failed to create request", Err: err}
 	}
@@ -39,85 +67,194 @@ func (c *client) sendRequest(method, url string, body []byte) ([]byte, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, &AuthleteError{Code: "REQUEST_ERROR", Message: "Failed to send request", Err: err}
+		return nil, newRequestError(ctx, "Failed to send request", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &AuthleteError{Code: "READ_ERROR", Message: "Failed to read response body", Err: err}
+		return nil, newRequestError(ctx, "Failed to read response body", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newHTTPError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
 }
 
-func (c *client) RequestAuthorization(params map[string]string) (*entity.AuthResponse, error) {
-	apiURL := fmt.Sprintf("%s/%s/auth/authorization", c.config.AuthleteBaseURL, c.config.AuthleteServiceID)
+// newRequestError は通信エラーをAuthleteErrorに変換します
+// 期限切れやキャンセルの場合は errors.Is で context のエラーを判定できるようにします
+func newRequestError(ctx context.Context, message string, err error) *AuthleteError {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &AuthleteError{Code: "TIMEOUT", Message: message, Err: ctx.Err()}
+	case errors.Is(ctx.Err(), context.Canceled):
+		return &AuthleteError{Code: "CANCELED", Message: message, Err: ctx.Err()}
+	default:
+		return &AuthleteError{Code: "REQUEST_ERROR", Message: message, Err: err}
+	}
+}
 
-	values := url.Values{}
-	for k, v := range params {
-		values.Set(k, v)
+// newHTTPError はAuthleteが返したHTTPエラーをAuthleteErrorに変換します
+func newHTTPError(statusCode int, body []byte) *AuthleteError {
+	message := http.StatusText(statusCode)
+
+	var result entity.AuthleteResult
+	if err := json.Unmarshal(body, &result); err == nil && result.ResultMessage != "" {
+		message = result.ResultMessage
 	}
 
-	values.Set("redirect_uri", c.config.AuthleteRedirectURI)
+	return &AuthleteError{Code: "HTTP_ERROR", Message: message, StatusCode: statusCode}
+}
 
-	reqBody := map[string]string{
-		"parameters": values.Encode(),
+// sendWithRetry はサーキットブレーカーを通してAPIを呼び出し、冪等なAPIであれば一時的な障害時に再試行します
+func (c *client) sendWithRetry(ctx context.Context, path string, body []byte) ([]byte, error) {
+	apiURL := fmt.Sprintf("%s/%s%s", c.config.AuthleteBaseURL, c.config.AuthleteServiceID, path)
+
+	maxAttempts := 1
+	if idempotentAPIs[path] {
+		maxAttempts += c.retry.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			c.metrics.record(path, func(s *APIStats) { s.Retries++ })
+			if err := sleepContext(ctx, c.retry.backoff(attempt-1)); err != nil {
+				return nil, newRequestError(ctx, "Retry aborted", err)
+			}
+		}
+
+		if err := c.breaker.allow(); err != nil {
+			c.metrics.record(path, func(s *APIStats) { s.Rejected++ })
+			return nil, err
+		}
+
+		c.metrics.record(path, func(s *APIStats) { s.Attempts++ })
+		respBody, err := c.sendAttempt(ctx, apiURL, body)
+		if err == nil {
+			c.breaker.success()
+			return respBody, nil
+		}
+
+		lastErr = err
+		switch {
+		case ctx.Err() != nil:
+			// 呼び出し元のキャンセルはAuthleteの障害として数えない
+			c.breaker.release()
+		case isRetryable(err):
+			c.breaker.failure()
+		default:
+			// 4xx などAuthleteは応答できている
+			c.breaker.success()
+		}
+
+		if ctx.Err() != nil || !isRetryable(err) {
+			break
+		}
+		logger.LogWarning("Authlete %s attempt %d/%d failed: %v", path, attempt, maxAttempts, err)
 	}
 
+	c.metrics.record(path, func(s *APIStats) { s.Failures++ })
+	return nil, lastErr
+}
+
+// sendAttempt は1回分の呼び出しに期限を設定してリクエストを送信します
+func (c *client) sendAttempt(ctx context.Context, apiURL string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.requestTimeout())
+	defer cancel()
+
+	return c.sendRequest(ctx, "POST", apiURL, body)
+}
+
+// postJSON はAuthleteのAPIへJSONをPOSTし、レスポンスをresultにデコードします
+func (c *client) postJSON(ctx context.Context, path string, reqBody interface{}, result interface{}) error {
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		logger.LogError("Error marshaling request body: %v", err)
-		return nil, &AuthleteError{Code: "MARSHAL_ERROR", Message: "Failed to marshal request body", Err: err}
+		return &AuthleteError{Code: "MARSHAL_ERROR", Message: "Failed to marshal request body", Err: err}
 	}
 
-	body, err := c.sendRequest("POST", apiURL, jsonBody)
+	body, err := c.sendWithRetry(ctx, path, jsonBody)
 	if err != nil {
 		logger.LogError("Error sending request: %v", err)
-		return nil, err
+		return err
 	}
 
-	var result entity.AuthResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := json.Unmarshal(body, result); err != nil {
 		logger.LogError("Error unmarshaling response body: %v", err)
-		return nil, &AuthleteError{Code: "UNMARSHAL_ERROR", Message: "Failed to unmarshal response body", Err: err}
+		return &AuthleteError{Code: "UNMARSHAL_ERROR", Message: "Failed to unmarshal response body", Err: err}
 	}
 
-	return &result, nil
+	return nil
 }
 
-func (c *client) IssueAuthorization(ticket string) (*entity.AuthResponse, error) {
-	apiURL := fmt.Sprintf("%s/%s/auth/authorization/issue", c.config.AuthleteBaseURL, c.config.AuthleteServiceID)
+func (c *client) RequestAuthorization(ctx context.Context, params map[string]string) (*entity.AuthResponse, error) {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+
+	values.Set("redirect_uri", c.config.AuthleteRedirectURI)
 
 	reqBody := map[string]string{
-		"ticket":  ticket,
-		"subject": "yamakenji",
+		"parameters": values.Encode(),
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		logger.LogError("Error marshaling request body: %v", err)
-		return nil, &AuthleteError{Code: "MARSHAL_ERROR", Message: "Failed to marshal request body", Err: err}
+	var result entity.AuthResponse
+	if err := c.postJSON(ctx, "/auth/authorization", reqBody, &result); err != nil {
+		return nil, err
 	}
 
-	body, err := c.sendRequest("POST", apiURL, jsonBody)
-	if err != nil {
-		logger.LogError("Error sending request: %v", err)
+	// LOCATION / FORM はクライアントへのエラー応答を意味する
+	if err := checkAction("/auth/authorization", result.AuthleteResult, result.ResponseContent,
+		entity.ActionInteraction, entity.ActionNoInteraction); err != nil {
 		return nil, err
 	}
 
+	return &result, nil
+}
+
+func (c *client) IssueAuthorization(ctx context.Context, req entity.AuthorizationIssueRequest) (*entity.AuthResponse, error) {
+	if req.Subject == "" {
+		return nil, &AuthleteError{Code: "INVALID_PARAMETER", Message: "Subject is required to issue authorization"}
+	}
+
 	var result entity.AuthResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		logger.LogError("Error unmarshaling response body: %v", err)
-		return nil, &AuthleteError{Code: "UNMARSHAL_ERROR", Message: "Failed to unmarshal response body", Err: err}
+	if err := c.postJSON(ctx, "/auth/authorization/issue", req, &result); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction("/auth/authorization/issue", result.AuthleteResult, result.ResponseContent,
+		entity.ActionLocation, entity.ActionForm); err != nil {
+		return nil, err
 	}
 
 	return &result, nil
 }
 
-func (c *client) ExchangeToken(params map[string]string) (*entity.TokenResponse, error) {
-	apiURL := fmt.Sprintf("%s/%s/auth/token", c.config.AuthleteBaseURL, c.config.AuthleteServiceID)
+// FailAuthorization 認可リクエストを失敗として終了し、クライアントへ返すエラーレスポンスを取得
+func (c *client) FailAuthorization(ctx context.Context, ticket, reason string) (*entity.AuthResponse, error) {
+	reqBody := map[string]string{
+		"ticket": ticket,
+		"reason": reason,
+	}
+
+	var result entity.AuthResponse
+	if err := c.postJSON(ctx, "/auth/authorization/fail", reqBody, &result); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction("/auth/authorization/fail", result.AuthleteResult, result.ResponseContent,
+		entity.ActionLocation, entity.ActionForm); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
 
+func (c *client) ExchangeToken(ctx context.Context, params map[string]string) (*entity.TokenResponse, error) {
 	values := url.Values{}
 	for k, v := range params {
 		values.Set(k, v)
@@ -129,55 +266,124 @@ func (c *client) ExchangeToken(params map[string]string) (*entity.TokenResponse,
 		"clientSecret": c.config.AuthleteClientSecret,
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		logger.LogError("Error marshaling request body: %v", err)
-		return nil, &AuthleteError{Code: "MARSHAL_ERROR", Message: "Failed to marshal request body", Err: err}
-	}
-
-	body, err := c.sendRequest("POST", apiURL, jsonBody)
-	if err != nil {
-		logger.LogError("Error sending request: %v", err)
+	var result entity.TokenResponse
+	if err := c.postJSON(ctx, "/auth/token", reqBody, &result); err != nil {
 		return nil, err
 	}
 
-	var result entity.TokenResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		logger.LogError("Error unmarshaling response body: %v", err)
-		return nil, &AuthleteError{Code: "UNMARSHAL_ERROR", Message: "Failed to unmarshal response body", Err: err}
+	if err := checkAction("/auth/token", result.AuthleteResult, result.ResponseContent,
+		entity.ActionOK); err != nil {
+		return nil, err
 	}
 
 	return &result, nil
 }
 
 // GetUserInfo アクセストークンからユーザー情報を取得
-func (c *client) GetUserInfo(accessToken string) (entity.UserInfo, error) {
-	apiURL := fmt.Sprintf("%s/%s/auth/userinfo", c.config.AuthleteBaseURL, c.config.AuthleteServiceID)
-	fmt.Println("access_token: ", accessToken)
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-			return entity.UserInfo{}, &AuthleteError{Code: "REQUEST_ERROR", Message: "Failed to create request", Err: err}
+// /auth/userinfo でトークンを検証した後、/auth/userinfo/issue でUserInfoレスポンスを生成する
+func (c *client) GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error) {
+	var userInfoResp entity.UserInfoResponse
+	if err := c.postJSON(ctx, "/auth/userinfo", map[string]string{"token": accessToken}, &userInfoResp); err != nil {
+		return entity.UserInfo{}, err
 	}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	if err := checkAction("/auth/userinfo", userInfoResp.AuthleteResult, userInfoResp.ResponseContent,
+		entity.ActionOK); err != nil {
+		return entity.UserInfo{}, err
+	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-			return entity.UserInfo{}, &AuthleteError{Code: "REQUEST_ERROR", Message: "Failed to send request", Err: err}
+	var issueResp entity.UserInfoIssueResponse
+	if err := c.postJSON(ctx, "/auth/userinfo/issue", map[string]string{"token": accessToken}, &issueResp); err != nil {
+		return entity.UserInfo{}, err
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-			return entity.UserInfo{}, &AuthleteError{Code: "READ_ERROR", Message: "Failed to read response body", Err: err}
+	if err := checkAction("/auth/userinfo/issue", issueResp.AuthleteResult, issueResp.ResponseContent,
+		entity.ActionJSON); err != nil {
+		return entity.UserInfo{}, err
 	}
 
 	var userInfo entity.UserInfo
-	if err := json.Unmarshal(respBody, &userInfo); err != nil {
-			return entity.UserInfo{}, &AuthleteError{Code: "UNMARSHAL_ERROR", Message: "Failed to unmarshal response body", Err: err}
+	if err := json.Unmarshal([]byte(issueResp.ResponseContent), &userInfo); err != nil {
+		return entity.UserInfo{}, &AuthleteError{Code: "UNMARSHAL_ERROR", Message: "Failed to unmarshal userinfo response", Err: err}
 	}
 
 	return userInfo, nil
-}
\ No newline at end of file
+}
+
+// Introspect リソースサーバー向けにアクセストークンを検証
+// トークンが無効・スコープ不足の場合もエラーではなく、actionで結果を返す
+func (c *client) Introspect(ctx context.Context, token string, scopes []string, subject string) (*entity.IntrospectionResponse, error) {
+	reqBody := struct {
+		Token   string   `json:"token"`
+		Scopes  []string `json:"scopes,omitempty"`
+		Subject string   `json:"subject,omitempty"`
+	}{
+		Token:   token,
+		Scopes:  scopes,
+		Subject: subject,
+	}
+
+	var result entity.IntrospectionResponse
+	if err := c.postJSON(ctx, "/auth/introspection", reqBody, &result); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction("/auth/introspection", result.AuthleteResult, result.ResponseContent,
+		entity.ActionOK, entity.ActionBadRequest, entity.ActionUnauthorized, entity.ActionForbidden); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// IntrospectStandard RFC 7662のイントロスペクションリクエストを処理し、レスポンスのJSONを取得
+func (c *client) IntrospectStandard(ctx context.Context, params map[string]string) (*entity.StandardIntrospectionResponse, error) {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+
+	reqBody := map[string]string{
+		"parameters": values.Encode(),
+	}
+
+	var result entity.StandardIntrospectionResponse
+	if err := c.postJSON(ctx, "/auth/introspection/standard", reqBody, &result); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction("/auth/introspection/standard", result.AuthleteResult, result.ResponseContent,
+		entity.ActionOK); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Revoke RFC 7009に従ってアクセストークンまたはリフレッシュトークンを失効させる
+func (c *client) Revoke(ctx context.Context, req entity.RevocationRequest) (*entity.RevocationResponse, error) {
+	values := url.Values{}
+	values.Set("token", req.Token)
+	if req.TokenTypeHint != "" {
+		values.Set("token_type_hint", req.TokenTypeHint)
+	}
+
+	// クライアント認証は req の認証情報だけで行う（呼び出し側が認証情報を省略しても、このクライアントの認証情報では補わない）
+	reqBody := map[string]string{
+		"parameters":   values.Encode(),
+		"clientId":     req.ClientID,
+		"clientSecret": req.ClientSecret,
+	}
+
+	var result entity.RevocationResponse
+	if err := c.postJSON(ctx, "/auth/revocation", reqBody, &result); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction("/auth/revocation", result.AuthleteResult, result.ResponseContent,
+		entity.ActionOK); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -1,29 +1,57 @@
 package authlete
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/pkg/config"
 )
 
 type MockHTTPClient struct {
 	Response *http.Response
 	Error    error
+	Request  *http.Request
 }
 
 func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	m.Request = req
 	return m.Response, m.Error
 }
 
+// SequenceHTTPClient は呼び出しごとに順番にレスポンスを返すモックです
+type SequenceHTTPClient struct {
+	Responses []func() (*http.Response, error)
+	Calls     int
+}
+
+func (m *SequenceHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	i := m.Calls
+	if i >= len(m.Responses) {
+		i = len(m.Responses) - 1
+	}
+	m.Calls++
+	return m.Responses[i]()
+}
+
+func jsonResponse(status int, body string) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+}
+
 func TestRequestAuthorization(t *testing.T) {
 	mockClient := &MockHTTPClient{
 		Response: &http.Response{
 			StatusCode: http.StatusOK,
-			Body:       io.NopCloser(strings.NewReader(`{"ticket": "test-ticket"}`)),
+			Body:       io.NopCloser(strings.NewReader(`{"action": "INTERACTION", "ticket": "test-ticket"}`)),
 		},
 	}
 
@@ -42,7 +70,7 @@ func TestRequestAuthorization(t *testing.T) {
 		"scope":         "openid",
 	}
 
-	resp, err := client.RequestAuthorization(params)
+	resp, err := client.RequestAuthorization(context.Background(), params)
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Equal(t, "test-ticket", resp.Ticket)
@@ -52,7 +80,7 @@ func TestIssueAuthorization(t *testing.T) {
 	mockClient := &MockHTTPClient{
 		Response: &http.Response{
 			StatusCode: http.StatusOK,
-			Body:       io.NopCloser(strings.NewReader(`{"ticket": "test-ticket"}`)),
+			Body:       io.NopCloser(strings.NewReader(`{"action": "LOCATION", "ticket": "test-ticket"}`)),
 		},
 	}
 
@@ -64,17 +92,40 @@ func TestIssueAuthorization(t *testing.T) {
 	client := NewClient(cfg)
 	client.httpClient = mockClient
 
-	resp, err := client.IssueAuthorization("test-ticket")
+	resp, err := client.IssueAuthorization(context.Background(), entity.AuthorizationIssueRequest{
+		Ticket:   "test-ticket",
+		Subject:  "test-subject",
+		AuthTime: 1234567890,
+		Claims:   `{"name":"Test User"}`,
+	})
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Equal(t, "test-ticket", resp.Ticket)
+
+	// リクエストボディに認証済みユーザーが含まれていることを確認
+	reqBody, err := io.ReadAll(mockClient.Request.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ticket":"test-ticket","subject":"test-subject","authTime":1234567890,"claims":"{\"name\":\"Test User\"}"}`, string(reqBody))
+}
+
+func TestIssueAuthorizationWithoutSubject(t *testing.T) {
+	cfg := &config.Config{
+		AuthleteBaseURL:     "http://test-server",
+		AuthleteServiceID:   "test-service",
+		AuthleteAccessToken: "test-token",
+	}
+	client := NewClient(cfg)
+	client.httpClient = &MockHTTPClient{}
+
+	_, err := client.IssueAuthorization(context.Background(), entity.AuthorizationIssueRequest{Ticket: "test-ticket"})
+	assert.Error(t, err)
 }
 
 func TestExchangeToken(t *testing.T) {
 	mockClient := &MockHTTPClient{
 		Response: &http.Response{
 			StatusCode: http.StatusOK,
-			Body:       io.NopCloser(strings.NewReader(`{"accessToken": "test-access-token"}`)),
+			Body:       io.NopCloser(strings.NewReader(`{"action": "OK", "accessToken": "test-access-token"}`)),
 		},
 	}
 
@@ -94,8 +145,218 @@ func TestExchangeToken(t *testing.T) {
 		"redirect_uri": "http://localhost:8081/auth/callback",
 	}
 
-	resp, err := client.ExchangeToken(params)
+	resp, err := client.ExchangeToken(context.Background(), params)
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Equal(t, "test-access-token", resp.AccessToken)
 }
+
+func TestRequestAuthorizationActionError(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body: io.NopCloser(strings.NewReader(`{
+				"action": "BAD_REQUEST",
+				"resultCode": "A001201",
+				"resultMessage": "[A001201] /auth/authorization, TLS must be used.",
+				"responseContent": "{\"error\":\"invalid_request\"}"
+			}`)),
+		},
+	}
+
+	cfg := &config.Config{
+		AuthleteBaseURL:     "http://test-server",
+		AuthleteServiceID:   "test-service",
+		AuthleteAccessToken: "test-token",
+	}
+	client := NewClient(cfg)
+	client.httpClient = mockClient
+
+	resp, err := client.RequestAuthorization(context.Background(), map[string]string{"response_type": "code"})
+	assert.Nil(t, resp)
+
+	var actionErr *entity.AuthleteActionError
+	assert.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, entity.ActionBadRequest, actionErr.Action)
+	assert.Equal(t, "A001201", actionErr.ResultCode)
+	assert.Equal(t, `{"error":"invalid_request"}`, actionErr.ResponseContent)
+}
+
+func TestExchangeTokenHTTPError(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Body:       io.NopCloser(strings.NewReader(`{"resultCode": "A001101", "resultMessage": "Authentication of the service failed."}`)),
+		},
+	}
+
+	cfg := &config.Config{
+		AuthleteBaseURL:     "http://test-server",
+		AuthleteServiceID:   "test-service",
+		AuthleteAccessToken: "invalid-token",
+	}
+	client := NewClient(cfg)
+	client.httpClient = mockClient
+
+	resp, err := client.ExchangeToken(context.Background(), map[string]string{"grant_type": "authorization_code"})
+	assert.Nil(t, resp)
+
+	var authleteErr *AuthleteError
+	assert.ErrorAs(t, err, &authleteErr)
+	assert.Equal(t, "HTTP_ERROR", authleteErr.Code)
+	assert.Equal(t, http.StatusUnauthorized, authleteErr.StatusCode)
+	assert.Equal(t, "Authentication of the service failed.", authleteErr.Message)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(300 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		AuthleteBaseURL:        server.URL,
+		AuthleteServiceID:      "test-service",
+		AuthleteAccessToken:    "test-token",
+		AuthleteRequestTimeout: 50 * time.Millisecond,
+	}
+	client := NewClient(cfg)
+
+	_, err := client.RequestAuthorization(context.Background(), map[string]string{"response_type": "code"})
+
+	var authleteErr *AuthleteError
+	assert.ErrorAs(t, err, &authleteErr)
+	assert.Equal(t, "TIMEOUT", authleteErr.Code)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRequestCanceled(t *testing.T) {
+	cfg := &config.Config{
+		AuthleteBaseURL:     "http://test-server",
+		AuthleteServiceID:   "test-service",
+		AuthleteAccessToken: "test-token",
+	}
+	client := NewClient(cfg)
+
+	// ブラウザが切断した場合を想定し、呼び出し前にキャンセル済みのcontextを渡す
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.ExchangeToken(ctx, map[string]string{"grant_type": "authorization_code"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRetryIdempotentAPI(t *testing.T) {
+	mockClient := &SequenceHTTPClient{
+		Responses: []func() (*http.Response, error){
+			jsonResponse(http.StatusServiceUnavailable, `{}`),
+			func() (*http.Response, error) { return nil, errors.New("connection reset by peer") },
+			jsonResponse(http.StatusOK, `{"action": "OK", "subject": "test-user-id"}`),
+		},
+	}
+
+	cfg := &config.Config{
+		AuthleteBaseURL:        "http://test-server",
+		AuthleteServiceID:      "test-service",
+		AuthleteAccessToken:    "test-token",
+		AuthleteMaxRetries:     2,
+		AuthleteRetryBaseDelay: time.Millisecond,
+		AuthleteRetryMaxDelay:  5 * time.Millisecond,
+	}
+	client := NewClient(cfg)
+	client.httpClient = mockClient
+
+	resp, err := client.Introspect(context.Background(), "test-access-token", nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-user-id", resp.Subject)
+	assert.Equal(t, 3, mockClient.Calls)
+
+	stats := client.Metrics().Snapshot()["/auth/introspection"]
+	assert.Equal(t, int64(3), stats.Attempts)
+	assert.Equal(t, int64(2), stats.Retries)
+	assert.Equal(t, int64(0), stats.Failures)
+}
+
+func TestNoRetryForTokenAPI(t *testing.T) {
+	mockClient := &SequenceHTTPClient{
+		Responses: []func() (*http.Response, error){
+			jsonResponse(http.StatusBadGateway, `{}`),
+		},
+	}
+
+	cfg := &config.Config{
+		AuthleteBaseURL:     "http://test-server",
+		AuthleteServiceID:   "test-service",
+		AuthleteAccessToken: "test-token",
+		AuthleteMaxRetries:  2,
+	}
+	client := NewClient(cfg)
+	client.httpClient = mockClient
+
+	// 認可コードを消費するAPIは再試行しない
+	_, err := client.ExchangeToken(context.Background(), map[string]string{"grant_type": "authorization_code"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, mockClient.Calls)
+}
+
+func TestNoRetryForAuthorizationAPI(t *testing.T) {
+	mockClient := &SequenceHTTPClient{
+		Responses: []func() (*http.Response, error){
+			jsonResponse(http.StatusBadGateway, `{}`),
+		},
+	}
+
+	cfg := &config.Config{
+		AuthleteBaseURL:     "http://test-server",
+		AuthleteServiceID:   "test-service",
+		AuthleteAccessToken: "test-token",
+		AuthleteMaxRetries:  2,
+	}
+	client := NewClient(cfg)
+	client.httpClient = mockClient
+
+	// 呼び出すたびにチケットを発行するAPIは再試行しない
+	_, err := client.RequestAuthorization(context.Background(), map[string]string{"response_type": "code"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, mockClient.Calls)
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	mockClient := &SequenceHTTPClient{
+		Responses: []func() (*http.Response, error){
+			jsonResponse(http.StatusServiceUnavailable, `{}`),
+		},
+	}
+
+	cfg := &config.Config{
+		AuthleteBaseURL:          "http://test-server",
+		AuthleteServiceID:        "test-service",
+		AuthleteAccessToken:      "test-token",
+		AuthleteBreakerThreshold: 2,
+		AuthleteBreakerCooldown:  time.Minute,
+	}
+	client := NewClient(cfg)
+	client.httpClient = mockClient
+
+	for i := 0; i < 2; i++ {
+		_, err := client.ExchangeToken(context.Background(), map[string]string{})
+		assert.Error(t, err)
+	}
+
+	// しきい値に達した後はAuthleteを呼び出さずに失敗する
+	_, err := client.ExchangeToken(context.Background(), map[string]string{})
+	assert.ErrorIs(t, err, entity.ErrAuthleteUnavailable)
+	assert.Equal(t, 2, mockClient.Calls)
+
+	// クールダウン経過後は1件だけ試行し、成功すれば閉じる
+	client.breaker.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	mockClient.Responses = []func() (*http.Response, error){
+		jsonResponse(http.StatusOK, `{"action": "OK", "accessToken": "test-access-token"}`),
+	}
+	resp, err := client.ExchangeToken(context.Background(), map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "test-access-token", resp.AccessToken)
+}
@@ -2,13 +2,17 @@ package authlete
 
 import (
 	"fmt"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
 )
 
 // AuthleteError は、Authleteクライアントで発生するエラーを表します。
+// StatusCode はAuthleteがHTTPエラーを返した場合のステータスコードです。
 type AuthleteError struct {
-	Code    string
-	Message string
-	Err     error
+	Code       string
+	Message    string
+	StatusCode int
+	Err        error
 }
 
 func (e *AuthleteError) Error() string {
@@ -17,3 +21,32 @@ func (e *AuthleteError) Error() string {
 	}
 	return fmt.Sprintf("AuthleteError: %s - %s", e.Code, e.Message)
 }
+
+func (e *AuthleteError) Unwrap() error {
+	return e.Err
+}
+
+// newActionError はAuthleteが返した想定外のactionをAuthleteErrorに変換します
+func newActionError(api string, result entity.AuthleteResult, responseContent string) *AuthleteError {
+	return &AuthleteError{
+		Code:    "ACTION_ERROR",
+		Message: fmt.Sprintf("Unexpected action from %s", api),
+		Err: &entity.AuthleteActionError{
+			API:             api,
+			Action:          result.Action,
+			ResultCode:      result.ResultCode,
+			ResultMessage:   result.ResultMessage,
+			ResponseContent: responseContent,
+		},
+	}
+}
+
+// checkAction はactionが期待される値のいずれかであることを確認します
+func checkAction(api string, result entity.AuthleteResult, responseContent string, expected ...string) error {
+	for _, action := range expected {
+		if result.Action == action {
+			return nil
+		}
+	}
+	return newActionError(api, result, responseContent)
+}
@@ -0,0 +1,201 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// handleAuthorization は /auth/authorization を模倣し、認可リクエストを検証してチケットを発行します
+func (s *Server) handleAuthorization(body []byte) interface{} {
+	var req struct {
+		Parameters string `json:"parameters"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return newResult("BAD_REQUEST", "A004201", "The request body is malformed.", oauthError("invalid_request", "malformed request"))
+	}
+
+	params, err := url.ParseQuery(req.Parameters)
+	if err != nil {
+		return newResult("BAD_REQUEST", "A004202", "The parameters are malformed.", oauthError("invalid_request", "malformed parameters"))
+	}
+
+	// クライアントとリダイレクトURIが確定するまではリダイレクトできないため BAD_REQUEST を返す
+	client, ok := s.clients[params.Get("client_id")]
+	if !ok {
+		return newResult("BAD_REQUEST", "A004301", "The client ID is unknown.", oauthError("invalid_request", "unknown client_id"))
+	}
+	redirectURI := params.Get("redirect_uri")
+	if !contains(client.RedirectURIs, redirectURI) {
+		return newResult("BAD_REQUEST", "A004302", "The redirect URI is not registered.", oauthError("invalid_request", "redirect_uri mismatch"))
+	}
+
+	state := params.Get("state")
+	if params.Get("response_type") != "code" {
+		return newResult("LOCATION", "A004303", "The response type is not supported.",
+			errorRedirect(redirectURI, "unsupported_response_type", "only code is supported", state))
+	}
+
+	method := params.Get("code_challenge_method")
+	challenge := params.Get("code_challenge")
+	if challenge != "" && method == "" {
+		method = "plain"
+	}
+	if method != "" && method != "S256" && method != "plain" {
+		return newResult("LOCATION", "A004304", "The code challenge method is not supported.",
+			errorRedirect(redirectURI, "invalid_request", "unsupported code_challenge_method", state))
+	}
+	if method != "" && challenge == "" {
+		return newResult("LOCATION", "A004305", "The code challenge is missing.",
+			errorRedirect(redirectURI, "invalid_request", "code_challenge is required", state))
+	}
+
+	ticket := randomToken(32)
+	s.tickets[ticket] = &authorization{
+		clientID:            client.ID,
+		redirectURI:         redirectURI,
+		scopes:              strings.Fields(params.Get("scope")),
+		nonce:               params.Get("nonce"),
+		codeChallenge:       challenge,
+		codeChallengeMethod: method,
+		expiresAt:           s.now().Add(s.config.TicketTTL),
+	}
+
+	return struct {
+		result
+		Ticket string `json:"ticket"`
+	}{
+		result: newResult("INTERACTION", "A004001", "The authorization request is valid.", ""),
+		Ticket: ticket,
+	}
+}
+
+// handleAuthorizationIssue は /auth/authorization/issue を模倣し、チケットと引き換えに認可コードを発行します
+func (s *Server) handleAuthorizationIssue(body []byte) interface{} {
+	var req struct {
+		Ticket   string   `json:"ticket"`
+		Subject  string   `json:"subject"`
+		AuthTime int64    `json:"authTime"`
+		ACR      string   `json:"acr"`
+		Claims   string   `json:"claims"`
+		Scopes   []string `json:"scopes"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return newResult("BAD_REQUEST", "A004201", "The request body is malformed.", oauthError("invalid_request", "malformed request"))
+	}
+	if req.Subject == "" {
+		return newResult("BAD_REQUEST", "A004401", "The subject is missing.", oauthError("server_error", "subject is required"))
+	}
+
+	authz, ok := s.consumeTicket(req.Ticket)
+	if !ok {
+		return newResult("BAD_REQUEST", "A004402", "The ticket is invalid or expired.", oauthError("invalid_request", "invalid ticket"))
+	}
+
+	var claims map[string]interface{}
+	if req.Claims != "" {
+		if err := json.Unmarshal([]byte(req.Claims), &claims); err != nil {
+			return newResult("BAD_REQUEST", "A004403", "The claims are malformed.", oauthError("server_error", "malformed claims"))
+		}
+	}
+
+	authz.subject = req.Subject
+	authz.authTime = req.AuthTime
+	authz.acr = req.ACR
+	authz.claims = claims
+	if len(req.Scopes) > 0 {
+		authz.scopes = req.Scopes
+	}
+	authz.expiresAt = s.now().Add(s.config.CodeTTL)
+
+	code := randomToken(32)
+	s.codes[code] = authz
+
+	return newResult("LOCATION", "A004002", "The authorization code was issued.",
+		withQuery(authz.redirectURI, url.Values{"code": {code}}))
+}
+
+// failReasons はAuthleteの失敗理由とOAuthのエラーコードの対応です
+var failReasons = map[string]string{
+	"NOT_AUTHENTICATED":      "login_required",
+	"NOT_LOGGED_IN":          "login_required",
+	"DENIED":                 "access_denied",
+	"CONSENT_REQUIRED":       "consent_required",
+	"INTERACTION_REQUIRED":   "interaction_required",
+	"ACCOUNT_SELECTION":      "account_selection_required",
+	"SERVER_ERROR":           "server_error",
+	"UNKNOWN":                "server_error",
+	"INVALID_TARGET":         "invalid_target",
+	"NOT_AUTHORIZED":         "access_denied",
+	"NO_SUCH_CLIENT":         "invalid_request",
+	"MAX_AGE_NOT_SUPPORTED":  "invalid_request",
+	"EXCEEDS_MAX_AGE":        "login_required",
+	"DIFFERENT_SUBJECT":      "login_required",
+	"ACR_NOT_SATISFIED":      "access_denied",
+	"SESSION_NOT_AUTHORIZED": "access_denied",
+}
+
+// handleAuthorizationFail は /auth/authorization/fail を模倣し、クライアントへのエラーリダイレクトを生成します
+func (s *Server) handleAuthorizationFail(body []byte) interface{} {
+	var req struct {
+		Ticket string `json:"ticket"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return newResult("BAD_REQUEST", "A004201", "The request body is malformed.", oauthError("invalid_request", "malformed request"))
+	}
+
+	errorCode, ok := failReasons[req.Reason]
+	if !ok {
+		return newResult("BAD_REQUEST", "A004501", "The reason is unknown.", oauthError("server_error", "unknown reason"))
+	}
+
+	authz, ok := s.consumeTicket(req.Ticket)
+	if !ok {
+		return newResult("BAD_REQUEST", "A004402", "The ticket is invalid or expired.", oauthError("invalid_request", "invalid ticket"))
+	}
+
+	return newResult("LOCATION", "A004003", "The authorization request was rejected.",
+		errorRedirect(authz.redirectURI, errorCode, req.Reason, ""))
+}
+
+// consumeTicket はチケットを1回限り取り出します
+func (s *Server) consumeTicket(ticket string) (*authorization, bool) {
+	authz, ok := s.tickets[ticket]
+	if !ok {
+		return nil, false
+	}
+	delete(s.tickets, ticket)
+	if s.now().After(authz.expiresAt) {
+		return nil, false
+	}
+	return authz, true
+}
+
+func errorRedirect(redirectURI, code, description, state string) string {
+	values := url.Values{
+		"error":             {code},
+		"error_description": {description},
+	}
+	if state != "" {
+		values.Set("state", state)
+	}
+	return withQuery(redirectURI, values)
+}
+
+func withQuery(rawURL string, values url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + values.Encode()
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+// Package fake はAuthleteのAPIをプロセス内で模倣するHTTPサーバーを提供します
+// 統合テストや、Authleteのテナントが無い環境でのローカル開発に利用します
+package fake
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client は模倣するAuthleteに登録済みのクライアントアプリケーションです
+type Client struct {
+	ID           string
+	Secret       string
+	RedirectURIs []string
+}
+
+// Config はフェイクサーバーの設定です
+type Config struct {
+	ServiceID          string
+	ServiceAccessToken string
+	Issuer             string
+	Clients            []Client
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+	CodeTTL            time.Duration
+	TicketTTL          time.Duration
+}
+
+// Server はAuthleteのAPIを模倣する http.Handler です
+type Server struct {
+	config  Config
+	clients map[string]Client
+	now     func() time.Time
+
+	mu            sync.Mutex
+	tickets       map[string]*authorization
+	codes         map[string]*authorization
+	accessTokens  map[string]*grant
+	refreshTokens map[string]*grant
+}
+
+// authorization は認可リクエストの内容と、認可後に付与されたユーザー情報です
+type authorization struct {
+	clientID            string
+	redirectURI         string
+	scopes              []string
+	nonce               string
+	codeChallenge       string
+	codeChallengeMethod string
+	subject             string
+	authTime            int64
+	acr                 string
+	claims              map[string]interface{}
+	expiresAt           time.Time
+}
+
+// grant は発行済みのアクセストークンまたはリフレッシュトークンです
+type grant struct {
+	*authorization
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time
+}
+
+// New はフェイクサーバーを作成します
+func New(cfg Config) *Server {
+	if cfg.AccessTokenTTL == 0 {
+		cfg.AccessTokenTTL = time.Hour
+	}
+	if cfg.RefreshTokenTTL == 0 {
+		cfg.RefreshTokenTTL = 24 * time.Hour
+	}
+	if cfg.CodeTTL == 0 {
+		cfg.CodeTTL = 10 * time.Minute
+	}
+	if cfg.TicketTTL == 0 {
+		cfg.TicketTTL = 24 * time.Hour
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "https://fake-authlete.local"
+	}
+
+	clients := make(map[string]Client, len(cfg.Clients))
+	for _, c := range cfg.Clients {
+		clients[c.ID] = c
+	}
+
+	return &Server{
+		config:        cfg,
+		clients:       clients,
+		now:           time.Now,
+		tickets:       make(map[string]*authorization),
+		codes:         make(map[string]*authorization),
+		accessTokens:  make(map[string]*grant),
+		refreshTokens: make(map[string]*grant),
+	}
+}
+
+// ServeHTTP は /{serviceID}/auth/... へのリクエストを各APIに振り分けます
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResult(w, http.StatusMethodNotAllowed, "A001001", "Method not allowed.")
+		return
+	}
+
+	if !s.authenticateService(r) {
+		writeResult(w, http.StatusUnauthorized, "A001101", "Authentication of the service failed.")
+		return
+	}
+
+	prefix := "/" + s.config.ServiceID
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+	if path == r.URL.Path && s.config.ServiceID != "" {
+		writeResult(w, http.StatusNotFound, "A001002", "Unknown service.")
+		return
+	}
+
+	var handle func(body []byte) interface{}
+	switch path {
+	case "/auth/authorization":
+		handle = s.handleAuthorization
+	case "/auth/authorization/issue":
+		handle = s.handleAuthorizationIssue
+	case "/auth/authorization/fail":
+		handle = s.handleAuthorizationFail
+	case "/auth/token":
+		handle = s.handleToken
+	case "/auth/userinfo":
+		handle = s.handleUserInfo
+	case "/auth/userinfo/issue":
+		handle = s.handleUserInfoIssue
+	case "/auth/introspection":
+		handle = s.handleIntrospection
+	case "/auth/introspection/standard":
+		handle = s.handleStandardIntrospection
+	case "/auth/revocation":
+		handle = s.handleRevocation
+	default:
+		writeResult(w, http.StatusNotFound, "A001002", "Unknown API.")
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, "A001003", "Failed to read the request body.")
+		return
+	}
+
+	s.mu.Lock()
+	resp := handle(body)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// authenticateService はサービスアクセストークンを検証します
+func (s *Server) authenticateService(r *http.Request) bool {
+	if s.config.ServiceAccessToken == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.config.ServiceAccessToken)) == 1
+}
+
+// authenticateClient はクライアントIDとシークレットを検証します
+func (s *Server) authenticateClient(clientID, clientSecret string) (Client, bool) {
+	client, ok := s.clients[clientID]
+	if !ok {
+		return Client{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(clientSecret), []byte(client.Secret)) != 1 {
+		return Client{}, false
+	}
+	return client, true
+}
+
+// result はAuthleteのレスポンスに共通する項目です
+type result struct {
+	Action          string `json:"action"`
+	ResultCode      string `json:"resultCode"`
+	ResultMessage   string `json:"resultMessage"`
+	ResponseContent string `json:"responseContent,omitempty"`
+}
+
+func newResult(action, code, message, content string) result {
+	return result{Action: action, ResultCode: code, ResultMessage: message, ResponseContent: content}
+}
+
+// oauthError はRFC 6749形式のエラーレスポンスのJSONを生成します
+func oauthError(code, description string) string {
+	b, _ := json.Marshal(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+	return string(b)
+}
+
+func writeResult(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{
+		"resultCode":    code,
+		"resultMessage": message,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	return io.ReadAll(io.LimitReader(r.Body, 1<<20))
+}
+
+// randomToken はURLセーフなランダム文字列を生成します
+func randomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
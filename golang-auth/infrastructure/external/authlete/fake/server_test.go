@@ -0,0 +1,261 @@
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/infrastructure/external/authlete"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+)
+
+const (
+	testRedirectURI  = "https://poc-authlete.local/api/auth/callback"
+	testCodeVerifier = "test-code-verifier-0123456789-abcdefghijklmnopqrstuvwxyz"
+)
+
+func setupFakeAuthlete(t *testing.T) (*config.Config, func()) {
+	t.Helper()
+	server := httptest.NewServer(New(Config{
+		ServiceID:          "test-service",
+		ServiceAccessToken: "test-token",
+		Clients: []Client{
+			{ID: "test-client", Secret: "test-secret", RedirectURIs: []string{testRedirectURI}},
+		},
+	}))
+
+	cfg := &config.Config{
+		AuthleteBaseURL:      server.URL,
+		AuthleteServiceID:    "test-service",
+		AuthleteAccessToken:  "test-token",
+		AuthleteClientID:     "test-client",
+		AuthleteClientSecret: "test-secret",
+		AuthleteRedirectURI:  testRedirectURI,
+	}
+	return cfg, server.Close
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorize は認可リクエストから認可コードの取得までを行います
+func authorize(t *testing.T, ctx context.Context, cfg *config.Config) string {
+	t.Helper()
+	client := authlete.NewClient(cfg)
+
+	authResp, err := client.RequestAuthorization(ctx, map[string]string{
+		"response_type":         "code",
+		"client_id":             "test-client",
+		"scope":                 "openid",
+		"code_challenge":        codeChallenge(testCodeVerifier),
+		"code_challenge_method": "S256",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, authResp.Ticket)
+
+	issueResp, err := client.IssueAuthorization(ctx, entity.AuthorizationIssueRequest{
+		Ticket:  authResp.Ticket,
+		Subject: "user-1",
+		Claims:  `{"name":"Test User","email":"test@example.com"}`,
+	})
+	require.NoError(t, err)
+
+	location, err := url.Parse(issueResp.ResponseContent)
+	require.NoError(t, err)
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+	return code
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	cfg, closeServer := setupFakeAuthlete(t)
+	defer closeServer()
+	ctx := context.Background()
+	client := authlete.NewClient(cfg)
+
+	code := authorize(t, ctx, cfg)
+
+	// テスト実行
+	tokenResp, err := client.ExchangeToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  testRedirectURI,
+		"code_verifier": testCodeVerifier,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokenResp.AccessToken)
+	assert.NotEmpty(t, tokenResp.RefreshToken)
+	assert.NotEmpty(t, tokenResp.IdToken)
+	assert.NotZero(t, tokenResp.AccessTokenExpiresAt)
+
+	userInfo, err := client.GetUserInfo(ctx, tokenResp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userInfo.Sub)
+	assert.Equal(t, "Test User", userInfo.Name)
+	assert.Equal(t, "test@example.com", userInfo.Email)
+
+	// 認可コードは再利用できない
+	_, err = client.ExchangeToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  testRedirectURI,
+		"code_verifier": testCodeVerifier,
+	})
+	var actionErr *entity.AuthleteActionError
+	assert.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, entity.ActionBadRequest, actionErr.Action)
+}
+
+func TestPKCEMismatch(t *testing.T) {
+	cfg, closeServer := setupFakeAuthlete(t)
+	defer closeServer()
+	ctx := context.Background()
+
+	code := authorize(t, ctx, cfg)
+
+	_, err := authlete.NewClient(cfg).ExchangeToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  testRedirectURI,
+		"code_verifier": "wrong-verifier",
+	})
+
+	var actionErr *entity.AuthleteActionError
+	assert.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, entity.ActionBadRequest, actionErr.Action)
+	assert.Contains(t, actionErr.ResponseContent, "invalid_grant")
+}
+
+func TestInvalidClient(t *testing.T) {
+	cfg, closeServer := setupFakeAuthlete(t)
+	defer closeServer()
+	ctx := context.Background()
+
+	code := authorize(t, ctx, cfg)
+	cfg.AuthleteClientSecret = "wrong-secret"
+
+	_, err := authlete.NewClient(cfg).ExchangeToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  testRedirectURI,
+		"code_verifier": testCodeVerifier,
+	})
+
+	var actionErr *entity.AuthleteActionError
+	assert.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, entity.ActionInvalidClient, actionErr.Action)
+}
+
+func TestFailAuthorization(t *testing.T) {
+	cfg, closeServer := setupFakeAuthlete(t)
+	defer closeServer()
+	ctx := context.Background()
+	client := authlete.NewClient(cfg)
+
+	authResp, err := client.RequestAuthorization(ctx, map[string]string{
+		"response_type": "code",
+		"client_id":     "test-client",
+		"scope":         "openid",
+	})
+	require.NoError(t, err)
+
+	resp, err := client.FailAuthorization(ctx, authResp.Ticket, "NOT_AUTHENTICATED")
+	require.NoError(t, err)
+	assert.Equal(t, entity.ActionLocation, resp.Action)
+	assert.Contains(t, resp.ResponseContent, testRedirectURI+"?")
+	assert.Contains(t, resp.ResponseContent, "error=login_required")
+}
+
+func TestServiceAuthentication(t *testing.T) {
+	cfg, closeServer := setupFakeAuthlete(t)
+	defer closeServer()
+	cfg.AuthleteAccessToken = "wrong-token"
+
+	_, err := authlete.NewClient(cfg).RequestAuthorization(context.Background(), map[string]string{
+		"response_type": "code",
+		"client_id":     "test-client",
+	})
+
+	var authleteErr *authlete.AuthleteError
+	assert.ErrorAs(t, err, &authleteErr)
+	assert.Equal(t, 401, authleteErr.StatusCode)
+}
+
+func TestIntrospection(t *testing.T) {
+	cfg, closeServer := setupFakeAuthlete(t)
+	defer closeServer()
+	ctx := context.Background()
+	client := authlete.NewClient(cfg)
+
+	code := authorize(t, ctx, cfg)
+	tokenResp, err := client.ExchangeToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  testRedirectURI,
+		"code_verifier": testCodeVerifier,
+	})
+	require.NoError(t, err)
+
+	// テスト実行
+	resp, err := client.Introspect(ctx, tokenResp.AccessToken, []string{"openid"}, "user-1")
+	require.NoError(t, err)
+	assert.True(t, resp.Usable)
+	assert.Equal(t, "user-1", resp.Subject)
+
+	standard, err := client.IntrospectStandard(ctx, map[string]string{"token": tokenResp.AccessToken})
+	require.NoError(t, err)
+	assert.Contains(t, standard.ResponseContent, `"active":true`)
+
+	standard, err = client.IntrospectStandard(ctx, map[string]string{"token": "unknown-token"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"active":false}`, standard.ResponseContent)
+}
+
+func TestRevocation(t *testing.T) {
+	cfg, closeServer := setupFakeAuthlete(t)
+	defer closeServer()
+	ctx := context.Background()
+	client := authlete.NewClient(cfg)
+
+	code := authorize(t, ctx, cfg)
+	tokenResp, err := client.ExchangeToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  testRedirectURI,
+		"code_verifier": testCodeVerifier,
+	})
+	require.NoError(t, err)
+
+	// クライアントの認証情報が無い場合は失効できない
+	_, err = client.Revoke(ctx, entity.RevocationRequest{Token: tokenResp.RefreshToken, TokenTypeHint: "refresh_token"})
+	var actionErr *entity.AuthleteActionError
+	require.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, entity.ActionInvalidClient, actionErr.Action)
+
+	// テスト実行
+	_, err = client.Revoke(ctx, entity.RevocationRequest{
+		Token:         tokenResp.RefreshToken,
+		TokenTypeHint: "refresh_token",
+		ClientID:      cfg.AuthleteClientID,
+		ClientSecret:  cfg.AuthleteClientSecret,
+	})
+	require.NoError(t, err)
+
+	// リフレッシュトークンと対になるアクセストークンも失効する
+	_, err = client.GetUserInfo(ctx, tokenResp.AccessToken)
+	assert.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, entity.ActionUnauthorized, actionErr.Action)
+
+	// 他のクライアントの認証情報では失効できない
+	_, err = client.Revoke(ctx, entity.RevocationRequest{Token: tokenResp.AccessToken, ClientID: "test-client", ClientSecret: "wrong-secret"})
+	assert.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, entity.ActionInvalidClient, actionErr.Action)
+}
@@ -0,0 +1,186 @@
+package fake
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// tokenResponse はAuthleteの /auth/token のレスポンスのうち、本サービスが利用する項目です
+type tokenResponse struct {
+	result
+	GrantType             string   `json:"grantType,omitempty"`
+	ClientID              string   `json:"clientId,omitempty"`
+	Subject               string   `json:"subject,omitempty"`
+	Scopes                []string `json:"scopes,omitempty"`
+	AccessToken           string   `json:"accessToken,omitempty"`
+	AccessTokenExpiresAt  int64    `json:"accessTokenExpiresAt,omitempty"`
+	AccessTokenDuration   int64    `json:"accessTokenDuration,omitempty"`
+	RefreshToken          string   `json:"refreshToken,omitempty"`
+	RefreshTokenExpiresAt int64    `json:"refreshTokenExpiresAt,omitempty"`
+	IDToken               string   `json:"idToken,omitempty"`
+}
+
+// handleToken は /auth/token を模倣し、authorization_code と refresh_token グラントを処理します
+func (s *Server) handleToken(body []byte) interface{} {
+	var req struct {
+		Parameters   string `json:"parameters"`
+		ClientID     string `json:"clientId"`
+		ClientSecret string `json:"clientSecret"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return newResult("BAD_REQUEST", "A005201", "The request body is malformed.", oauthError("invalid_request", "malformed request"))
+	}
+
+	params, err := url.ParseQuery(req.Parameters)
+	if err != nil {
+		return newResult("BAD_REQUEST", "A005202", "The parameters are malformed.", oauthError("invalid_request", "malformed parameters"))
+	}
+
+	client, ok := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if !ok {
+		return newResult("INVALID_CLIENT", "A005301", "Client authentication failed.", oauthError("invalid_client", "client authentication failed"))
+	}
+
+	switch params.Get("grant_type") {
+	case "authorization_code":
+		return s.exchangeAuthorizationCode(client, params)
+	case "refresh_token":
+		return s.refresh(client, params)
+	default:
+		return newResult("BAD_REQUEST", "A005302", "The grant type is not supported.", oauthError("unsupported_grant_type", "unsupported grant_type"))
+	}
+}
+
+func (s *Server) exchangeAuthorizationCode(client Client, params url.Values) interface{} {
+	code := params.Get("code")
+	authz, ok := s.codes[code]
+	if !ok {
+		return newResult("BAD_REQUEST", "A005401", "The authorization code is invalid.", oauthError("invalid_grant", "invalid code"))
+	}
+	// 認可コードは検証結果にかかわらず1回限り
+	delete(s.codes, code)
+
+	if s.now().After(authz.expiresAt) {
+		return newResult("BAD_REQUEST", "A005402", "The authorization code has expired.", oauthError("invalid_grant", "code expired"))
+	}
+	if authz.clientID != client.ID {
+		return newResult("BAD_REQUEST", "A005403", "The authorization code was issued to another client.", oauthError("invalid_grant", "client mismatch"))
+	}
+	if params.Get("redirect_uri") != authz.redirectURI {
+		return newResult("BAD_REQUEST", "A005404", "The redirect URI does not match.", oauthError("invalid_grant", "redirect_uri mismatch"))
+	}
+	if !verifyPKCE(authz.codeChallenge, authz.codeChallengeMethod, params.Get("code_verifier")) {
+		return newResult("BAD_REQUEST", "A005405", "PKCE verification failed.", oauthError("invalid_grant", "code_verifier mismatch"))
+	}
+
+	return s.issueTokens(client, authz, "AUTHORIZATION_CODE", true)
+}
+
+func (s *Server) refresh(client Client, params url.Values) interface{} {
+	refreshToken := params.Get("refresh_token")
+	g, ok := s.refreshTokens[refreshToken]
+	if !ok || g.clientID != client.ID || s.now().After(g.expiresAt) {
+		return newResult("BAD_REQUEST", "A005501", "The refresh token is invalid.", oauthError("invalid_grant", "invalid refresh_token"))
+	}
+
+	// リフレッシュトークンはローテーションし、古いアクセストークンも失効させる
+	delete(s.refreshTokens, refreshToken)
+	delete(s.accessTokens, g.accessToken)
+
+	return s.issueTokens(client, g.authorization, "REFRESH_TOKEN", false)
+}
+
+func (s *Server) issueTokens(client Client, authz *authorization, grantType string, withIDToken bool) interface{} {
+	now := s.now()
+	accessExpiresAt := now.Add(s.config.AccessTokenTTL)
+	refreshExpiresAt := now.Add(s.config.RefreshTokenTTL)
+
+	accessToken := randomToken(32)
+	refreshToken := randomToken(32)
+	s.accessTokens[accessToken] = &grant{authorization: authz, accessToken: accessToken, refreshToken: refreshToken, expiresAt: accessExpiresAt}
+	s.refreshTokens[refreshToken] = &grant{authorization: authz, accessToken: accessToken, refreshToken: refreshToken, expiresAt: refreshExpiresAt}
+
+	var idToken string
+	if withIDToken && contains(authz.scopes, "openid") {
+		idToken = s.signIDToken(client, authz)
+	}
+
+	content := map[string]interface{}{
+		"access_token":  accessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int64(s.config.AccessTokenTTL.Seconds()),
+		"refresh_token": refreshToken,
+		"scope":         strings.Join(authz.scopes, " "),
+	}
+	if idToken != "" {
+		content["id_token"] = idToken
+	}
+	b, _ := json.Marshal(content)
+
+	return tokenResponse{
+		result:                newResult("OK", "A005001", "The token request was processed.", string(b)),
+		GrantType:             grantType,
+		ClientID:              client.ID,
+		Subject:               authz.subject,
+		Scopes:                authz.scopes,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt.UnixMilli(),
+		AccessTokenDuration:   int64(s.config.AccessTokenTTL.Seconds()),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt.UnixMilli(),
+		IDToken:               idToken,
+	}
+}
+
+// verifyPKCE はRFC 7636に従ってcode_verifierを検証します
+func verifyPKCE(challenge, method, verifier string) bool {
+	if challenge == "" {
+		return true
+	}
+	if verifier == "" {
+		return false
+	}
+
+	expected := verifier
+	if method == "S256" {
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// signIDToken はクライアントシークレットでHS256署名したIDトークンを生成します
+func (s *Server) signIDToken(client Client, authz *authorization) string {
+	now := s.now()
+	claims := map[string]interface{}{}
+	for k, v := range authz.claims {
+		claims[k] = v
+	}
+	claims["iss"] = s.config.Issuer
+	claims["sub"] = authz.subject
+	claims["aud"] = client.ID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(s.config.AccessTokenTTL).Unix()
+	if authz.authTime != 0 {
+		claims["auth_time"] = authz.authTime
+	}
+	if authz.nonce != "" {
+		claims["nonce"] = authz.nonce
+	}
+	if authz.acr != "" {
+		claims["acr"] = authz.acr
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, []byte(client.Secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
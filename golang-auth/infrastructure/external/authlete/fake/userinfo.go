@@ -0,0 +1,214 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// bearerError はWWW-Authenticateヘッダーの値を生成します
+func bearerError(code, description string) string {
+	return fmt.Sprintf(`Bearer error="%s",error_description="%s"`, code, description)
+}
+
+// lookupAccessToken は有効なアクセストークンを取得します
+func (s *Server) lookupAccessToken(token string) (*grant, bool) {
+	g, ok := s.accessTokens[token]
+	if !ok {
+		return nil, false
+	}
+	if s.now().After(g.expiresAt) {
+		delete(s.accessTokens, token)
+		return nil, false
+	}
+	return g, true
+}
+
+// handleUserInfo は /auth/userinfo を模倣し、アクセストークンを検証します
+func (s *Server) handleUserInfo(body []byte) interface{} {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Token == "" {
+		return newResult("BAD_REQUEST", "A091201", "The access token is missing.", bearerError("invalid_request", "access token is missing"))
+	}
+
+	g, ok := s.lookupAccessToken(req.Token)
+	if !ok {
+		return newResult("UNAUTHORIZED", "A091301", "The access token is invalid.", bearerError("invalid_token", "access token is invalid or expired"))
+	}
+	if !contains(g.scopes, "openid") {
+		return newResult("FORBIDDEN", "A091302", "The access token does not cover openid.", bearerError("insufficient_scope", "openid scope is required"))
+	}
+
+	return struct {
+		result
+		ClientID string   `json:"clientId"`
+		Subject  string   `json:"subject"`
+		Scopes   []string `json:"scopes"`
+	}{
+		result:   newResult("OK", "A091001", "The access token is valid.", ""),
+		ClientID: g.clientID,
+		Subject:  g.subject,
+		Scopes:   g.scopes,
+	}
+}
+
+// handleUserInfoIssue は /auth/userinfo/issue を模倣し、UserInfoレスポンスのJSONを生成します
+// 認可時に渡されたクレームと、リクエストで追加されたクレームを合わせて返します
+func (s *Server) handleUserInfoIssue(body []byte) interface{} {
+	var req struct {
+		Token  string `json:"token"`
+		Claims string `json:"claims"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Token == "" {
+		return newResult("BAD_REQUEST", "A092201", "The access token is missing.", bearerError("invalid_request", "access token is missing"))
+	}
+
+	g, ok := s.lookupAccessToken(req.Token)
+	if !ok {
+		return newResult("UNAUTHORIZED", "A092301", "The access token is invalid.", bearerError("invalid_token", "access token is invalid or expired"))
+	}
+
+	claims := map[string]interface{}{}
+	for k, v := range g.claims {
+		claims[k] = v
+	}
+	if req.Claims != "" {
+		var extra map[string]interface{}
+		if err := json.Unmarshal([]byte(req.Claims), &extra); err != nil {
+			return newResult("INTERNAL_SERVER_ERROR", "A092401", "The claims are malformed.", bearerError("server_error", "malformed claims"))
+		}
+		for k, v := range extra {
+			claims[k] = v
+		}
+	}
+	claims["sub"] = g.subject
+
+	b, _ := json.Marshal(claims)
+	return newResult("JSON", "A092001", "The userinfo response was generated.", string(b))
+}
+
+// handleIntrospection は /auth/introspection を模倣し、リソースサーバー向けにアクセストークンの情報を返します
+func (s *Server) handleIntrospection(body []byte) interface{} {
+	var req struct {
+		Token   string   `json:"token"`
+		Scopes  []string `json:"scopes"`
+		Subject string   `json:"subject"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Token == "" {
+		return newResult("BAD_REQUEST", "A056201", "The access token is missing.", bearerError("invalid_request", "access token is missing"))
+	}
+
+	g, ok := s.lookupAccessToken(req.Token)
+	if !ok {
+		return newResult("UNAUTHORIZED", "A056301", "The access token is invalid.", bearerError("invalid_token", "access token is invalid or expired"))
+	}
+
+	for _, scope := range req.Scopes {
+		if !contains(g.scopes, scope) {
+			return newResult("FORBIDDEN", "A056302", "The access token does not cover the required scopes.", bearerError("insufficient_scope", "required scopes are not granted"))
+		}
+	}
+	if req.Subject != "" && req.Subject != g.subject {
+		return newResult("FORBIDDEN", "A056303", "The access token was issued to another subject.", bearerError("invalid_token", "subject mismatch"))
+	}
+
+	return struct {
+		result
+		ClientID   string   `json:"clientId"`
+		Subject    string   `json:"subject"`
+		Scopes     []string `json:"scopes"`
+		ExpiresAt  int64    `json:"expiresAt"`
+		Existent   bool     `json:"existent"`
+		Usable     bool     `json:"usable"`
+		Sufficient bool     `json:"sufficient"`
+	}{
+		result:     newResult("OK", "A056001", "The access token is valid.", ""),
+		ClientID:   g.clientID,
+		Subject:    g.subject,
+		Scopes:     g.scopes,
+		ExpiresAt:  g.expiresAt.UnixMilli(),
+		Existent:   true,
+		Usable:     true,
+		Sufficient: true,
+	}
+}
+
+// handleStandardIntrospection は /auth/introspection/standard を模倣し、RFC 7662形式のレスポンスを生成します
+func (s *Server) handleStandardIntrospection(body []byte) interface{} {
+	var req struct {
+		Parameters string `json:"parameters"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return newResult("BAD_REQUEST", "A146201", "The request body is malformed.", oauthError("invalid_request", "malformed request"))
+	}
+
+	params, err := url.ParseQuery(req.Parameters)
+	if err != nil || params.Get("token") == "" {
+		return newResult("BAD_REQUEST", "A146202", "The token is missing.", oauthError("invalid_request", "token is missing"))
+	}
+
+	token := params.Get("token")
+	g, tokenType := s.lookupAccessTokenOrRefreshToken(token)
+	if g == nil {
+		return newResult("OK", "A146001", "The token is not active.", `{"active":false}`)
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{
+		"active":     true,
+		"client_id":  g.clientID,
+		"sub":        g.subject,
+		"scope":      strings.Join(g.scopes, " "),
+		"exp":        g.expiresAt.Unix(),
+		"iss":        s.config.Issuer,
+		"token_type": tokenType,
+	})
+	return newResult("OK", "A146001", "The token is active.", string(b))
+}
+
+// lookupAccessTokenOrRefreshToken は有効なアクセストークンまたはリフレッシュトークンを取得します
+func (s *Server) lookupAccessTokenOrRefreshToken(token string) (*grant, string) {
+	if g, ok := s.lookupAccessToken(token); ok {
+		return g, "Bearer"
+	}
+	if g, ok := s.refreshTokens[token]; ok && !s.now().After(g.expiresAt) {
+		return g, "refresh_token"
+	}
+	return nil, ""
+}
+
+// handleRevocation は /auth/revocation を模倣し、RFC 7009に従ってトークンを失効させます
+func (s *Server) handleRevocation(body []byte) interface{} {
+	var req struct {
+		Parameters   string `json:"parameters"`
+		ClientID     string `json:"clientId"`
+		ClientSecret string `json:"clientSecret"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return newResult("BAD_REQUEST", "A058201", "The request body is malformed.", oauthError("invalid_request", "malformed request"))
+	}
+
+	params, err := url.ParseQuery(req.Parameters)
+	if err != nil || params.Get("token") == "" {
+		return newResult("BAD_REQUEST", "A058202", "The token is missing.", oauthError("invalid_request", "token is missing"))
+	}
+
+	client, ok := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if !ok {
+		return newResult("INVALID_CLIENT", "A058301", "Client authentication failed.", oauthError("invalid_client", "client authentication failed"))
+	}
+
+	// RFC 7009 2.2: 無効なトークンであっても成功として扱う
+	token := params.Get("token")
+	if g, ok := s.refreshTokens[token]; ok && g.clientID == client.ID {
+		delete(s.refreshTokens, token)
+		delete(s.accessTokens, g.accessToken)
+	}
+	if g, ok := s.accessTokens[token]; ok && g.clientID == client.ID {
+		delete(s.accessTokens, token)
+	}
+
+	return newResult("OK", "A058001", "The token was revoked.", "")
+}
@@ -0,0 +1,45 @@
+package authlete
+
+import "sync"
+
+// APIStats はAuthlete API 1つあたりの呼び出し統計です
+type APIStats struct {
+	Attempts int64 `json:"attempts"`
+	Retries  int64 `json:"retries"`
+	Failures int64 `json:"failures"`
+	Rejected int64 `json:"rejected"`
+}
+
+// Metrics はAuthlete API呼び出しの試行回数を記録します
+type Metrics struct {
+	mu    sync.Mutex
+	stats map[string]*APIStats
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{stats: make(map[string]*APIStats)}
+}
+
+func (m *Metrics) record(api string, update func(s *APIStats)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[api]
+	if !ok {
+		s = &APIStats{}
+		m.stats[api] = s
+	}
+	update(s)
+}
+
+// Snapshot は現在までの統計のコピーをAPIのパスごとに返します
+func (m *Metrics) Snapshot() map[string]APIStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]APIStats, len(m.stats))
+	for api, s := range m.stats {
+		snapshot[api] = *s
+	}
+	return snapshot
+}
@@ -0,0 +1,70 @@
+package authlete
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// idempotentAPIs は再試行しても副作用が重複しないAuthlete APIです
+// 呼び出すたびにチケットを発行する /auth/authorization や、チケットや認可コードを消費する
+// /auth/authorization/issue、/auth/token は含めません
+var idempotentAPIs = map[string]bool{
+	"/auth/userinfo":               true,
+	"/auth/userinfo/issue":         true,
+	"/auth/introspection":          true,
+	"/auth/introspection/standard": true,
+	"/auth/revocation":             true,
+}
+
+// retryPolicy はAuthlete API呼び出しの再試行設定です
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// backoff は attempt 回目の再試行までの待ち時間を、上限付き指数バックオフにフルジッターを掛けて返します
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.maxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := p.baseDelay << uint(shift); d > 0 && d < p.maxDelay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// isRetryable は一時的な障害と考えられるエラーかどうかを判定します
+func isRetryable(err error) bool {
+	var authleteErr *AuthleteError
+	if !errors.As(err, &authleteErr) {
+		return false
+	}
+
+	switch authleteErr.Code {
+	case "REQUEST_ERROR", "TIMEOUT":
+		return true
+	case "HTTP_ERROR":
+		return authleteErr.StatusCode >= 500
+	default:
+		return false
+	}
+}
+
+// sleepContext は ctx がキャンセルされるまでの間、d だけ待機します
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
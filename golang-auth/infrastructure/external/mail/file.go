@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+)
+
+// fileMailer はメールを送信せず、ディレクトリに .eml ファイルとして書き出します（ローカル開発用）
+type fileMailer struct {
+	from string
+	dir  string
+	now  func() time.Time
+}
+
+// NewFileMailer は dir にメールを書き出すメーラーを作成します
+func NewFileMailer(from, dir string) *fileMailer {
+	return &fileMailer{from: from, dir: dir, now: time.Now}
+}
+
+func (m *fileMailer) Send(ctx context.Context, mail entity.Mail) error {
+	now := m.now()
+	msg, err := buildMessage(m.from, mail, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	// ファイル名を時刻順に並べられるようにする
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000Z"), hex.EncodeToString(suffix))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, msg, 0o600); err != nil {
+		return err
+	}
+	logger.LogInfo("mail to %s written to %s", mail.To, path)
+	return nil
+}
+
+// logMailer はメールを送信せず、ログに出力します（ローカル開発用）
+// 本文にはメール確認・パスワード再設定のリンクが含まれるため、本番環境では使わないでください
+type logMailer struct {
+	from string
+}
+
+// NewLogMailer はメールをログに出力するメーラーを作成します
+func NewLogMailer(from string) *logMailer {
+	return &logMailer{from: from}
+}
+
+func (m *logMailer) Send(ctx context.Context, mail entity.Mail) error {
+	logger.LogInfo("mail from %s to %s: %s\n%s", m.from, mail.To, mail.Subject, mail.HTMLBody)
+	return nil
+}
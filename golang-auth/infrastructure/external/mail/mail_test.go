@@ -0,0 +1,159 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+)
+
+var testMail = entity.Mail{
+	To:       "alice@example.com",
+	Subject:  "メールアドレスの確認",
+	HTMLBody: "<p>" + strings.Repeat("確認してください。", 20) + "</p>",
+}
+
+// parseMessage はメールを読み込み、件名とデコードした本文を返します
+func parseMessage(t *testing.T, raw []byte) (*netmail.Message, string, string) {
+	t.Helper()
+	msg, err := netmail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, msg.Body))
+	require.NoError(t, err)
+	return msg, subject, string(body)
+}
+
+func TestBuildMessage(t *testing.T) {
+	// テスト実行
+	raw, err := buildMessage("no-reply@poc-authlete.local", testMail, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	// アサーション
+	require.NoError(t, err)
+	msg, subject, body := parseMessage(t, raw)
+	assert.Equal(t, "alice@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "text/html; charset=UTF-8", msg.Header.Get("Content-Type"))
+	assert.True(t, strings.HasSuffix(msg.Header.Get("Message-ID"), "@poc-authlete.local>"))
+	assert.Equal(t, testMail.Subject, subject)
+	assert.Equal(t, testMail.HTMLBody, body)
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		assert.LessOrEqual(t, len(line), 78)
+	}
+
+	// ヘッダーの挿入を防ぐ
+	_, err = buildMessage("no-reply@poc-authlete.local", entity.Mail{To: "alice@example.com\r\nBcc: mallory@example.com"}, time.Now())
+	assert.Error(t, err)
+}
+
+// fakeSMTPServer はSTARTTLSと認証に対応しない最小限のSMTPサーバーで、受け取ったメールを1通返します
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+		reply("220 localhost ESMTP")
+		var envelope []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				envelope = append(envelope, strings.TrimSpace(line))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				received <- strings.Join(envelope, "\n") + "\n\n" + data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	// テストケースの準備
+	addr, received := fakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	mailer := NewMailer(config.MailConfig{Driver: config.MailDriverSMTP, From: "no-reply@poc-authlete.local", SMTPHost: host, SMTPPort: portNumber})
+
+	// テスト実行
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = mailer.Send(ctx, testMail)
+
+	// アサーション
+	require.NoError(t, err)
+	envelope, raw, _ := strings.Cut(<-received, "\n\n")
+	assert.Equal(t, "MAIL FROM:<no-reply@poc-authlete.local>\nRCPT TO:<alice@example.com>", envelope)
+	_, subject, body := parseMessage(t, []byte(raw))
+	assert.Equal(t, testMail.Subject, subject)
+	assert.Equal(t, testMail.HTMLBody, body)
+}
+
+func TestFileMailer(t *testing.T) {
+	// テストケースの準備
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewMailer(config.MailConfig{Driver: config.MailDriverFile, From: "no-reply@poc-authlete.local", FileDir: dir})
+
+	// テスト実行
+	require.NoError(t, mailer.Send(context.Background(), testMail))
+	require.NoError(t, mailer.Send(context.Background(), testMail))
+
+	// アサーション
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	raw, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	_, subject, body := parseMessage(t, raw)
+	assert.Equal(t, testMail.Subject, subject)
+	assert.Equal(t, testMail.HTMLBody, body)
+}
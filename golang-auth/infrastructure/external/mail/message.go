@@ -0,0 +1,63 @@
+// Package mail はメールの送信（SMTP）と、ローカル開発用にメールをファイルやログへ書き出す実装です
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+)
+
+// NewMailer は設定した送信方法のメーラーを作成します
+func NewMailer(cfg config.MailConfig) repository.Mailer {
+	switch cfg.Driver {
+	case config.MailDriverSMTP:
+		return NewSMTPMailer(cfg)
+	case config.MailDriverFile:
+		return NewFileMailer(cfg.From, cfg.FileDir)
+	default:
+		return NewLogMailer(cfg.From)
+	}
+}
+
+// buildMessage はHTMLのメールをRFC 5322の形式にします（本文はbase64で76文字ごとに折り返します）
+// ヘッダーに改行を含む値はヘッダーの挿入を防ぐため拒否します
+func buildMessage(from string, mail entity.Mail, now time.Time) ([]byte, error) {
+	for _, v := range []string{from, mail.To, mail.Subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("mail header must not contain line breaks")
+		}
+	}
+	domain := from[strings.LastIndex(from, "@")+1:]
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", mail.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(mail.HTMLBody))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes(), nil
+}
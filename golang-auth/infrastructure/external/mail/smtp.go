@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+)
+
+// smtpMailer はSMTPでメールを送信します
+// サーバーがSTARTTLSに対応している場合は暗号化してから認証・送信します
+type smtpMailer struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+	now  func() time.Time
+	// tlsConfig はテストで自己署名の証明書を受け入れるために差し替えます
+	tlsConfig *tls.Config
+}
+
+// NewSMTPMailer はSMTPのメーラーを作成します（SMTPUsername が空の場合は認証しません）
+func NewSMTPMailer(cfg config.MailConfig) *smtpMailer {
+	m := &smtpMailer{
+		addr:      net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:      cfg.SMTPHost,
+		from:      cfg.From,
+		now:       time.Now,
+		tlsConfig: &tls.Config{ServerName: cfg.SMTPHost},
+	}
+	if cfg.SMTPUsername != "" {
+		// PlainAuth はTLSで暗号化されていない接続では（localhost を除き）認証情報を送りません
+		m.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return m
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail entity.Mail) error {
+	msg, err := buildMessage(m.from, mail, m.now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	// net/smtp は context に対応していないため、期限は接続に設定する
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(m.tlsConfig); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return fmt.Errorf("failed to authenticate to smtp server: %w", err)
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(mail.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// AccountTokenRepository はメモリ上で使用済みのアカウントのトークンを記録します
+// 期限切れの記録は MarkUsed のたびに削除します
+type AccountTokenRepository struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+	now  func() time.Time
+}
+
+// NewAccountTokenRepository は使用済みトークンのリポジトリを作成します
+func NewAccountTokenRepository() *AccountTokenRepository {
+	return &AccountTokenRepository{
+		used: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+func (r *AccountTokenRepository) MarkUsed(id string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for usedID, usedExpiresAt := range r.used {
+		if now.After(usedExpiresAt) {
+			delete(r.used, usedID)
+		}
+	}
+	if _, ok := r.used[id]; ok {
+		return entity.ErrAccountTokenUsed
+	}
+	r.used[id] = expiresAt
+	return nil
+}
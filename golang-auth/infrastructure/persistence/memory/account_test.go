@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+func TestAccountTokenRepository(t *testing.T) {
+	repo := NewAccountTokenRepository()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	require.NoError(t, repo.MarkUsed("token-1", now.Add(time.Hour)))
+	require.NoError(t, repo.MarkUsed("token-2", now.Add(time.Minute)))
+
+	// 使用済みのトークンは再び使えない
+	assert.ErrorIs(t, repo.MarkUsed("token-1", now.Add(time.Hour)), entity.ErrAccountTokenUsed)
+
+	// 期限切れの記録は削除する
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, repo.MarkUsed("token-3", now.Add(time.Hour)))
+	assert.Len(t, repo.used, 2)
+	assert.NotContains(t, repo.used, "token-2")
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// CeremonyRepository はメモリ上でWebAuthnのセレモニーを管理します
+// 期限切れのセレモニーは取り出せなくなり、janitor が定期的に削除します
+type CeremonyRepository struct {
+	mu         sync.Mutex
+	ceremonies map[string]*entity.WebAuthnCeremony
+	now        func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewCeremonyRepository はセレモニーリポジトリを作成し、interval ごとに期限切れのセレモニーを削除する janitor を起動します
+// 不要になったら Close で janitor を停止してください
+func NewCeremonyRepository(interval time.Duration) *CeremonyRepository {
+	r := &CeremonyRepository{
+		ceremonies: make(map[string]*entity.WebAuthnCeremony),
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+	if interval > 0 {
+		go r.janitor(interval)
+	}
+	return r
+}
+
+// Close は janitor を停止します
+func (r *CeremonyRepository) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *CeremonyRepository) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.evictExpired()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// evictExpired は期限切れのセレモニーを削除します
+func (r *CeremonyRepository) evictExpired() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for id, ceremony := range r.ceremonies {
+		if now.After(ceremony.ExpiresAt) {
+			delete(r.ceremonies, id)
+		}
+	}
+}
+
+func (r *CeremonyRepository) Save(ceremony *entity.WebAuthnCeremony) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *ceremony
+	r.ceremonies[ceremony.ID] = &stored
+	return nil
+}
+
+func (r *CeremonyRepository) Consume(id string) (*entity.WebAuthnCeremony, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ceremony, ok := r.ceremonies[id]
+	if !ok {
+		return nil, entity.ErrCeremonyNotFound
+	}
+	delete(r.ceremonies, id)
+	if r.now().After(ceremony.ExpiresAt) {
+		return nil, entity.ErrCeremonyNotFound
+	}
+	return ceremony, nil
+}
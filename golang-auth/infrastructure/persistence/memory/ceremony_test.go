@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+func TestCeremonyRepositoryConsume(t *testing.T) {
+	repo := NewCeremonyRepository(0)
+	defer repo.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	require.NoError(t, repo.Save(&entity.WebAuthnCeremony{ID: "ceremony-1", Challenge: []byte("challenge"), ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, repo.Save(&entity.WebAuthnCeremony{ID: "ceremony-2", ExpiresAt: now.Add(time.Minute)}))
+
+	// 取り出せるのは1回限り
+	ceremony, err := repo.Consume("ceremony-1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("challenge"), ceremony.Challenge)
+	_, err = repo.Consume("ceremony-1")
+	assert.ErrorIs(t, err, entity.ErrCeremonyNotFound)
+
+	// 期限切れのセレモニーは取り出せず、janitor により削除される
+	now = now.Add(2 * time.Minute)
+	repo.Save(&entity.WebAuthnCeremony{ID: "ceremony-3", ExpiresAt: now.Add(time.Minute)})
+	repo.evictExpired()
+	assert.Len(t, repo.ceremonies, 1)
+	_, err = repo.Consume("ceremony-2")
+	assert.ErrorIs(t, err, entity.ErrCeremonyNotFound)
+}
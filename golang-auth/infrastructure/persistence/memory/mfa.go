@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+)
+
+// TOTPRepository はメモリ上でTOTPとリカバリーコードを管理します
+type TOTPRepository struct {
+	mu            sync.Mutex
+	credentials   map[string]*entity.TOTPCredential
+	recoveryCodes map[string]map[string]bool
+}
+
+func NewTOTPRepository() repository.TOTPRepository {
+	return &TOTPRepository{
+		credentials:   make(map[string]*entity.TOTPCredential),
+		recoveryCodes: make(map[string]map[string]bool),
+	}
+}
+
+func (r *TOTPRepository) Get(userID string) (*entity.TOTPCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[userID]
+	if !ok {
+		return nil, entity.ErrTOTPNotFound
+	}
+	found := *credential
+	return &found, nil
+}
+
+func (r *TOTPRepository) Save(credential *entity.TOTPCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if credential.CreatedAt.IsZero() {
+		credential.CreatedAt = time.Now()
+	}
+	stored := *credential
+	r.credentials[credential.UserID] = &stored
+	return nil
+}
+
+func (r *TOTPRepository) Delete(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.credentials, userID)
+	delete(r.recoveryCodes, userID)
+	return nil
+}
+
+func (r *TOTPRepository) UpdateLastUsedStep(userID string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[userID]
+	if !ok {
+		return entity.ErrTOTPNotFound
+	}
+	if step <= credential.LastUsedStep {
+		return entity.ErrTOTPStepUsed
+	}
+	credential.LastUsedStep = step
+	return nil
+}
+
+func (r *TOTPRepository) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		codes[hash] = true
+	}
+	r.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (r *TOTPRepository) UseRecoveryCode(userID, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recoveryCodes[userID][hash] {
+		return entity.ErrRecoveryCodeNotFound
+	}
+	delete(r.recoveryCodes[userID], hash)
+	return nil
+}
+
+func (r *TOTPRepository) CountRecoveryCodes(userID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.recoveryCodes[userID]), nil
+}
+
+// MFAChallengeRepository はメモリ上で2要素目の入力を待つログインを管理します
+// 期限切れのログインは取り出せなくなり、janitor が定期的に削除します
+type MFAChallengeRepository struct {
+	mu         sync.Mutex
+	challenges map[string]*entity.MFAChallenge
+	now        func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMFAChallengeRepository はリポジトリを作成し、interval ごとに期限切れのログインを削除する janitor を起動します
+// 不要になったら Close で janitor を停止してください
+func NewMFAChallengeRepository(interval time.Duration) *MFAChallengeRepository {
+	r := &MFAChallengeRepository{
+		challenges: make(map[string]*entity.MFAChallenge),
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+	if interval > 0 {
+		go r.janitor(interval)
+	}
+	return r
+}
+
+// Close は janitor を停止します
+func (r *MFAChallengeRepository) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *MFAChallengeRepository) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.evictExpired()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// evictExpired は期限切れのログインを削除します
+func (r *MFAChallengeRepository) evictExpired() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for id, challenge := range r.challenges {
+		if now.After(challenge.ExpiresAt) {
+			delete(r.challenges, id)
+		}
+	}
+}
+
+func (r *MFAChallengeRepository) Save(challenge *entity.MFAChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *challenge
+	r.challenges[challenge.ID] = &stored
+	return nil
+}
+
+func (r *MFAChallengeRepository) Consume(id string) (*entity.MFAChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, entity.ErrMFAChallengeNotFound
+	}
+	delete(r.challenges, id)
+	if r.now().After(challenge.ExpiresAt) {
+		return nil, entity.ErrMFAChallengeNotFound
+	}
+	return challenge, nil
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+func TestTOTPRepositoryUpdateLastUsedStep(t *testing.T) {
+	repo := NewTOTPRepository()
+	require.NoError(t, repo.Save(&entity.TOTPCredential{UserID: "user-1", Secret: []byte("secret"), LastUsedStep: 10}))
+
+	// 受け付けた時間ステップ以前のコードは使えない
+	assert.ErrorIs(t, repo.UpdateLastUsedStep("user-1", 10), entity.ErrTOTPStepUsed)
+	require.NoError(t, repo.UpdateLastUsedStep("user-1", 11))
+	assert.ErrorIs(t, repo.UpdateLastUsedStep("user-2", 11), entity.ErrTOTPNotFound)
+}
+
+func TestMFAChallengeRepository(t *testing.T) {
+	repo := NewMFAChallengeRepository(0)
+	defer repo.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	require.NoError(t, repo.Save(&entity.MFAChallenge{ID: "challenge-1", UserID: "user-1", ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, repo.Save(&entity.MFAChallenge{ID: "challenge-2", UserID: "user-1", ExpiresAt: now.Add(2 * time.Minute)}))
+
+	challenge, err := repo.Consume("challenge-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", challenge.UserID)
+	_, err = repo.Consume("challenge-1")
+	assert.ErrorIs(t, err, entity.ErrMFAChallengeNotFound)
+
+	// 期限切れのログインは取り出せず、janitor が削除する
+	now = now.Add(3 * time.Minute)
+	_, err = repo.Consume("challenge-2")
+	assert.ErrorIs(t, err, entity.ErrMFAChallengeNotFound)
+	repo.evictExpired()
+	assert.Empty(t, repo.challenges)
+}
@@ -1,12 +1,18 @@
 package memory
 
 import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/interface/repository"
 )
 
 type PasskeyRepository struct {
 	credentials map[string]*entity.Credential
+	mu          sync.RWMutex
 }
 
 func NewPasskeyRepository() repository.PasskeyRepository {
@@ -16,11 +22,18 @@ func NewPasskeyRepository() repository.PasskeyRepository {
 }
 
 func (r *PasskeyRepository) SaveCredential(credential *entity.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.credentials[credential.ID]; exists {
+		return entity.ErrCredentialAlreadyRegistered
+	}
 	r.credentials[credential.ID] = credential
 	return nil
 }
 
 func (r *PasskeyRepository) GetCredential(id string) (*entity.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	if credential, exists := r.credentials[id]; exists {
 		return credential, nil
 	}
@@ -28,6 +41,8 @@ func (r *PasskeyRepository) GetCredential(id string) (*entity.Credential, error)
 }
 
 func (r *PasskeyRepository) GetCredentialsByUsername(username string) ([]*entity.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	var credentials []*entity.Credential
 	for _, credential := range r.credentials {
 		if credential.Username == username {
@@ -36,3 +51,77 @@ func (r *PasskeyRepository) GetCredentialsByUsername(username string) ([]*entity
 	}
 	return credentials, nil
 }
+
+func (r *PasskeyRepository) GetCredentialsByUserHandle(userHandle []byte) ([]*entity.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var credentials []*entity.Credential
+	for _, credential := range r.credentials {
+		if bytes.Equal(credential.UserHandle, userHandle) {
+			credentials = append(credentials, credential)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool {
+		if !credentials[i].CreatedAt.Equal(credentials[j].CreatedAt) {
+			return credentials[i].CreatedAt.Before(credentials[j].CreatedAt)
+		}
+		return credentials[i].ID < credentials[j].ID
+	})
+	return credentials, nil
+}
+
+func (r *PasskeyRepository) UpdateNickname(id, nickname string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, exists := r.credentials[id]
+	if !exists {
+		return entity.ErrCredentialNotFound
+	}
+	credential.Nickname = nickname
+	return nil
+}
+
+func (r *PasskeyRepository) UpdateLastUsed(id string, usedAt time.Time, backupState bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, exists := r.credentials[id]
+	if !exists {
+		return entity.ErrCredentialNotFound
+	}
+	credential.LastUsedAt = usedAt
+	credential.BackupState = backupState
+	return nil
+}
+
+func (r *PasskeyRepository) DeleteCredential(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.credentials[id]; !exists {
+		return entity.ErrCredentialNotFound
+	}
+	delete(r.credentials, id)
+	return nil
+}
+
+func (r *PasskeyRepository) UpdateSignCount(id string, signCount uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, exists := r.credentials[id]
+	if !exists {
+		return entity.ErrCredentialNotFound
+	}
+	if !signCountIncreased(credential.SignCount, signCount) {
+		return entity.ErrSignCountNotIncreased
+	}
+	credential.SignCount = signCount
+	return nil
+}
+
+// signCountIncreased は署名カウンターが増えているかを判定します（両方0はカウンター非対応の認証器）
+func signCountIncreased(stored, received uint32) bool {
+	return received > stored || (stored == 0 && received == 0)
+}
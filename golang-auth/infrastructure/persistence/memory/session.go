@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// SessionRepository はメモリ上でセッションを管理します
+// 最終アクセスから ttl を過ぎたセッションは取得できなくなり、janitor が定期的に削除します
+type SessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]*entity.Session
+	ttl      time.Duration
+	now      func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSessionRepository はセッションリポジトリを作成し、interval ごとに期限切れのセッションを削除する janitor を起動します
+// 不要になったら Close で janitor を停止してください
+func NewSessionRepository(ttl, interval time.Duration) *SessionRepository {
+	r := &SessionRepository{
+		sessions: make(map[string]*entity.Session),
+		ttl:      ttl,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+	if interval > 0 {
+		go r.janitor(interval)
+	}
+	return r
+}
+
+// Close は janitor を停止します
+func (r *SessionRepository) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *SessionRepository) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.evictExpired()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// evictExpired は期限切れのセッションを削除します
+func (r *SessionRepository) evictExpired() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for id, session := range r.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(r.sessions, id)
+		}
+	}
+}
+
+func (r *SessionRepository) Create(session *entity.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = now
+	}
+	session.ExpiresAt = session.LastSeenAt.Add(r.ttl)
+
+	stored := *session
+	r.sessions[session.ID] = &stored
+	return nil
+}
+
+// lookup は有効なセッションを取得します（呼び出し元でロックを取得してください）
+func (r *SessionRepository) lookup(id string) (*entity.Session, bool) {
+	session, ok := r.sessions[id]
+	if !ok || r.now().After(session.ExpiresAt) {
+		return nil, false
+	}
+	return session, true
+}
+
+func (r *SessionRepository) Get(id string) (*entity.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.lookup(id)
+	if !ok {
+		return nil, entity.ErrSessionNotFound
+	}
+	// 呼び出し元での変更が保存済みのセッションに影響しないようコピーを返す
+	copied := *session
+	return &copied, nil
+}
+
+func (r *SessionRepository) Touch(id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.lookup(id)
+	if !ok {
+		return entity.ErrSessionNotFound
+	}
+	session.LastSeenAt = at
+	session.ExpiresAt = at.Add(r.ttl)
+	return nil
+}
+
+func (r *SessionRepository) UpdateTokens(id string, tokens entity.Tokens) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.lookup(id)
+	if !ok {
+		return entity.ErrSessionNotFound
+	}
+	session.Tokens = tokens
+	return nil
+}
+
+func (r *SessionRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *SessionRepository) ListByUser(userID string) ([]*entity.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*entity.Session
+	for id, session := range r.sessions {
+		if session.UserID != userID {
+			continue
+		}
+		if _, ok := r.lookup(id); !ok {
+			continue
+		}
+		copied := *session
+		sessions = append(sessions, &copied)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+func TestSessionRepositoryTTL(t *testing.T) {
+	repo := NewSessionRepository(time.Minute, 0)
+	defer repo.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	err := repo.Create(&entity.Session{ID: "session-1", UserID: "user-1"})
+	assert.NoError(t, err)
+
+	// 最終アクセスから30秒後にアクセスすると有効期限が延長される
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, repo.Touch("session-1", now))
+	now = now.Add(45 * time.Second)
+	session, err := repo.Get("session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", session.UserID)
+
+	// 最終アクセスからTTLを過ぎると取得できない
+	now = now.Add(time.Minute)
+	_, err = repo.Get("session-1")
+	assert.ErrorIs(t, err, entity.ErrSessionNotFound)
+	sessions, err := repo.ListByUser("user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	// janitor により削除される
+	repo.evictExpired()
+	assert.Empty(t, repo.sessions)
+}
+
+func TestSessionRepositoryListByUser(t *testing.T) {
+	repo := NewSessionRepository(time.Hour, time.Millisecond)
+	defer repo.Close()
+
+	repo.Create(&entity.Session{ID: "session-1", UserID: "user-1"})
+	repo.Create(&entity.Session{ID: "session-2", UserID: "user-1"})
+	repo.Create(&entity.Session{ID: "session-3", UserID: "user-2"})
+
+	sessions, err := repo.ListByUser("user-1")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	assert.NoError(t, repo.UpdateTokens("session-1", entity.Tokens{AccessToken: "new-access-token"}))
+	session, err := repo.Get("session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-access-token", session.Tokens.AccessToken)
+
+	assert.NoError(t, repo.Delete("session-1"))
+	_, err = repo.Get("session-1")
+	assert.ErrorIs(t, err, entity.ErrSessionNotFound)
+}
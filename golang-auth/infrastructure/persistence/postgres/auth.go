@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+)
+
+type authRepository struct {
+	db *sql.DB
+}
+
+func NewAuthRepository(db *sql.DB) repository.AuthRepository {
+	return &authRepository{db: db}
+}
+
+func (r *authRepository) StoreAuthData(state string, data entity.AuthData) error {
+	_, err := r.db.Exec(`INSERT INTO auth_transactions (state, code_verifier, ticket)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (state) DO UPDATE SET
+			code_verifier = EXCLUDED.code_verifier,
+			ticket = EXCLUDED.ticket`,
+		state, data.CodeVerifier, data.Ticket)
+	return err
+}
+
+func (r *authRepository) GetAuthData(state string) (entity.AuthData, bool) {
+	var data entity.AuthData
+	err := r.db.QueryRow(`SELECT code_verifier, ticket FROM auth_transactions WHERE state = $1`, state).
+		Scan(&data.CodeVerifier, &data.Ticket)
+	if err != nil {
+		return entity.AuthData{}, false
+	}
+	return data, true
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+)
+
+// CeremonyRepository はPostgreSQLでWebAuthnのセレモニーを管理します
+// 期限切れのセレモニーは取り出せなくなり、janitor が定期的に削除します
+type CeremonyRepository struct {
+	db  *sql.DB
+	now func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewCeremonyRepository はセレモニーリポジトリを作成し、interval ごとに期限切れのセレモニーを削除する janitor を起動します
+// 不要になったら Close で janitor を停止してください
+func NewCeremonyRepository(db *sql.DB, interval time.Duration) *CeremonyRepository {
+	r := &CeremonyRepository{
+		db:   db,
+		now:  time.Now,
+		stop: make(chan struct{}),
+	}
+	if interval > 0 {
+		go r.janitor(interval)
+	}
+	return r
+}
+
+// Close は janitor を停止します
+func (r *CeremonyRepository) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *CeremonyRepository) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.evictExpired(); err != nil {
+				logger.LogWarning("failed to evict expired webauthn ceremonies: %v", err)
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// evictExpired は期限切れのセレモニーを削除します
+func (r *CeremonyRepository) evictExpired() error {
+	_, err := r.db.Exec(`DELETE FROM webauthn_ceremonies WHERE expires_at < $1`, r.now())
+	return err
+}
+
+func (r *CeremonyRepository) Save(ceremony *entity.WebAuthnCeremony) error {
+	_, err := r.db.Exec(`INSERT INTO webauthn_ceremonies (id, type, challenge, user_id, username, rp_id, allow_credentials, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		ceremony.ID, ceremony.Type, ceremony.Challenge, ceremony.UserID, ceremony.Username, ceremony.RPID,
+		pq.Array(ceremony.AllowCredentials), ceremony.ExpiresAt)
+	return err
+}
+
+// Consume はセレモニーを削除して返します（削除と取得を1文で行うため、同じIDを同時に2回取り出せません）
+func (r *CeremonyRepository) Consume(id string) (*entity.WebAuthnCeremony, error) {
+	var ceremony entity.WebAuthnCeremony
+	err := r.db.QueryRow(`DELETE FROM webauthn_ceremonies WHERE id = $1
+		RETURNING id, type, challenge, user_id, username, rp_id, allow_credentials, expires_at`, id).
+		Scan(&ceremony.ID, &ceremony.Type, &ceremony.Challenge, &ceremony.UserID, &ceremony.Username, &ceremony.RPID,
+			pq.Array(&ceremony.AllowCredentials), &ceremony.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrCeremonyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.now().After(ceremony.ExpiresAt) {
+		return nil, entity.ErrCeremonyNotFound
+	}
+	return &ceremony, nil
+}
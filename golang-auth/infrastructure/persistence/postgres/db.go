@@ -0,0 +1,42 @@
+// Package postgres はPostgreSQLを使ったリポジトリの実装です
+// 複数のバックエンドのレプリカで同じデータベースを共有する本番環境向けです
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Open はPostgreSQLに接続します
+// マイグレーションは適用しないため、事前に migrate サブコマンドで適用してください
+func Open(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	return db, nil
+}
+
+// nullTime はゼロ値の時刻をNULLとして保存するための変換です
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// isUniqueViolation は err が一意制約（主キーを含む）の違反による失敗かを返します
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// scanner は *sql.Row と *sql.Rows の共通部分です
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+)
+
+type totpRepository struct {
+	db *sql.DB
+}
+
+func NewTOTPRepository(db *sql.DB) repository.TOTPRepository {
+	return &totpRepository{db: db}
+}
+
+func (r *totpRepository) Get(userID string) (*entity.TOTPCredential, error) {
+	var (
+		credential  entity.TOTPCredential
+		confirmedAt sql.NullTime
+	)
+	err := r.db.QueryRow(`SELECT user_id, secret, confirmed_at, last_used_step, created_at FROM totp_credentials WHERE user_id = $1`, userID).
+		Scan(&credential.UserID, &credential.Secret, &confirmedAt, &credential.LastUsedStep, &credential.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrTOTPNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	credential.ConfirmedAt = confirmedAt.Time
+	return &credential, nil
+}
+
+func (r *totpRepository) Save(credential *entity.TOTPCredential) error {
+	if credential.CreatedAt.IsZero() {
+		credential.CreatedAt = time.Now()
+	}
+	_, err := r.db.Exec(`INSERT INTO totp_credentials (user_id, secret, confirmed_at, last_used_step, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			confirmed_at = EXCLUDED.confirmed_at,
+			last_used_step = EXCLUDED.last_used_step,
+			created_at = EXCLUDED.created_at`,
+		credential.UserID, credential.Secret, nullTime(credential.ConfirmedAt), credential.LastUsedStep, credential.CreatedAt)
+	return err
+}
+
+func (r *totpRepository) Delete(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM totp_credentials WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateLastUsedStep は条件付きの更新で時間ステップを記録します（同じコードを同時に2回受け付けません）
+func (r *totpRepository) UpdateLastUsedStep(userID string, step int64) error {
+	result, err := r.db.Exec(`UPDATE totp_credentials SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $3`, step, userID, step)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		if _, err := r.Get(userID); err != nil {
+			return err
+		}
+		return entity.ErrTOTPStepUsed
+	}
+	return nil
+}
+
+func (r *totpRepository) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec(`INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *totpRepository) UseRecoveryCode(userID, hash string) error {
+	result, err := r.db.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1 AND code_hash = $2`, userID, hash)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return entity.ErrRecoveryCodeNotFound
+	}
+	return nil
+}
+
+func (r *totpRepository) CountRecoveryCodes(userID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+// MFAChallengeRepository はPostgreSQLで2要素目の入力を待つログインを管理します
+// 期限切れのログインは取り出せなくなり、janitor が定期的に削除します
+type MFAChallengeRepository struct {
+	db  *sql.DB
+	now func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMFAChallengeRepository はリポジトリを作成し、interval ごとに期限切れのログインを削除する janitor を起動します
+// 不要になったら Close で janitor を停止してください
+func NewMFAChallengeRepository(db *sql.DB, interval time.Duration) *MFAChallengeRepository {
+	r := &MFAChallengeRepository{
+		db:   db,
+		now:  time.Now,
+		stop: make(chan struct{}),
+	}
+	if interval > 0 {
+		go r.janitor(interval)
+	}
+	return r
+}
+
+// Close は janitor を停止します
+func (r *MFAChallengeRepository) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *MFAChallengeRepository) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.evictExpired(); err != nil {
+				logger.LogWarning("failed to evict expired mfa challenges: %v", err)
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// evictExpired は期限切れのログインを削除します
+func (r *MFAChallengeRepository) evictExpired() error {
+	_, err := r.db.Exec(`DELETE FROM mfa_challenges WHERE expires_at < $1`, r.now())
+	return err
+}
+
+func (r *MFAChallengeRepository) Save(challenge *entity.MFAChallenge) error {
+	_, err := r.db.Exec(`INSERT INTO mfa_challenges (id, state, user_id, attempts, expires_at) VALUES ($1, $2, $3, $4, $5)`,
+		challenge.ID, challenge.State, challenge.UserID, challenge.Attempts, challenge.ExpiresAt)
+	return err
+}
+
+// Consume はログインを削除して返します（削除と取得を1文で行うため、同じIDを同時に2回取り出せません）
+func (r *MFAChallengeRepository) Consume(id string) (*entity.MFAChallenge, error) {
+	var challenge entity.MFAChallenge
+	err := r.db.QueryRow(`DELETE FROM mfa_challenges WHERE id = $1 RETURNING id, state, user_id, attempts, expires_at`, id).
+		Scan(&challenge.ID, &challenge.State, &challenge.UserID, &challenge.Attempts, &challenge.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrMFAChallengeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.now().After(challenge.ExpiresAt) {
+		return nil, entity.ErrMFAChallengeNotFound
+	}
+	return &challenge, nil
+}
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID は複数のレプリカが同時にマイグレーションしないための advisory lock のキーです
+const migrationLockID = 7262010
+
+// ErrPendingMigrations は未適用のマイグレーションがあることを表します
+var ErrPendingMigrations = errors.New("database schema is out of date; run the migrate subcommand")
+
+// migration は番号付きのスキーマ変更です
+// ファイル名は "0001_init.up.sql" と "0001_init.down.sql" の組です
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := cutDirection(file)
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", file)
+		}
+		prefix, _, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", file)
+		}
+		b, err := migrationFiles.ReadFile("migrations/" + file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: base}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.up = string(b)
+		} else {
+			m.down = string(b)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %s must have both up and down files", m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// cutDirection は "0001_init.up.sql" を "0001_init" と "up" に分けます
+func cutDirection(file string) (string, string, bool) {
+	name, ok := strings.CutSuffix(file, ".sql")
+	if !ok {
+		return "", "", false
+	}
+	for _, direction := range []string{"up", "down"} {
+		if base, ok := strings.CutSuffix(name, "."+direction); ok {
+			return base, direction, true
+		}
+	}
+	return "", "", false
+}
+
+// Migrator はマイグレーションの適用と取り消しを行います
+type Migrator struct {
+	db         *sql.DB
+	migrations []migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Version は適用済みの最新のバージョンを返します（未適用の場合は0）
+func (m *Migrator) Version() (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+	var version int
+	err := m.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// Check は未適用のマイグレーションがあれば ErrPendingMigrations を返します
+func (m *Migrator) Check() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if len(m.migrations) > 0 && version < m.migrations[len(m.migrations)-1].version {
+		return ErrPendingMigrations
+	}
+	return nil
+}
+
+// Up は未適用のマイグレーションをすべて適用し、適用した件数を返します
+func (m *Migrator) Up() (int, error) {
+	return m.withLock(func(tx *sql.Tx, version int) (int, error) {
+		applied := 0
+		for _, mig := range m.migrations {
+			if mig.version <= version {
+				continue
+			}
+			if _, err := tx.Exec(mig.up); err != nil {
+				return applied, fmt.Errorf("failed to apply migration %s: %w", mig.name, err)
+			}
+			if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, mig.version); err != nil {
+				return applied, err
+			}
+			applied++
+		}
+		return applied, nil
+	})
+}
+
+// Down は適用済みのマイグレーションを新しい順に steps 件取り消し、取り消した件数を返します
+func (m *Migrator) Down(steps int) (int, error) {
+	return m.withLock(func(tx *sql.Tx, version int) (int, error) {
+		reverted := 0
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			mig := m.migrations[i]
+			if mig.version > version {
+				continue
+			}
+			if _, err := tx.Exec(mig.down); err != nil {
+				return reverted, fmt.Errorf("failed to revert migration %s: %w", mig.name, err)
+			}
+			if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, mig.version); err != nil {
+				return reverted, err
+			}
+			reverted++
+		}
+		return reverted, nil
+	})
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// withLock は advisory lock を取得したトランザクション内で fn を実行します
+// PostgreSQLはDDLもトランザクションで扱えるため、途中で失敗した場合はすべて取り消されます
+func (m *Migrator) withLock(fn func(tx *sql.Tx, version int) (int, error)) (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return 0, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	var version int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	n, err := fn(tx, version)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,4 @@
+DROP TABLE sessions;
+DROP TABLE auth_transactions;
+DROP TABLE credentials;
+DROP TABLE users;
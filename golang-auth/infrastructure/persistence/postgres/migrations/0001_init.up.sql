@@ -0,0 +1,46 @@
+CREATE TABLE users (
+    id            TEXT PRIMARY KEY,
+    username      TEXT NOT NULL,
+    email         TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX users_username ON users (username);
+CREATE INDEX users_email ON users (email);
+
+CREATE TABLE credentials (
+    id               TEXT PRIMARY KEY,
+    username         TEXT NOT NULL,
+    public_key       BYTEA,
+    user_handle      BYTEA,
+    sign_count       BIGINT NOT NULL DEFAULT 0,
+    transports       TEXT[] NOT NULL DEFAULT '{}',
+    attestation_type TEXT NOT NULL DEFAULT '',
+    aaguid           BYTEA
+);
+CREATE INDEX credentials_username ON credentials (username);
+
+CREATE TABLE auth_transactions (
+    state         TEXT PRIMARY KEY,
+    code_verifier TEXT NOT NULL,
+    ticket        TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE sessions (
+    id               TEXT PRIMARY KEY,
+    user_id          TEXT NOT NULL,
+    access_token     TEXT NOT NULL,
+    refresh_token    TEXT NOT NULL DEFAULT '',
+    id_token         TEXT NOT NULL DEFAULT '',
+    token_expires_at TIMESTAMPTZ,
+    subject          TEXT NOT NULL DEFAULT '',
+    ip_address       TEXT NOT NULL DEFAULT '',
+    user_agent       TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL,
+    last_seen_at     TIMESTAMPTZ NOT NULL,
+    expires_at       TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX sessions_user_id ON sessions (user_id);
+CREATE INDEX sessions_expires_at ON sessions (expires_at);
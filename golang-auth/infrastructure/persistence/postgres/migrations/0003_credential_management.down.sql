@@ -0,0 +1,7 @@
+DROP INDEX credentials_user_handle;
+ALTER TABLE credentials
+    DROP COLUMN nickname,
+    DROP COLUMN created_at,
+    DROP COLUMN last_used_at,
+    DROP COLUMN backup_eligible,
+    DROP COLUMN backup_state;
@@ -0,0 +1,7 @@
+ALTER TABLE credentials
+    ADD COLUMN nickname        TEXT NOT NULL DEFAULT '',
+    ADD COLUMN created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN last_used_at    TIMESTAMPTZ,
+    ADD COLUMN backup_eligible BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN backup_state    BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX credentials_user_handle ON credentials (user_handle);
@@ -0,0 +1,3 @@
+ALTER TABLE credentials
+    DROP COLUMN attestation_format,
+    DROP COLUMN attestation_trust_path;
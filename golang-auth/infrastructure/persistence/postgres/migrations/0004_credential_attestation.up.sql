@@ -0,0 +1,3 @@
+ALTER TABLE credentials
+    ADD COLUMN attestation_format     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN attestation_trust_path BYTEA[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+DROP INDEX users_email_unique;
+DROP INDEX users_username_unique;
+ALTER TABLE users
+    DROP COLUMN display_name,
+    DROP COLUMN status;
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN status       TEXT NOT NULL DEFAULT 'active';
+-- 退会済みのユーザーはユーザー名・メールアドレスを消去するため一意性の対象外にする
+CREATE UNIQUE INDEX users_username_unique ON users (username) WHERE status <> 'deleted';
+CREATE UNIQUE INDEX users_email_unique ON users (email) WHERE status <> 'deleted';
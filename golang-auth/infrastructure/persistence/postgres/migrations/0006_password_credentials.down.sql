@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN password_history,
+    DROP COLUMN password_changed_at;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN password_history    TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN password_changed_at TIMESTAMPTZ;
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+CREATE TABLE used_account_tokens (
+    id         TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX used_account_tokens_expires_at ON used_account_tokens (expires_at);
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+)
+
+type passkeyRepository struct {
+	db *sql.DB
+}
+
+func NewPasskeyRepository(db *sql.DB) repository.PasskeyRepository {
+	return &passkeyRepository{db: db}
+}
+
+const selectCredential = `SELECT id, username, public_key, user_handle, sign_count, transports, attestation_type, aaguid,
+	nickname, created_at, last_used_at, backup_eligible, backup_state, attestation_format, attestation_trust_path FROM credentials`
+
+func (r *passkeyRepository) SaveCredential(credential *entity.Credential) error {
+	transports := credential.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	trustPath := credential.AttestationTrustPath
+	if trustPath == nil {
+		trustPath = [][]byte{}
+	}
+
+	// created_at が未設定の場合は保存時刻を使う
+	_, err := r.db.Exec(`INSERT INTO credentials (id, username, public_key, user_handle, sign_count, transports, attestation_type, aaguid,
+			nickname, created_at, last_used_at, backup_eligible, backup_state, attestation_format, attestation_trust_path)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE($10::timestamptz, now()), $11, $12, $13, $14, $15)`,
+		credential.ID, credential.Username, credential.PublicKey, credential.UserHandle,
+		int64(credential.SignCount), pq.Array(transports), credential.AttestationType, credential.AAGUID,
+		credential.Nickname, nullTime(credential.CreatedAt), nullTime(credential.LastUsedAt),
+		credential.BackupEligible, credential.BackupState, credential.AttestationFormat, pq.Array(trustPath))
+	if isUniqueViolation(err) {
+		return entity.ErrCredentialAlreadyRegistered
+	}
+	return err
+}
+
+// GetCredential はクレデンシャルを取得します（存在しない場合は nil を返します）
+func (r *passkeyRepository) GetCredential(id string) (*entity.Credential, error) {
+	credential, err := scanCredential(r.db.QueryRow(selectCredential+` WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return credential, err
+}
+
+func (r *passkeyRepository) GetCredentialsByUsername(username string) ([]*entity.Credential, error) {
+	return r.queryCredentials(selectCredential+` WHERE username = $1`, username)
+}
+
+func (r *passkeyRepository) GetCredentialsByUserHandle(userHandle []byte) ([]*entity.Credential, error) {
+	return r.queryCredentials(selectCredential+` WHERE user_handle = $1 ORDER BY created_at, id`, userHandle)
+}
+
+func (r *passkeyRepository) queryCredentials(query string, args ...interface{}) ([]*entity.Credential, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []*entity.Credential
+	for rows.Next() {
+		credential, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, rows.Err()
+}
+
+// UpdateSignCount は行ロックを取ったトランザクション内で署名カウンターを比較・更新します
+// 複数のレプリカで同じクレデンシャルの認証が同時に行われても、カウンターの巻き戻りを見逃さないようにします
+func (r *passkeyRepository) UpdateSignCount(id string, signCount uint32) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stored int64
+	err = tx.QueryRow(`SELECT sign_count FROM credentials WHERE id = $1 FOR UPDATE`, id).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ErrCredentialNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	received := int64(signCount)
+	if received <= stored && !(stored == 0 && received == 0) {
+		return entity.ErrSignCountNotIncreased
+	}
+
+	if _, err := tx.Exec(`UPDATE credentials SET sign_count = $1 WHERE id = $2`, received, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// exec はクレデンシャル1件を更新・削除し、対象が無ければ entity.ErrCredentialNotFound を返します
+func (r *passkeyRepository) exec(query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return entity.ErrCredentialNotFound
+	}
+	return nil
+}
+
+func (r *passkeyRepository) UpdateNickname(id, nickname string) error {
+	return r.exec(`UPDATE credentials SET nickname = $1 WHERE id = $2`, nickname, id)
+}
+
+func (r *passkeyRepository) UpdateLastUsed(id string, usedAt time.Time, backupState bool) error {
+	return r.exec(`UPDATE credentials SET last_used_at = $1, backup_state = $2 WHERE id = $3`, usedAt, backupState, id)
+}
+
+func (r *passkeyRepository) DeleteCredential(id string) error {
+	return r.exec(`DELETE FROM credentials WHERE id = $1`, id)
+}
+
+func scanCredential(s scanner) (*entity.Credential, error) {
+	var (
+		credential entity.Credential
+		signCount  int64
+		lastUsedAt sql.NullTime
+	)
+	if err := s.Scan(&credential.ID, &credential.Username, &credential.PublicKey, &credential.UserHandle,
+		&signCount, pq.Array(&credential.Transports), &credential.AttestationType, &credential.AAGUID,
+		&credential.Nickname, &credential.CreatedAt, &lastUsedAt, &credential.BackupEligible, &credential.BackupState,
+		&credential.AttestationFormat, pq.Array(&credential.AttestationTrustPath)); err != nil {
+		return nil, err
+	}
+	credential.SignCount = uint32(signCount)
+	credential.LastUsedAt = lastUsedAt.Time
+	return &credential, nil
+}
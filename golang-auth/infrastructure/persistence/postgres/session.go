@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+)
+
+// SessionRepository はPostgreSQLでセッションを管理します
+// 最終アクセスから ttl を過ぎたセッションは取得できなくなり、janitor が定期的に削除します
+type SessionRepository struct {
+	db  *sql.DB
+	ttl time.Duration
+	now func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSessionRepository はセッションリポジトリを作成し、interval ごとに期限切れのセッションを削除する janitor を起動します
+// 不要になったら Close で janitor を停止してください
+func NewSessionRepository(db *sql.DB, ttl, interval time.Duration) *SessionRepository {
+	r := &SessionRepository{
+		db:   db,
+		ttl:  ttl,
+		now:  time.Now,
+		stop: make(chan struct{}),
+	}
+	if interval > 0 {
+		go r.janitor(interval)
+	}
+	return r
+}
+
+// Close は janitor を停止します
+func (r *SessionRepository) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *SessionRepository) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.evictExpired(); err != nil {
+				logger.LogWarning("failed to evict expired sessions: %v", err)
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// evictExpired は期限切れのセッションを削除します
+func (r *SessionRepository) evictExpired() error {
+	_, err := r.db.Exec(`DELETE FROM sessions WHERE expires_at < $1`, r.now())
+	return err
+}
+
+func (r *SessionRepository) Create(session *entity.Session) error {
+	now := r.now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = now
+	}
+	session.ExpiresAt = session.LastSeenAt.Add(r.ttl)
+
+	_, err := r.db.Exec(`INSERT INTO sessions (id, user_id, access_token, refresh_token, id_token, token_expires_at, subject,
+			ip_address, user_agent, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		session.ID, session.UserID, session.Tokens.AccessToken, session.Tokens.RefreshToken, session.Tokens.IDToken,
+		nullTime(session.Tokens.ExpiresAt), session.Tokens.Subject, session.IPAddress, session.UserAgent,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	return err
+}
+
+const selectSession = `SELECT id, user_id, access_token, refresh_token, id_token, token_expires_at, subject,
+	ip_address, user_agent, created_at, last_seen_at, expires_at FROM sessions`
+
+func scanSession(s scanner) (*entity.Session, error) {
+	var (
+		session        entity.Session
+		tokenExpiresAt sql.NullTime
+	)
+	if err := s.Scan(&session.ID, &session.UserID, &session.Tokens.AccessToken, &session.Tokens.RefreshToken,
+		&session.Tokens.IDToken, &tokenExpiresAt, &session.Tokens.Subject, &session.IPAddress, &session.UserAgent,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt); err != nil {
+		return nil, err
+	}
+	session.Tokens.ExpiresAt = tokenExpiresAt.Time
+	return &session, nil
+}
+
+func (r *SessionRepository) Get(id string) (*entity.Session, error) {
+	session, err := scanSession(r.db.QueryRow(selectSession+` WHERE id = $1 AND expires_at >= $2`, id, r.now()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrSessionNotFound
+	}
+	return session, err
+}
+
+// exec は有効なセッション1件を更新し、対象が無ければ entity.ErrSessionNotFound を返します
+func (r *SessionRepository) exec(query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return entity.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepository) Touch(id string, at time.Time) error {
+	return r.exec(`UPDATE sessions SET last_seen_at = $1, expires_at = $2 WHERE id = $3 AND expires_at >= $4`,
+		at, at.Add(r.ttl), id, r.now())
+}
+
+func (r *SessionRepository) UpdateTokens(id string, tokens entity.Tokens) error {
+	return r.exec(`UPDATE sessions SET access_token = $1, refresh_token = $2, id_token = $3, token_expires_at = $4, subject = $5
+		WHERE id = $6 AND expires_at >= $7`,
+		tokens.AccessToken, tokens.RefreshToken, tokens.IDToken, nullTime(tokens.ExpiresAt), tokens.Subject,
+		id, r.now())
+}
+
+func (r *SessionRepository) Delete(id string) error {
+	_, err := r.db.Exec(`DELETE FROM sessions WHERE id = $1`, id)
+	return err
+}
+
+func (r *SessionRepository) ListByUser(userID string) ([]*entity.Session, error) {
+	rows, err := r.db.Query(selectSession+` WHERE user_id = $1 AND expires_at >= $2 ORDER BY created_at`, userID, r.now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*entity.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+)
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) repository.UserRepository {
+	return &userRepository{db: db}
+}
+
+const selectUser = `SELECT id, username, email, email_verified_at, display_name, password_hash, password_history, password_changed_at, status, created_at, updated_at FROM users`
+
+func (r *userRepository) findOne(query string, arg interface{}) (*entity.User, error) {
+	var (
+		user                               entity.User
+		emailVerifiedAt, passwordChangedAt sql.NullTime
+	)
+	err := r.db.QueryRow(query, arg).Scan(&user.ID, &user.Username, &user.Email, &emailVerifiedAt, &user.DisplayName, &user.PasswordHash,
+		pq.Array(&user.PasswordHistory), &passwordChangedAt, &user.Status, &user.CreatedAt, &user.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = emailVerifiedAt.Time
+	user.PasswordChangedAt = passwordChangedAt.Time
+	return &user, nil
+}
+
+func (r *userRepository) FindByID(id string) (*entity.User, error) {
+	return r.findOne(selectUser+` WHERE id = $1`, id)
+}
+
+func (r *userRepository) FindByUsername(username string) (*entity.User, error) {
+	return r.findOne(selectUser+` WHERE username = $1 ORDER BY created_at LIMIT 1`, username)
+}
+
+func (r *userRepository) FindByEmail(email string) (*entity.User, error) {
+	return r.findOne(selectUser+` WHERE email = $1 ORDER BY created_at LIMIT 1`, email)
+}
+
+func (r *userRepository) Save(user *entity.User) error {
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+	if user.Status == "" {
+		user.Status = entity.UserStatusActive
+	}
+
+	history := user.PasswordHistory
+	if history == nil {
+		history = []string{}
+	}
+
+	_, err := r.db.Exec(`INSERT INTO users (id, username, email, email_verified_at, display_name, password_hash, password_history, password_changed_at, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET
+			username = EXCLUDED.username,
+			email = EXCLUDED.email,
+			email_verified_at = EXCLUDED.email_verified_at,
+			display_name = EXCLUDED.display_name,
+			password_hash = EXCLUDED.password_hash,
+			password_history = EXCLUDED.password_history,
+			password_changed_at = EXCLUDED.password_changed_at,
+			status = EXCLUDED.status,
+			updated_at = EXCLUDED.updated_at`,
+		user.ID, user.Username, user.Email, nullTime(user.EmailVerifiedAt), user.DisplayName, user.PasswordHash, pq.Array(history), nullTime(user.PasswordChangedAt),
+		user.Status, user.CreatedAt, user.UpdatedAt)
+	return userConflictError(err)
+}
+
+// userConflictError はユーザー名・メールアドレスの一意インデックス（0005_user_accounts）の違反を重複のエラーに変換します
+func userConflictError(err error) error {
+	var pqErr *pq.Error
+	if !isUniqueViolation(err) || !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Constraint {
+	case "users_username_unique":
+		return entity.ErrUsernameTaken
+	case "users_email_unique":
+		return entity.ErrEmailTaken
+	}
+	return err
+}
+
+func (r *userRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return entity.ErrUserNotFound
+	}
+	return nil
+}
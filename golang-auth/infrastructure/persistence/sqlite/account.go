@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// AccountTokenRepository はSQLiteで使用済みのアカウントのトークンを記録します
+// 期限切れの記録は MarkUsed のたびに削除します
+type AccountTokenRepository struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewAccountTokenRepository は使用済みトークンのリポジトリを作成します
+func NewAccountTokenRepository(db *sql.DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: db, now: time.Now}
+}
+
+// MarkUsed はトークンを使用済みとして記録します（挿入と重複の確認を1文で行うため、同じトークンを同時に2回使えません）
+func (r *AccountTokenRepository) MarkUsed(id string, expiresAt time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM used_account_tokens WHERE expires_at < ?`, toUnix(r.now())); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`INSERT INTO used_account_tokens (id, expires_at) VALUES (?, ?) ON CONFLICT (id) DO NOTHING`,
+		id, toUnix(expiresAt))
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return entity.ErrAccountTokenUsed
+	}
+	return nil
+}
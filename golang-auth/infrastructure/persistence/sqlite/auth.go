@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+)
+
+type authRepository struct {
+	db *sql.DB
+}
+
+func NewAuthRepository(db *sql.DB) repository.AuthRepository {
+	return &authRepository{db: db}
+}
+
+func (r *authRepository) StoreAuthData(state string, data entity.AuthData) error {
+	_, err := r.db.Exec(`INSERT INTO auth_transactions (state, code_verifier, ticket, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (state) DO UPDATE SET
+			code_verifier = excluded.code_verifier,
+			ticket = excluded.ticket`,
+		state, data.CodeVerifier, data.Ticket, toUnix(time.Now()))
+	return err
+}
+
+func (r *authRepository) GetAuthData(state string) (entity.AuthData, bool) {
+	var data entity.AuthData
+	err := r.db.QueryRow(`SELECT code_verifier, ticket FROM auth_transactions WHERE state = ?`, state).
+		Scan(&data.CodeVerifier, &data.Ticket)
+	if err != nil {
+		return entity.AuthData{}, false
+	}
+	return data, true
+}
@@ -0,0 +1,59 @@
+// Package sqlite はSQLiteを使ったリポジトリの実装です
+// ドライバーには純Go実装の modernc.org/sqlite を使うため、cgoは不要です
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Open はSQLiteのデータベースを開き、未適用のマイグレーションを適用します
+// path には ":memory:" も指定できます
+func Open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	// SQLiteは書き込みを直列化するため、接続を1本に絞ってロック競合を避ける
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// toUnix は時刻をUNIX時間（ナノ秒）で保存するための変換です（ゼロ値は0）
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnix は保存したUNIX時間（ナノ秒）を時刻に戻します（0はゼロ値）
+func fromUnix(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// isUniqueViolation は err がUNIQUEインデックスの重複による失敗かを返します
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// isPrimaryKeyViolation は err が主キーの重複による INSERT の失敗かを返します
+func isPrimaryKeyViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
@@ -0,0 +1,11 @@
+CREATE TABLE webauthn_ceremonies (
+    id                TEXT PRIMARY KEY,
+    type              TEXT NOT NULL,
+    challenge         BLOB NOT NULL,
+    user_id           TEXT NOT NULL DEFAULT '',
+    username          TEXT NOT NULL DEFAULT '',
+    rp_id             TEXT NOT NULL,
+    allow_credentials TEXT NOT NULL DEFAULT '[]',
+    expires_at        INTEGER NOT NULL
+);
+CREATE INDEX webauthn_ceremonies_expires_at ON webauthn_ceremonies (expires_at);
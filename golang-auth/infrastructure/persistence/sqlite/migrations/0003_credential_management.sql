@@ -0,0 +1,6 @@
+ALTER TABLE credentials ADD COLUMN nickname TEXT NOT NULL DEFAULT '';
+ALTER TABLE credentials ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE credentials ADD COLUMN last_used_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE credentials ADD COLUMN backup_eligible INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE credentials ADD COLUMN backup_state INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX credentials_user_handle ON credentials (user_handle);
@@ -0,0 +1,2 @@
+ALTER TABLE credentials ADD COLUMN attestation_format TEXT NOT NULL DEFAULT '';
+ALTER TABLE credentials ADD COLUMN attestation_trust_path TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+-- 以前のパスワードのハッシュをJSONの配列で保持する
+ALTER TABLE users ADD COLUMN password_history TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE users ADD COLUMN password_changed_at INTEGER NOT NULL DEFAULT 0;
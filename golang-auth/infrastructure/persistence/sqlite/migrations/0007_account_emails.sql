@@ -0,0 +1,9 @@
+-- メールアドレスの所有を確認した日時（0は未確認）
+ALTER TABLE users ADD COLUMN email_verified_at INTEGER NOT NULL DEFAULT 0;
+
+-- メール確認・パスワード再設定の使用済みトークン（期限切れのものは削除する）
+CREATE TABLE used_account_tokens (
+    id         TEXT PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX used_account_tokens_expires_at ON used_account_tokens (expires_at);
@@ -0,0 +1,23 @@
+CREATE TABLE totp_credentials (
+    user_id        TEXT PRIMARY KEY,
+    secret         BLOB NOT NULL,
+    confirmed_at   INTEGER NOT NULL DEFAULT 0,
+    last_used_step INTEGER NOT NULL DEFAULT 0,
+    created_at     INTEGER NOT NULL
+);
+
+-- リカバリーコードはSHA-256のハッシュだけを保存し、使用したものは削除する
+CREATE TABLE mfa_recovery_codes (
+    user_id   TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+CREATE TABLE mfa_challenges (
+    id         TEXT PRIMARY KEY,
+    state      TEXT NOT NULL,
+    user_id    TEXT NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    expires_at INTEGER NOT NULL
+);
+CREATE INDEX mfa_challenges_expires_at ON mfa_challenges (expires_at);
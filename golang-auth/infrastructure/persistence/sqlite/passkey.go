@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+)
+
+type passkeyRepository struct {
+	db *sql.DB
+}
+
+func NewPasskeyRepository(db *sql.DB) repository.PasskeyRepository {
+	return &passkeyRepository{db: db}
+}
+
+const selectCredential = `SELECT id, username, public_key, user_handle, sign_count, transports, attestation_type, aaguid,
+	nickname, created_at, last_used_at, backup_eligible, backup_state, attestation_format, attestation_trust_path FROM credentials`
+
+func (r *passkeyRepository) SaveCredential(credential *entity.Credential) error {
+	transports, err := json.Marshal(credential.Transports)
+	if err != nil {
+		return err
+	}
+	trustPath, err := json.Marshal(credential.AttestationTrustPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`INSERT INTO credentials (id, username, public_key, user_handle, sign_count, transports, attestation_type, aaguid,
+			nickname, created_at, last_used_at, backup_eligible, backup_state, attestation_format, attestation_trust_path)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		credential.ID, credential.Username, credential.PublicKey, credential.UserHandle,
+		credential.SignCount, string(transports), credential.AttestationType, credential.AAGUID,
+		credential.Nickname, toUnix(credential.CreatedAt), toUnix(credential.LastUsedAt),
+		credential.BackupEligible, credential.BackupState, credential.AttestationFormat, string(trustPath))
+	if isPrimaryKeyViolation(err) {
+		return entity.ErrCredentialAlreadyRegistered
+	}
+	return err
+}
+
+// GetCredential はクレデンシャルを取得します（存在しない場合は nil を返します）
+func (r *passkeyRepository) GetCredential(id string) (*entity.Credential, error) {
+	credential, err := scanCredential(r.db.QueryRow(selectCredential+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return credential, err
+}
+
+func (r *passkeyRepository) GetCredentialsByUsername(username string) ([]*entity.Credential, error) {
+	return r.queryCredentials(selectCredential+` WHERE username = ?`, username)
+}
+
+func (r *passkeyRepository) GetCredentialsByUserHandle(userHandle []byte) ([]*entity.Credential, error) {
+	return r.queryCredentials(selectCredential+` WHERE user_handle = ? ORDER BY created_at, id`, userHandle)
+}
+
+func (r *passkeyRepository) queryCredentials(query string, args ...interface{}) ([]*entity.Credential, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []*entity.Credential
+	for rows.Next() {
+		credential, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, rows.Err()
+}
+
+// scanner は *sql.Row と *sql.Rows の共通部分です
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCredential(s scanner) (*entity.Credential, error) {
+	var (
+		credential entity.Credential
+		transports string
+		trustPath  string
+		createdAt  int64
+		lastUsedAt int64
+	)
+	if err := s.Scan(&credential.ID, &credential.Username, &credential.PublicKey, &credential.UserHandle,
+		&credential.SignCount, &transports, &credential.AttestationType, &credential.AAGUID,
+		&credential.Nickname, &createdAt, &lastUsedAt, &credential.BackupEligible, &credential.BackupState,
+		&credential.AttestationFormat, &trustPath); err != nil {
+		return nil, err
+	}
+	credential.CreatedAt = fromUnix(createdAt)
+	credential.LastUsedAt = fromUnix(lastUsedAt)
+	if err := json.Unmarshal([]byte(transports), &credential.Transports); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(trustPath), &credential.AttestationTrustPath); err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *passkeyRepository) UpdateSignCount(id string, signCount uint32) error {
+	// 条件付きUPDATEで比較と更新を不可分に行う
+	result, err := r.db.Exec(`UPDATE credentials SET sign_count = ?
+		WHERE id = ? AND (sign_count < ? OR (sign_count = 0 AND ? = 0))`,
+		signCount, id, signCount, signCount)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM credentials WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return entity.ErrCredentialNotFound
+	}
+	return entity.ErrSignCountNotIncreased
+}
+
+// exec はクレデンシャル1件を更新・削除し、対象が無ければ entity.ErrCredentialNotFound を返します
+func (r *passkeyRepository) exec(query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return entity.ErrCredentialNotFound
+	}
+	return nil
+}
+
+func (r *passkeyRepository) UpdateNickname(id, nickname string) error {
+	return r.exec(`UPDATE credentials SET nickname = ? WHERE id = ?`, nickname, id)
+}
+
+func (r *passkeyRepository) UpdateLastUsed(id string, usedAt time.Time, backupState bool) error {
+	return r.exec(`UPDATE credentials SET last_used_at = ?, backup_state = ? WHERE id = ?`, toUnix(usedAt), backupState, id)
+}
+
+func (r *passkeyRepository) DeleteCredential(id string) error {
+	return r.exec(`DELETE FROM credentials WHERE id = ?`, id)
+}
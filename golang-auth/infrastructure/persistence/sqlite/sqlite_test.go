@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, NewUserRepository(db).Save(&entity.User{ID: "user-1", Username: "alice", Email: "alice@example.com"}))
+	db.Close()
+
+	// 再起動してもデータが残り、マイグレーションは再適用されない
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	user, err := NewUserRepository(db).FindByID("user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+}
+
+func TestUserRepository(t *testing.T) {
+	repo := NewUserRepository(openTestDB(t))
+
+	user := &entity.User{ID: "user-1", Username: "alice", Email: "alice@example.com", DisplayName: "Alice", PasswordHash: "hash"}
+	require.NoError(t, repo.Save(user))
+	assert.False(t, user.CreatedAt.IsZero())
+
+	found, err := repo.FindByEmail("alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", found.ID)
+	assert.Equal(t, "hash", found.PasswordHash)
+	assert.Equal(t, "Alice", found.DisplayName)
+	assert.Equal(t, entity.UserStatusActive, found.Status)
+
+	// 有効なユーザーとメールアドレスは重複できない
+	assert.ErrorIs(t, repo.Save(&entity.User{ID: "user-2", Username: "bob", Email: "alice@example.com"}), entity.ErrEmailTaken)
+	assert.ErrorIs(t, repo.Save(&entity.User{ID: "user-2", Username: "alice", Email: "bob@example.com"}), entity.ErrUsernameTaken)
+	_, err = repo.FindByUsername("unknown")
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+
+	assert.Empty(t, found.PasswordHistory)
+
+	assert.True(t, found.EmailVerifiedAt.IsZero())
+
+	changedAt := time.Unix(1700000000, 0)
+	verifiedAt := time.Unix(1700000100, 0)
+	user.Username = "alice2"
+	user.PasswordHistory = []string{"old-hash-2", "old-hash-1"}
+	user.PasswordChangedAt = changedAt
+	user.EmailVerifiedAt = verifiedAt
+	require.NoError(t, repo.Save(user))
+	found, err = repo.FindByUsername("alice2")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", found.ID)
+	assert.Equal(t, []string{"old-hash-2", "old-hash-1"}, found.PasswordHistory)
+	assert.True(t, changedAt.Equal(found.PasswordChangedAt))
+	assert.True(t, verifiedAt.Equal(found.EmailVerifiedAt))
+
+	// 退会済みのユーザーのメールアドレスは再び使える
+	user.Status = entity.UserStatusDeleted
+	require.NoError(t, repo.Save(user))
+	require.NoError(t, repo.Save(&entity.User{ID: "user-2", Username: "alice2", Email: "alice@example.com"}))
+
+	require.NoError(t, repo.Delete("user-1"))
+	_, err = repo.FindByID("user-1")
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+	assert.Error(t, repo.Delete("user-1"))
+}
+
+func TestPasskeyRepository(t *testing.T) {
+	repo := NewPasskeyRepository(openTestDB(t))
+
+	credential := &entity.Credential{
+		ID:         "credential-1",
+		Username:   "alice",
+		PublicKey:  []byte{1, 2, 3},
+		UserHandle: []byte("user-1"),
+		SignCount:  5,
+		Transports: []string{"internal", "hybrid"},
+		AAGUID:     make([]byte, 16),
+
+		AttestationType:      "basic",
+		AttestationFormat:    "packed",
+		AttestationTrustPath: [][]byte{{4, 5, 6}, {7, 8}},
+	}
+	require.NoError(t, repo.SaveCredential(credential))
+
+	found, err := repo.GetCredential("credential-1")
+	require.NoError(t, err)
+	assert.Equal(t, credential, found)
+
+	missing, err := repo.GetCredential("unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	credentials, err := repo.GetCredentialsByUsername("alice")
+	require.NoError(t, err)
+	assert.Len(t, credentials, 1)
+
+	// 登録済みのIDでは上書きしない
+	err = repo.SaveCredential(&entity.Credential{ID: "credential-1", Username: "mallory", PublicKey: []byte{9}})
+	assert.ErrorIs(t, err, entity.ErrCredentialAlreadyRegistered)
+	found, err = repo.GetCredential("credential-1")
+	require.NoError(t, err)
+	assert.Equal(t, credential, found)
+}
+
+func TestAuthRepository(t *testing.T) {
+	repo := NewAuthRepository(openTestDB(t))
+
+	require.NoError(t, repo.StoreAuthData("state-1", entity.AuthData{CodeVerifier: "verifier", Ticket: "ticket"}))
+	data, ok := repo.GetAuthData("state-1")
+	assert.True(t, ok)
+	assert.Equal(t, "ticket", data.Ticket)
+
+	_, ok = repo.GetAuthData("unknown")
+	assert.False(t, ok)
+}
+
+func TestSessionRepository(t *testing.T) {
+	repo := NewSessionRepository(openTestDB(t), time.Minute, 0)
+	defer repo.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	tokenExpiresAt := now.Add(time.Hour)
+	require.NoError(t, repo.Create(&entity.Session{
+		ID:        "session-1",
+		UserID:    "user-1",
+		Tokens:    entity.Tokens{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: tokenExpiresAt},
+		IPAddress: "192.0.2.1",
+		UserAgent: "test-agent",
+	}))
+
+	session, err := repo.Get("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, "refresh", session.Tokens.RefreshToken)
+	assert.True(t, tokenExpiresAt.Equal(session.Tokens.ExpiresAt))
+	assert.Equal(t, "192.0.2.1", session.IPAddress)
+
+	require.NoError(t, repo.UpdateTokens("session-1", entity.Tokens{AccessToken: "new-access"}))
+	now = now.Add(30 * time.Second)
+	require.NoError(t, repo.Touch("session-1", now))
+
+	now = now.Add(45 * time.Second)
+	sessions, err := repo.ListByUser("user-1")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "new-access", sessions[0].Tokens.AccessToken)
+
+	// 最終アクセスからTTLを過ぎると取得できない
+	now = now.Add(time.Minute)
+	_, err = repo.Get("session-1")
+	assert.ErrorIs(t, err, entity.ErrSessionNotFound)
+	assert.ErrorIs(t, repo.Touch("session-1", now), entity.ErrSessionNotFound)
+
+	require.NoError(t, repo.evictExpired())
+	var count int
+	require.NoError(t, repo.db.QueryRow(`SELECT COUNT(*) FROM sessions`).Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestPasskeyRepositoryUpdateSignCount(t *testing.T) {
+	repo := NewPasskeyRepository(openTestDB(t))
+	require.NoError(t, repo.SaveCredential(&entity.Credential{ID: "credential-1", Username: "alice", SignCount: 1}))
+
+	require.NoError(t, repo.UpdateSignCount("credential-1", 2))
+	assert.ErrorIs(t, repo.UpdateSignCount("credential-1", 2), entity.ErrSignCountNotIncreased)
+	assert.ErrorIs(t, repo.UpdateSignCount("unknown", 1), entity.ErrCredentialNotFound)
+
+	credential, err := repo.GetCredential("credential-1")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), credential.SignCount)
+}
+
+func TestPasskeyRepositoryManagement(t *testing.T) {
+	repo := NewPasskeyRepository(openTestDB(t))
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.SaveCredential(&entity.Credential{ID: "credential-2", Username: "alice", UserHandle: []byte("user-1"), CreatedAt: createdAt.Add(time.Hour), BackupEligible: true}))
+	require.NoError(t, repo.SaveCredential(&entity.Credential{ID: "credential-1", Username: "alice", UserHandle: []byte("user-1"), CreatedAt: createdAt}))
+	require.NoError(t, repo.SaveCredential(&entity.Credential{ID: "credential-3", Username: "bob", UserHandle: []byte("user-2"), CreatedAt: createdAt}))
+
+	require.NoError(t, repo.UpdateNickname("credential-2", "MacBook"))
+	usedAt := createdAt.Add(2 * time.Hour)
+	require.NoError(t, repo.UpdateLastUsed("credential-2", usedAt, true))
+
+	credentials, err := repo.GetCredentialsByUserHandle([]byte("user-1"))
+	require.NoError(t, err)
+	require.Len(t, credentials, 2)
+	assert.Equal(t, "credential-1", credentials[0].ID)
+	assert.True(t, createdAt.Equal(credentials[0].CreatedAt))
+	assert.True(t, credentials[0].LastUsedAt.IsZero())
+	assert.Equal(t, "MacBook", credentials[1].Nickname)
+	assert.True(t, usedAt.Equal(credentials[1].LastUsedAt))
+	assert.True(t, credentials[1].BackupEligible)
+	assert.True(t, credentials[1].BackupState)
+
+	require.NoError(t, repo.DeleteCredential("credential-2"))
+	assert.ErrorIs(t, repo.DeleteCredential("credential-2"), entity.ErrCredentialNotFound)
+	assert.ErrorIs(t, repo.UpdateNickname("credential-2", "MacBook"), entity.ErrCredentialNotFound)
+	assert.ErrorIs(t, repo.UpdateLastUsed("credential-2", usedAt, false), entity.ErrCredentialNotFound)
+}
+
+func TestCeremonyRepository(t *testing.T) {
+	repo := NewCeremonyRepository(openTestDB(t), 0)
+	defer repo.Close()
+	now := time.Now()
+
+	require.NoError(t, repo.Save(&entity.WebAuthnCeremony{
+		ID:               "ceremony-1",
+		Type:             entity.CeremonyAuthentication,
+		Challenge:        []byte("challenge"),
+		Username:         "alice",
+		RPID:             "localhost",
+		AllowCredentials: []string{"credential-1"},
+		ExpiresAt:        now.Add(time.Minute),
+	}))
+	require.NoError(t, repo.Save(&entity.WebAuthnCeremony{ID: "ceremony-2", RPID: "localhost", Challenge: []byte("c"), ExpiresAt: now.Add(-time.Second)}))
+
+	ceremony, err := repo.Consume("ceremony-1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("challenge"), ceremony.Challenge)
+	assert.Equal(t, []string{"credential-1"}, ceremony.AllowCredentials)
+
+	// 使用済みや期限切れのセレモニーは取り出せない
+	_, err = repo.Consume("ceremony-1")
+	assert.ErrorIs(t, err, entity.ErrCeremonyNotFound)
+	_, err = repo.Consume("ceremony-2")
+	assert.ErrorIs(t, err, entity.ErrCeremonyNotFound)
+}
+
+func TestAccountTokenRepository(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewAccountTokenRepository(db)
+	now := time.Now()
+
+	require.NoError(t, repo.MarkUsed("token-1", now.Add(time.Hour)))
+	require.NoError(t, repo.MarkUsed("token-2", now.Add(-time.Second)))
+
+	// 使用済みのトークンは再び使えない
+	assert.ErrorIs(t, repo.MarkUsed("token-1", now.Add(time.Hour)), entity.ErrAccountTokenUsed)
+
+	// 期限切れの記録は削除する
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM used_account_tokens`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestTOTPRepository(t *testing.T) {
+	repo := NewTOTPRepository(openTestDB(t))
+	confirmedAt := time.Now().Truncate(time.Second)
+
+	_, err := repo.Get("user-1")
+	assert.ErrorIs(t, err, entity.ErrTOTPNotFound)
+	assert.ErrorIs(t, repo.UpdateLastUsedStep("user-1", 1), entity.ErrTOTPNotFound)
+
+	require.NoError(t, repo.Save(&entity.TOTPCredential{UserID: "user-1", Secret: []byte("secret")}))
+	credential, err := repo.Get("user-1")
+	require.NoError(t, err)
+	assert.False(t, credential.Confirmed())
+
+	credential.ConfirmedAt = confirmedAt
+	credential.LastUsedStep = 10
+	require.NoError(t, repo.Save(credential))
+	credential, err = repo.Get("user-1")
+	require.NoError(t, err)
+	assert.True(t, confirmedAt.Equal(credential.ConfirmedAt))
+	assert.Equal(t, []byte("secret"), credential.Secret)
+
+	// 受け付けた時間ステップ以前のコードは使えない
+	assert.ErrorIs(t, repo.UpdateLastUsedStep("user-1", 10), entity.ErrTOTPStepUsed)
+	require.NoError(t, repo.UpdateLastUsedStep("user-1", 11))
+
+	require.NoError(t, repo.ReplaceRecoveryCodes("user-1", []string{"hash-1", "hash-2"}))
+	require.NoError(t, repo.UseRecoveryCode("user-1", "hash-1"))
+	assert.ErrorIs(t, repo.UseRecoveryCode("user-1", "hash-1"), entity.ErrRecoveryCodeNotFound)
+	count, err := repo.CountRecoveryCodes("user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// 削除するとリカバリーコードも消える
+	require.NoError(t, repo.Delete("user-1"))
+	_, err = repo.Get("user-1")
+	assert.ErrorIs(t, err, entity.ErrTOTPNotFound)
+	count, err = repo.CountRecoveryCodes("user-1")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestMFAChallengeRepository(t *testing.T) {
+	repo := NewMFAChallengeRepository(openTestDB(t), 0)
+	defer repo.Close()
+	now := time.Now()
+
+	require.NoError(t, repo.Save(&entity.MFAChallenge{ID: "challenge-1", State: "state", UserID: "user-1", Attempts: 2, ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, repo.Save(&entity.MFAChallenge{ID: "challenge-2", UserID: "user-1", ExpiresAt: now.Add(-time.Second)}))
+
+	challenge, err := repo.Consume("challenge-1")
+	require.NoError(t, err)
+	assert.Equal(t, "state", challenge.State)
+	assert.Equal(t, 2, challenge.Attempts)
+
+	// 使用済みや期限切れのログインは取り出せない
+	_, err = repo.Consume("challenge-1")
+	assert.ErrorIs(t, err, entity.ErrMFAChallengeNotFound)
+	_, err = repo.Consume("challenge-2")
+	assert.ErrorIs(t, err, entity.ErrMFAChallengeNotFound)
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/interface/repository"
+)
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) repository.UserRepository {
+	return &userRepository{db: db}
+}
+
+const selectUser = `SELECT id, username, email, email_verified_at, display_name, password_hash, password_history, password_changed_at, status, created_at, updated_at FROM users`
+
+func (r *userRepository) findOne(query string, arg interface{}) (*entity.User, error) {
+	var (
+		user                                                     entity.User
+		history                                                  string
+		emailVerifiedAt, passwordChangedAt, createdAt, updatedAt int64
+	)
+	err := r.db.QueryRow(query, arg).Scan(&user.ID, &user.Username, &user.Email, &emailVerifiedAt, &user.DisplayName, &user.PasswordHash,
+		&history, &passwordChangedAt, &user.Status, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(history), &user.PasswordHistory); err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = fromUnix(emailVerifiedAt)
+	user.PasswordChangedAt = fromUnix(passwordChangedAt)
+	user.CreatedAt = fromUnix(createdAt)
+	user.UpdatedAt = fromUnix(updatedAt)
+	return &user, nil
+}
+
+func (r *userRepository) FindByID(id string) (*entity.User, error) {
+	return r.findOne(selectUser+` WHERE id = ?`, id)
+}
+
+func (r *userRepository) FindByUsername(username string) (*entity.User, error) {
+	return r.findOne(selectUser+` WHERE username = ? ORDER BY created_at LIMIT 1`, username)
+}
+
+func (r *userRepository) FindByEmail(email string) (*entity.User, error) {
+	return r.findOne(selectUser+` WHERE email = ? ORDER BY created_at LIMIT 1`, email)
+}
+
+func (r *userRepository) Save(user *entity.User) error {
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+	if user.Status == "" {
+		user.Status = entity.UserStatusActive
+	}
+
+	history, err := json.Marshal(user.PasswordHistory)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHistory == nil {
+		history = []byte("[]")
+	}
+
+	_, err = r.db.Exec(`INSERT INTO users (id, username, email, email_verified_at, display_name, password_hash, password_history, password_changed_at, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			username = excluded.username,
+			email = excluded.email,
+			email_verified_at = excluded.email_verified_at,
+			display_name = excluded.display_name,
+			password_hash = excluded.password_hash,
+			password_history = excluded.password_history,
+			password_changed_at = excluded.password_changed_at,
+			status = excluded.status,
+			updated_at = excluded.updated_at`,
+		user.ID, user.Username, user.Email, toUnix(user.EmailVerifiedAt), user.DisplayName, user.PasswordHash, string(history), toUnix(user.PasswordChangedAt),
+		user.Status, toUnix(user.CreatedAt), toUnix(user.UpdatedAt))
+	return userConflictError(err)
+}
+
+// userConflictError はユーザー名・メールアドレスのUNIQUEインデックスの違反を重複のエラーに変換します
+// SQLiteのエラーメッセージにはインデックス名ではなく「users.username」のように列名が入ります
+func userConflictError(err error) error {
+	if !isUniqueViolation(err) {
+		return err
+	}
+	switch {
+	case strings.Contains(err.Error(), "users.username"):
+		return entity.ErrUsernameTaken
+	case strings.Contains(err.Error(), "users.email"):
+		return entity.ErrEmailTaken
+	}
+	return err
+}
+
+func (r *userRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return entity.ErrUserNotFound
+	}
+	return nil
+}
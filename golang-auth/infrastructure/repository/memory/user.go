@@ -1,7 +1,6 @@
 package memory
 
 import (
-	"errors"
 	"sync"
 	"time"
 
@@ -26,27 +25,33 @@ func (r *userRepository) FindByID(id string) (*entity.User, error) {
 
 	user, exists := r.users[id]
 	if !exists {
-		return nil, errors.New("user not found")
+		return nil, entity.ErrUserNotFound
 	}
 	return user, nil
 }
 
 func (r *userRepository) FindByUsername(username string) (*entity.User, error) {
-	// r.mu.RLock()
-	// defer r.mu.RUnlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, entity.ErrUserNotFound
+}
 
-	// for _, user := range r.users {
-	// 	if user.Username == username {
-	// 		return user, nil
-	// 	}
-	// }
-	// return nil, errors.New("user not found")
-	return &entity.User{
-		ID:        "1",
-		Username:  "John Doe",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}, nil
+func (r *userRepository) FindByEmail(email string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, entity.ErrUserNotFound
 }
 
 func (r *userRepository) Save(user *entity.User) error {
@@ -58,6 +63,9 @@ func (r *userRepository) Save(user *entity.User) error {
 		user.CreatedAt = now
 	}
 	user.UpdatedAt = now
+	if user.Status == "" {
+		user.Status = entity.UserStatusActive
+	}
 
 	r.users[user.ID] = user
 	return nil
@@ -68,7 +76,7 @@ func (r *userRepository) Delete(id string) error {
 	defer r.mu.Unlock()
 
 	if _, exists := r.users[id]; !exists {
-		return errors.New("user not found")
+		return entity.ErrUserNotFound
 	}
 	delete(r.users, id)
 	return nil
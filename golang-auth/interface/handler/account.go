@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
+)
+
+// AccountHandler はメールアドレスの確認とパスワードの再設定のHTTPハンドラーを実装します
+type AccountHandler struct {
+	accountUseCase *usecase.AccountUseCase
+	authUseCase    usecase.AuthUseCase
+}
+
+// NewAccountHandler は新しいアカウントハンドラーを作成します
+func NewAccountHandler(accountUseCase *usecase.AccountUseCase, authUseCase usecase.AuthUseCase) *AccountHandler {
+	return &AccountHandler{
+		accountUseCase: accountUseCase,
+		authUseCase:    authUseCase,
+	}
+}
+
+// SendEmailVerification はログイン中のユーザーにメールアドレスの確認のリンクを送信するハンドラーです
+func (h *AccountHandler) SendEmailVerification(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	if err := h.accountUseCase.SendEmailVerification(c.Request.Context(), userID, c.GetHeader("Accept-Language")); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyEmail はメールアドレスの確認のリンクのトークンを受け取り、メールアドレスを確認済みにするハンドラーです
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountUseCase.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RequestPasswordReset はパスワードの再設定のリンクを送信するハンドラーです
+// 登録されていないメールアドレスでも同じレスポンスを返します
+func (h *AccountHandler) RequestPasswordReset(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountUseCase.RequestPasswordReset(c.Request.Context(), req.Email, c.GetHeader("Accept-Language")); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword はパスワードの再設定のリンクのトークンを受け取り、新しいパスワードに変更するハンドラーです
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"newPassword" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountUseCase.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// accountErrorStatus はアカウントのユースケースのエラーをHTTPステータスに変換します
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAccountToken), errors.Is(err, entity.ErrAccountTokenUsed):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrEmailAlreadyVerified):
+		return http.StatusConflict
+	default:
+		return userErrorStatus(err)
+	}
+}
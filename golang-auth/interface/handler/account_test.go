@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	usecasemock "github.com/yamakenji24/golang-auth/domain/usecase/mock"
+)
+
+var linkTokenPattern = regexp.MustCompile(`token=([^"&]+)`)
+
+// linkToken は最後に送信したメールのリンクからトークンを取り出します
+func linkToken(t *testing.T, mailer *usecasemock.MockMailer) string {
+	t.Helper()
+	require.NotEmpty(t, mailer.Sent)
+	m := linkTokenPattern.FindStringSubmatch(mailer.Sent[len(mailer.Sent)-1].HTMLBody)
+	require.NotNil(t, m)
+	token, err := url.QueryUnescape(m[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestEmailVerification(t *testing.T) {
+	router, mockAuthUseCase, _, mailer := setupUserTestRouter()
+	w := postJSON(router, "/api/users", gin.H{"username": "new-user", "email": "new@example.com", "password": "first-password"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	// モックの設定
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		return created["id"].(string), nil
+	}
+
+	// テスト実行
+	w = sessionRequest(router, "POST", "/api/account/email-verification", nil)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	w = postJSON(router, "/api/account/email-verification/confirm", gin.H{"token": linkToken(t, mailer)})
+
+	// アサーション
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = sessionRequest(router, "GET", "/api/users/me", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, true, response["emailVerified"])
+
+	// 使用済み・不正なトークン
+	w = postJSON(router, "/api/account/email-verification/confirm", gin.H{"token": linkToken(t, mailer)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postJSON(router, "/api/account/email-verification/confirm", gin.H{"token": "invalid"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	// 確認済みのメールアドレスには送らない
+	w = sessionRequest(router, "POST", "/api/account/email-verification", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// メールアドレスを変更すると確認のリンクを送り直す
+	sent := len(mailer.Sent)
+	w = sessionRequest(router, "PATCH", "/api/users/me", gin.H{"email": "changed@example.com"})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, false, response["emailVerified"])
+	require.Len(t, mailer.Sent, sent+1)
+	assert.Equal(t, "changed@example.com", mailer.Sent[sent].To)
+}
+
+func TestPasswordReset(t *testing.T) {
+	router, _, _, mailer := setupUserTestRouter()
+	w := postJSON(router, "/api/users", gin.H{"username": "new-user", "email": "new@example.com", "password": "first-password"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	sent := len(mailer.Sent)
+
+	// 登録されていないメールアドレスでも同じレスポンスを返す
+	w = postJSON(router, "/api/account/password-reset", gin.H{"email": "unknown@example.com"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Len(t, mailer.Sent, sent)
+
+	// テスト実行
+	w = postJSON(router, "/api/account/password-reset", gin.H{"email": "new@example.com"})
+	require.Equal(t, http.StatusAccepted, w.Code)
+	token := linkToken(t, mailer)
+
+	// アサーション
+	w = postJSON(router, "/api/account/password-reset/confirm", gin.H{"token": token, "newPassword": "short"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postJSON(router, "/api/account/password-reset/confirm", gin.H{"token": token, "newPassword": "second-password"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = postJSON(router, "/api/account/password-reset/confirm", gin.H{"token": token, "newPassword": "third-password"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,8 +1,8 @@
 package handler
 
 import (
-	"fmt"
-	"math/rand"
+	"crypto/rand"
+	"errors"
 	"net/http"
 
 	"encoding/base64"
@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/domain/usecase"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
 )
 
 type AuthHandler struct {
@@ -23,9 +24,11 @@ func NewAuthHandler(authUseCase usecase.AuthUseCase) *AuthHandler {
 }
 
 func (h *AuthHandler) Authorize(c *gin.Context) {
-	fmt.Println("Authorize")
-	url, err := h.authUseCase.GetAuthorizationURL()
+	url, err := h.authUseCase.GetAuthorizationURL(c.Request.Context())
 	if err != nil {
+		if writeAuthleteError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -40,8 +43,28 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	redirectURI, err := h.authUseCase.Login(req)
+	redirectURI, err := h.authUseCase.Login(c.Request.Context(), req)
 	if err != nil {
+		// 2要素認証を有効にしているユーザーには、続けて LoginMFA で2要素目を送ってもらう
+		var mfaErr *usecase.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_required": true,
+				"mfa_token":    mfaErr.MFAToken,
+			})
+			return
+		}
+		var invalidErr *usecase.InvalidCredentialsError
+		if errors.As(err, &invalidErr) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":        invalidErr.Error(),
+				"redirect_url": invalidErr.RedirectURL,
+			})
+			return
+		}
+		if writeAuthleteError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -51,6 +74,43 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// LoginMFA はパスワードを確認済みのログインの2要素目（TOTPのコードまたはリカバリーコード）を受け取るハンドラーです
+// 成功した場合は Login と同じリダイレクト先を返します
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req entity.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	redirectURI, err := h.authUseCase.LoginWithSecondFactor(c.Request.Context(), req)
+	if err != nil {
+		var invalidErr *usecase.InvalidCredentialsError
+		switch {
+		case errors.As(err, &invalidErr):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":        invalidErr.Error(),
+				"redirect_url": invalidErr.RedirectURL,
+			})
+		case errors.Is(err, usecase.ErrInvalidMFACode):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, entity.ErrMFAChallengeNotFound):
+			// 期限切れ・使用済みの場合はパスワードの入力からやり直してもらう
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			if writeAuthleteError(c, err) {
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"redirect_url": redirectURI,
+	})
+}
+
 func (h *AuthHandler) Callback(c *gin.Context) {
 	state := c.Query("state")
 	code := c.Query("code")
@@ -66,9 +126,11 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authUseCase.ExchangeCodeForTokens(code, authData.CodeVerifier)
-	fmt.Println("tokens: ", tokens)
+	tokens, err := h.authUseCase.ExchangeCodeForTokens(c.Request.Context(), code, authData.CodeVerifier)
 	if err != nil {
+		if writeAuthleteError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -76,14 +138,24 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	// ランダムなセッションIDを生成
 	sessionID := generateRandomSessionID()
 
-	// セッションIDとアクセストークンを紐付けて保存
-	h.authUseCase.StoreSession(sessionID, tokens.AccessToken)
+	// セッションIDとトークンを紐付けて保存
+	if err := h.authUseCase.StoreSession(&entity.Session{
+		ID:        sessionID,
+		UserID:    tokens.Subject,
+		Tokens:    tokens,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// セッションIDをCookieに設定
+	// 有効期限は最終アクセスから延長されるサーバー側のセッション（SESSION_TTL）に任せ、Cookieには付けない
 	c.SetCookie(
 		"poc-authlete",       // 名前
 		sessionID,            // 値
-		3600,                 // 有効期限（秒）
+		0,                    // 有効期限（ブラウザのセッションCookie）
 		"/",                  // パス
 		"poc-authlete.local", // ドメイン
 		false,                // Secure
@@ -102,16 +174,40 @@ func generateRandomSessionID() string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
-func (h *AuthHandler) GetSession(c *gin.Context) {
+// sessionAccessToken Cookieのセッションからアクセストークンを取得し、失敗時はレスポンスを書き込む
+func (h *AuthHandler) sessionAccessToken(c *gin.Context) (string, string, bool) {
 	sessionID, err := c.Cookie("poc-authlete")
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found"})
-		return
+		return "", "", false
 	}
 
-	accessToken, err := h.authUseCase.GetAccessToken(sessionID)
+	accessToken, err := h.authUseCase.GetAccessToken(c.Request.Context(), sessionID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+		if !h.writeSessionError(c, err) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+		}
+		return "", "", false
+	}
+	return sessionID, accessToken, true
+}
+
+// writeSessionError セッションの取得・更新時のエラーをレスポンスに変換する
+// セッションが無効な場合はfalseを返し、呼び出し元で401を返す
+func (h *AuthHandler) writeSessionError(c *gin.Context, err error) bool {
+	if errors.Is(err, usecase.ErrSessionNotFound) || errors.Is(err, usecase.ErrSessionExpired) {
+		return false
+	}
+	if writeAuthleteError(c, err) {
+		return true
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	return true
+}
+
+func (h *AuthHandler) GetSession(c *gin.Context) {
+	_, accessToken, ok := h.sessionAccessToken(c)
+	if !ok {
 		return
 	}
 
@@ -121,20 +217,28 @@ func (h *AuthHandler) GetSession(c *gin.Context) {
 }
 
 func (h *AuthHandler) GetUserInfo(c *gin.Context) {
-	sessionID, err := c.Cookie("poc-authlete")
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found"})
+	sessionID, accessToken, ok := h.sessionAccessToken(c)
+	if !ok {
 		return
 	}
 
-	accessToken, err := h.authUseCase.GetAccessToken(sessionID)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
-		return
+	userInfo, err := h.authUseCase.GetUserInfo(c.Request.Context(), accessToken)
+	var actionErr *entity.AuthleteActionError
+	if errors.As(err, &actionErr) && actionErr.Action == entity.ActionUnauthorized {
+		// 期限前に失効したアクセストークンは、リフレッシュしてから1回だけ再試行する
+		accessToken, err = h.authUseCase.RefreshSession(c.Request.Context(), sessionID, accessToken)
+		if err != nil {
+			if !h.writeSessionError(c, err) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+			}
+			return
+		}
+		userInfo, err = h.authUseCase.GetUserInfo(c.Request.Context(), accessToken)
 	}
-
-	userInfo, err := h.authUseCase.GetUserInfo(accessToken)
 	if err != nil {
+		if writeAuthleteError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -149,12 +253,17 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.authUseCase.DeleteSession(sessionID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// トークンの失効に失敗してもセッションは削除済みのため、ログアウトは完了させる
+	if err := h.authUseCase.DeleteSession(c.Request.Context(), sessionID); err != nil {
+		logger.LogWarning("failed to revoke tokens on logout: %v", err)
 	}
 
-	// Cookieを削除
+	clearSessionCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// clearSessionCookie セッションのCookieを削除する
+func clearSessionCookie(c *gin.Context) {
 	c.SetCookie(
 		"poc-authlete",
 		"",
@@ -164,6 +273,4 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		false,
 		true,
 	)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
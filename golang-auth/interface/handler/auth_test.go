@@ -3,13 +3,18 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
 	"github.com/yamakenji24/golang-auth/interface/handler/mock"
 )
 
@@ -25,11 +30,18 @@ func setupTestRouter() (*gin.Engine, *mock.MockAuthUseCase) {
 		{
 			auth.GET("/authorize", authHandler.Authorize)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/mfa", authHandler.LoginMFA)
 			auth.GET("/callback", authHandler.Callback)
 			auth.GET("/session", authHandler.GetSession)
 			auth.GET("/userinfo", authHandler.GetUserInfo)
 			auth.POST("/logout", authHandler.Logout)
 		}
+
+		oauth := api.Group("/oauth")
+		{
+			oauth.POST("/introspect", authHandler.Introspect)
+			oauth.POST("/revoke", authHandler.Revoke)
+		}
 	}
 
 	return router, mockUseCase
@@ -54,6 +66,29 @@ func TestAuthorize(t *testing.T) {
 	assert.Equal(t, expectedURL, w.Header().Get("Location"))
 }
 
+func TestAuthorizeActionError(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	errorRedirect := "https://client.example.com/cb?error=invalid_request"
+	mockUseCase.GetAuthorizationURLFunc = func() (string, error) {
+		return "", &entity.AuthleteActionError{
+			Action:          entity.ActionLocation,
+			ResponseContent: errorRedirect,
+		}
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/auth/authorize", nil)
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, errorRedirect, w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
 func TestLogin(t *testing.T) {
 	router, mockUseCase := setupTestRouter()
 
@@ -81,6 +116,94 @@ func TestLogin(t *testing.T) {
 	assert.Equal(t, expectedRedirectURL, response["redirect_url"])
 }
 
+func TestLoginInvalidCredentials(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	expectedRedirectURL := "https://poc-authlete.local/callback?error=login_required&state=test-state"
+	mockUseCase.LoginFunc = func(req entity.AuthRequest) (string, error) {
+		return "", &usecase.InvalidCredentialsError{RedirectURL: expectedRedirectURL}
+	}
+
+	// テストリクエストの作成
+	loginReq := entity.AuthRequest{
+		State:    "test-state",
+		Email:    "test@example.com",
+		Password: "wrong-password",
+	}
+	reqBody, _ := json.Marshal(loginReq)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid credentials", response["error"])
+	assert.Equal(t, expectedRedirectURL, response["redirect_url"])
+}
+
+func TestLoginMFARequired(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	mockUseCase.LoginFunc = func(req entity.AuthRequest) (string, error) {
+		return "", &usecase.MFARequiredError{MFAToken: "test-mfa-token"}
+	}
+
+	// テスト実行
+	w := postJSON(router, "/api/auth/login", entity.AuthRequest{State: "test-state", Email: "test@example.com", Password: "test-password"})
+
+	// アサーション
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"mfa_required":true,"mfa_token":"test-mfa-token"}`, w.Body.String())
+}
+
+func TestLoginMFA(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	expectedRedirectURL := "https://poc-authlete.local/callback?code=test-code&state=test-state"
+	mockUseCase.LoginWithSecondFactorFunc = func(req entity.MFALoginRequest) (string, error) {
+		switch req.Code {
+		case "123456":
+			return expectedRedirectURL, nil
+		case "expired":
+			return "", entity.ErrMFAChallengeNotFound
+		case "locked":
+			return "", &usecase.InvalidCredentialsError{RedirectURL: "https://poc-authlete.local/callback?error=login_required"}
+		default:
+			return "", usecase.ErrInvalidMFACode
+		}
+	}
+	req := entity.MFALoginRequest{State: "test-state", MFAToken: "test-mfa-token", Code: "123456"}
+
+	// テスト実行
+	w := postJSON(router, "/api/auth/login/mfa", req)
+
+	// アサーション
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"redirect_url":"`+expectedRedirectURL+`"}`, w.Body.String())
+
+	// 誤ったコード・試行回数の超過・期限切れ
+	req.Code = "000000"
+	w = postJSON(router, "/api/auth/login/mfa", req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	req.Code = "locked"
+	w = postJSON(router, "/api/auth/login/mfa", req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "redirect_url")
+	req.Code = "expired"
+	w = postJSON(router, "/api/auth/login/mfa", req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	req.Code = ""
+	w = postJSON(router, "/api/auth/login/mfa", req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestCallback(t *testing.T) {
 	router, mockUseCase := setupTestRouter()
 
@@ -97,10 +220,13 @@ func TestCallback(t *testing.T) {
 			AccessToken:  "test-access-token",
 			RefreshToken: "test-refresh-token",
 			IDToken:      "test-id-token",
+			Subject:      "test-user-id",
 		}, nil
 	}
 
-	mockUseCase.StoreSessionFunc = func(sessionID, accessToken string) error {
+	var stored *entity.Session
+	mockUseCase.StoreSessionFunc = func(session *entity.Session) error {
+		stored = session
 		return nil
 	}
 
@@ -112,6 +238,41 @@ func TestCallback(t *testing.T) {
 	// アサーション
 	assert.Equal(t, http.StatusFound, w.Code)
 	assert.Equal(t, "https://poc-authlete.local/dashboard", w.Header().Get("Location"))
+	assert.Equal(t, "test-refresh-token", stored.Tokens.RefreshToken)
+	assert.Equal(t, "test-user-id", stored.UserID)
+	assert.NotEmpty(t, stored.ID)
+
+	// Cookieの有効期限はサーバー側のセッションに任せる
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "poc-authlete", cookies[0].Name)
+	assert.Zero(t, cookies[0].MaxAge)
+	assert.True(t, cookies[0].Expires.IsZero())
+}
+
+func TestCallbackInvalidClient(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	mockUseCase.GetAuthDataFunc = func(state string) (entity.AuthData, bool) {
+		return entity.AuthData{CodeVerifier: "test-code-verifier"}, true
+	}
+	mockUseCase.ExchangeCodeForTokensFunc = func(code, codeVerifier string) (entity.Tokens, error) {
+		return entity.Tokens{}, fmt.Errorf("exchange failed: %w", &entity.AuthleteActionError{
+			Action:          entity.ActionInvalidClient,
+			ResponseContent: `{"error":"invalid_client"}`,
+		})
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/auth/callback?state=test-state&code=test-code", nil)
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"error":"invalid_client"}`, w.Body.String())
 }
 
 func TestGetUserInfo(t *testing.T) {
@@ -155,7 +316,9 @@ func TestLogout(t *testing.T) {
 	router, mockUseCase := setupTestRouter()
 
 	// モックの設定
+	var deleted string
 	mockUseCase.DeleteSessionFunc = func(sessionID string) error {
+		deleted = sessionID
 		return nil
 	}
 
@@ -174,6 +337,7 @@ func TestLogout(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
 	assert.Equal(t, "Logged out", response["message"])
+	assert.Equal(t, "test-session-id", deleted)
 
 	// Cookieが削除されていることを確認
 	cookies := w.Result().Cookies()
@@ -186,3 +350,176 @@ func TestLogout(t *testing.T) {
 	}
 	assert.True(t, found)
 }
+
+func TestIntrospect(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	mockUseCase.IntrospectStandardFunc = func(clientID, clientSecret string, params map[string]string) (string, error) {
+		assert.Equal(t, "resource-server", clientID)
+		assert.Equal(t, "rs-secret", clientSecret)
+		assert.Equal(t, "test-access-token", params["token"])
+		assert.Equal(t, "access_token", params["token_type_hint"])
+		return `{"active":true,"sub":"test-user-id"}`, nil
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	form := url.Values{"token": {"test-access-token"}, "token_type_hint": {"access_token"}}
+	req, _ := http.NewRequest("POST", "/api/oauth/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("resource-server", "rs-secret")
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"active":true,"sub":"test-user-id"}`, w.Body.String())
+}
+
+func TestIntrospectInvalidClient(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	mockUseCase.IntrospectStandardFunc = func(clientID, clientSecret string, params map[string]string) (string, error) {
+		return "", usecase.ErrInvalidClient
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	form := url.Values{"token": {"test-access-token"}}
+	req, _ := http.NewRequest("POST", "/api/oauth/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("resource-server", "wrong-secret")
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid_client", response["error"])
+}
+
+func TestRevoke(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	var revoked entity.RevocationRequest
+	mockUseCase.RevokeTokenFunc = func(req entity.RevocationRequest) error {
+		revoked = req
+		return nil
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	form := url.Values{"token": {"test-refresh-token"}, "token_type_hint": {"refresh_token"}}
+	req, _ := http.NewRequest("POST", "/api/oauth/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("test-client", "test-secret")
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "test-refresh-token", revoked.Token)
+	assert.Equal(t, "refresh_token", revoked.TokenTypeHint)
+	assert.Equal(t, "test-client", revoked.ClientID)
+	assert.Equal(t, "test-secret", revoked.ClientSecret)
+}
+
+func TestRevokeInvalidClient(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	mockUseCase.RevokeTokenFunc = func(req entity.RevocationRequest) error {
+		return &entity.AuthleteActionError{
+			API:             "/auth/revocation",
+			Action:          entity.ActionInvalidClient,
+			ResponseContent: `{"error":"invalid_client"}`,
+		}
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	form := url.Values{"token": {"test-refresh-token"}}
+	req, _ := http.NewRequest("POST", "/api/oauth/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("test-client", "wrong-secret")
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"invalid_client"}`, w.Body.String())
+}
+
+func TestRevokeWithoutClientCredentials(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	called := false
+	mockUseCase.RevokeTokenFunc = func(req entity.RevocationRequest) error {
+		called = true
+		return nil
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	form := url.Values{"token": {"test-refresh-token"}}
+	req, _ := http.NewRequest("POST", "/api/oauth/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_client")
+	assert.False(t, called)
+}
+
+func TestGetUserInfoRefreshesRevokedToken(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	mockUseCase.GetAccessTokenFunc = func(sessionID string) (string, error) {
+		return "old-access-token", nil
+	}
+	mockUseCase.RefreshSessionFunc = func(sessionID, staleAccessToken string) (string, error) {
+		assert.Equal(t, "test-session-id", sessionID)
+		assert.Equal(t, "old-access-token", staleAccessToken)
+		return "new-access-token", nil
+	}
+	mockUseCase.GetUserInfoFunc = func(accessToken string) (entity.UserInfo, error) {
+		if accessToken != "new-access-token" {
+			return entity.UserInfo{}, &entity.AuthleteActionError{API: "/auth/userinfo", Action: entity.ActionUnauthorized}
+		}
+		return entity.UserInfo{Sub: "test-sub"}, nil
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/auth/userinfo", nil)
+	req.AddCookie(&http.Cookie{Name: "poc-authlete", Value: "test-session-id"})
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "test-sub")
+}
+
+func TestGetUserInfoSessionExpired(t *testing.T) {
+	router, mockUseCase := setupTestRouter()
+
+	// モックの設定
+	mockUseCase.GetAccessTokenFunc = func(sessionID string) (string, error) {
+		return "", usecase.ErrSessionExpired
+	}
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/auth/userinfo", nil)
+	req.AddCookie(&http.Cookie{Name: "poc-authlete", Value: "test-session-id"})
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// statusClientClosedRequest はブラウザが応答を待たずに切断したことを表すステータスです（nginx互換）
+const statusClientClosedRequest = 499
+
+// writeAuthleteError はAuthleteが返したactionに応じてHTTPステータス、ヘッダー、ボディを書き込みます
+// Authlete呼び出しの期限切れ・キャンセル・障害による停止もここで扱い、それ以外のエラーの場合は何も書き込まずに false を返します
+func writeAuthleteError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "authorization server timed out"})
+		return true
+	case errors.Is(err, context.Canceled):
+		c.AbortWithStatus(statusClientClosedRequest)
+		return true
+	case errors.Is(err, entity.ErrAuthleteUnavailable):
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": entity.ErrAuthleteUnavailable.Error()})
+		return true
+	}
+
+	var actionErr *entity.AuthleteActionError
+	if !errors.As(err, &actionErr) {
+		return false
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	content := actionErr.ResponseContent
+	switch actionErr.Action {
+	case entity.ActionLocation:
+		c.Redirect(http.StatusFound, content)
+	case entity.ActionForm:
+		c.Data(http.StatusOK, "text/html; charset=UTF-8", []byte(content))
+	case entity.ActionBadRequest:
+		writeJSONContent(c, http.StatusBadRequest, content)
+	case entity.ActionInvalidClient:
+		c.Header("WWW-Authenticate", `Basic realm="token"`)
+		writeJSONContent(c, http.StatusUnauthorized, content)
+	case entity.ActionUnauthorized:
+		// userinfo等ではresponseContentがWWW-Authenticateヘッダーの値になる
+		c.Header("WWW-Authenticate", content)
+		c.Status(http.StatusUnauthorized)
+	case entity.ActionForbidden:
+		c.Header("WWW-Authenticate", content)
+		c.Status(http.StatusForbidden)
+	default:
+		writeJSONContent(c, http.StatusInternalServerError, content)
+	}
+	return true
+}
+
+// writeJSONContent はAuthleteが生成したJSONをそのままレスポンスとして返します
+func writeJSONContent(c *gin.Context, status int, content string) {
+	if content == "" {
+		c.JSON(status, gin.H{"error": http.StatusText(status)})
+		return
+	}
+	c.Data(status, "application/json; charset=UTF-8", []byte(content))
+}
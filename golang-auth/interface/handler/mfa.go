@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
+)
+
+// MFAHandler はログイン中のユーザーのTOTPとリカバリーコードを管理するHTTPハンドラーを実装します
+type MFAHandler struct {
+	mfaUseCase  *usecase.MFAUseCase
+	authUseCase usecase.AuthUseCase
+}
+
+// NewMFAHandler は新しい2要素認証ハンドラーを作成します
+func NewMFAHandler(mfaUseCase *usecase.MFAUseCase, authUseCase usecase.AuthUseCase) *MFAHandler {
+	return &MFAHandler{
+		mfaUseCase:  mfaUseCase,
+		authUseCase: authUseCase,
+	}
+}
+
+// mfaCodeRequest はTOTPのコードまたはリカバリーコードを受け取るリクエストです
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// GetStatus は2要素認証の状態を返すハンドラーです
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	status, err := h.mfaUseCase.Status(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"totpEnabled":            status.TOTPEnabled,
+		"recoveryCodesRemaining": status.RecoveryCodesRemaining,
+	})
+}
+
+// StartTOTPEnrollment はTOTPの登録を始め、認証アプリに登録する秘密鍵・URI・QRコードを返すハンドラーです
+func (h *MFAHandler) StartTOTPEnrollment(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfaUseCase.StartTOTPEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret": enrollment.Secret,
+		"uri":    enrollment.URI,
+		"qrCode": "data:image/png;base64," + base64.StdEncoding.EncodeToString(enrollment.QRCode),
+	})
+}
+
+// ConfirmTOTPEnrollment は認証アプリのコードでTOTPの登録を完了し、リカバリーコードを返すハンドラーです
+func (h *MFAHandler) ConfirmTOTPEnrollment(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.mfaUseCase.ConfirmTOTPEnrollment(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// DisableTOTP はTOTPのコードまたはリカバリーコードを確認してTOTPを無効にするハンドラーです
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.mfaUseCase.DisableTOTP(c.Request.Context(), userID, req.Code); err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes はTOTPのコードまたはリカバリーコードを確認してリカバリーコードを発行し直すハンドラーです
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.mfaUseCase.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// mfaErrorStatus は2要素認証のユースケースのエラーをHTTPステータスに変換します
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidMFACode):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrTOTPAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrTOTPNotEnabled), errors.Is(err, entity.ErrTOTPNotFound):
+		return http.StatusNotFound
+	default:
+		return userErrorStatus(err)
+	}
+}
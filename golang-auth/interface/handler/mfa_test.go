@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
+	usecasemock "github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/interface/handler/mock"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/totp"
+)
+
+func setupMFATestRouter() (*gin.Engine, *mock.MockAuthUseCase) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	userRepo := usecasemock.NewMockUserRepository()
+	userRepo.Save(&entity.User{ID: "test-user-id", Username: "test-user", Email: "test@example.com"})
+	mockAuthUseCase := mock.NewMockAuthUseCase()
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		return "test-user-id", nil
+	}
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, usecasemock.NewMockTOTPRepository(), config.MFAConfig{
+		TOTPIssuer:   "Passkey Demo",
+		TOTPSkew:     1,
+		ChallengeTTL: 5 * time.Minute,
+		MaxAttempts:  5,
+	})
+	mfaHandler := NewMFAHandler(mfaUseCase, mockAuthUseCase)
+
+	mfa := router.Group("/api/mfa")
+	{
+		mfa.GET("", mfaHandler.GetStatus)
+		mfa.POST("/totp", mfaHandler.StartTOTPEnrollment)
+		mfa.POST("/totp/confirm", mfaHandler.ConfirmTOTPEnrollment)
+		mfa.DELETE("/totp", mfaHandler.DisableTOTP)
+		mfa.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+	}
+
+	return router, mockAuthUseCase
+}
+
+func TestTOTPEnrollmentAPI(t *testing.T) {
+	router, _ := setupMFATestRouter()
+
+	// テスト実行
+	w := sessionRequest(router, "POST", "/api/mfa/totp", nil)
+
+	// アサーション
+	require.Equal(t, http.StatusOK, w.Code)
+	var enrollment map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollment))
+	assert.True(t, strings.HasPrefix(enrollment["uri"], "otpauth://totp/"))
+	assert.True(t, strings.HasPrefix(enrollment["qrCode"], "data:image/png;base64,"))
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment["secret"])
+	require.NoError(t, err)
+
+	// 誤ったコードでは登録を完了しない
+	w = sessionRequest(router, "POST", "/api/mfa/totp/confirm", gin.H{"code": "000000"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// テスト実行
+	w = sessionRequest(router, "POST", "/api/mfa/totp/confirm", gin.H{"code": totp.Code(secret, totp.Step(time.Now()))})
+
+	// アサーション
+	require.Equal(t, http.StatusOK, w.Code)
+	var confirmed map[string][]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &confirmed))
+	require.Len(t, confirmed["recoveryCodes"], 10)
+
+	w = sessionRequest(router, "GET", "/api/mfa", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"totpEnabled":true,"recoveryCodesRemaining":10}`, w.Body.String())
+	w = sessionRequest(router, "POST", "/api/mfa/totp", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// リカバリーコードを発行し直すと以前のコードは使えない
+	w = sessionRequest(router, "POST", "/api/mfa/recovery-codes", gin.H{"code": confirmed["recoveryCodes"][0]})
+	require.Equal(t, http.StatusOK, w.Code)
+	var regenerated map[string][]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &regenerated))
+	w = sessionRequest(router, "DELETE", "/api/mfa/totp", gin.H{"code": confirmed["recoveryCodes"][1]})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// テスト実行
+	w = sessionRequest(router, "DELETE", "/api/mfa/totp", gin.H{"code": regenerated["recoveryCodes"][0]})
+
+	// アサーション
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = sessionRequest(router, "GET", "/api/mfa", nil)
+	assert.JSONEq(t, `{"totpEnabled":false,"recoveryCodesRemaining":0}`, w.Body.String())
+}
+
+func TestMFAAPIRequiresSession(t *testing.T) {
+	router, mockAuthUseCase := setupMFATestRouter()
+	mockAuthUseCase.GetSessionUserIDFunc = nil
+
+	// テスト実行
+	w := sessionRequest(router, "POST", "/api/mfa/totp", nil)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
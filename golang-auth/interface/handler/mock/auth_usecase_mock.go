@@ -1,38 +1,63 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
 )
 
 type MockAuthUseCase struct {
 	GetAuthorizationURLFunc   func() (string, error)
 	LoginFunc                 func(req entity.AuthRequest) (string, error)
+	LoginWithPasskeyFunc      func(state string, user *entity.User) (string, error)
+	LoginWithSecondFactorFunc func(req entity.MFALoginRequest) (string, error)
 	GetAuthDataFunc           func(state string) (entity.AuthData, bool)
 	ExchangeCodeForTokensFunc func(code, codeVerifier string) (entity.Tokens, error)
-	StoreSessionFunc          func(sessionID, accessToken string) error
+	StoreSessionFunc          func(session *entity.Session) error
 	GetAccessTokenFunc        func(sessionID string) (string, error)
+	GetSessionUserIDFunc      func(sessionID string) (string, error)
 	GetUserInfoFunc           func(accessToken string) (entity.UserInfo, error)
 	DeleteSessionFunc         func(sessionID string) error
+	DeleteUserSessionsFunc    func(userID string) error
+	IntrospectTokenFunc       func(token string, scopes []string, subject string) (entity.TokenIntrospection, error)
+	IntrospectStandardFunc    func(clientID, clientSecret string, params map[string]string) (string, error)
+	RevokeTokenFunc           func(req entity.RevocationRequest) error
+	RefreshSessionFunc        func(sessionID, staleAccessToken string) (string, error)
 }
 
 func NewMockAuthUseCase() *MockAuthUseCase {
 	return &MockAuthUseCase{}
 }
 
-func (m *MockAuthUseCase) GetAuthorizationURL() (string, error) {
+func (m *MockAuthUseCase) GetAuthorizationURL(ctx context.Context) (string, error) {
 	if m.GetAuthorizationURLFunc != nil {
 		return m.GetAuthorizationURLFunc()
 	}
 	return "", nil
 }
 
-func (m *MockAuthUseCase) Login(req entity.AuthRequest) (string, error) {
+func (m *MockAuthUseCase) Login(ctx context.Context, req entity.AuthRequest) (string, error) {
 	if m.LoginFunc != nil {
 		return m.LoginFunc(req)
 	}
 	return "", nil
 }
 
+func (m *MockAuthUseCase) LoginWithPasskey(ctx context.Context, state string, user *entity.User) (string, error) {
+	if m.LoginWithPasskeyFunc != nil {
+		return m.LoginWithPasskeyFunc(state, user)
+	}
+	return "", nil
+}
+
+func (m *MockAuthUseCase) LoginWithSecondFactor(ctx context.Context, req entity.MFALoginRequest) (string, error) {
+	if m.LoginWithSecondFactorFunc != nil {
+		return m.LoginWithSecondFactorFunc(req)
+	}
+	return "", nil
+}
+
 func (m *MockAuthUseCase) GetAuthData(state string) (entity.AuthData, bool) {
 	if m.GetAuthDataFunc != nil {
 		return m.GetAuthDataFunc(state)
@@ -40,37 +65,79 @@ func (m *MockAuthUseCase) GetAuthData(state string) (entity.AuthData, bool) {
 	return entity.AuthData{}, false
 }
 
-func (m *MockAuthUseCase) ExchangeCodeForTokens(code, codeVerifier string) (entity.Tokens, error) {
+func (m *MockAuthUseCase) ExchangeCodeForTokens(ctx context.Context, code, codeVerifier string) (entity.Tokens, error) {
 	if m.ExchangeCodeForTokensFunc != nil {
 		return m.ExchangeCodeForTokensFunc(code, codeVerifier)
 	}
 	return entity.Tokens{}, nil
 }
 
-func (m *MockAuthUseCase) StoreSession(sessionID, accessToken string) error {
+func (m *MockAuthUseCase) StoreSession(session *entity.Session) error {
 	if m.StoreSessionFunc != nil {
-		return m.StoreSessionFunc(sessionID, accessToken)
+		return m.StoreSessionFunc(session)
 	}
 	return nil
 }
 
-func (m *MockAuthUseCase) GetAccessToken(sessionID string) (string, error) {
+func (m *MockAuthUseCase) GetAccessToken(ctx context.Context, sessionID string) (string, error) {
 	if m.GetAccessTokenFunc != nil {
 		return m.GetAccessTokenFunc(sessionID)
 	}
 	return "", nil
 }
 
-func (m *MockAuthUseCase) GetUserInfo(accessToken string) (entity.UserInfo, error) {
+func (m *MockAuthUseCase) GetSessionUserID(ctx context.Context, sessionID string) (string, error) {
+	if m.GetSessionUserIDFunc != nil {
+		return m.GetSessionUserIDFunc(sessionID)
+	}
+	return "", usecase.ErrSessionNotFound
+}
+
+func (m *MockAuthUseCase) GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error) {
 	if m.GetUserInfoFunc != nil {
 		return m.GetUserInfoFunc(accessToken)
 	}
 	return entity.UserInfo{}, nil
 }
 
-func (m *MockAuthUseCase) DeleteSession(sessionID string) error {
+func (m *MockAuthUseCase) DeleteSession(ctx context.Context, sessionID string) error {
 	if m.DeleteSessionFunc != nil {
 		return m.DeleteSessionFunc(sessionID)
 	}
 	return nil
 }
+
+func (m *MockAuthUseCase) DeleteUserSessions(ctx context.Context, userID string) error {
+	if m.DeleteUserSessionsFunc != nil {
+		return m.DeleteUserSessionsFunc(userID)
+	}
+	return nil
+}
+
+func (m *MockAuthUseCase) IntrospectToken(ctx context.Context, token string, scopes []string, subject string) (entity.TokenIntrospection, error) {
+	if m.IntrospectTokenFunc != nil {
+		return m.IntrospectTokenFunc(token, scopes, subject)
+	}
+	return entity.TokenIntrospection{}, nil
+}
+
+func (m *MockAuthUseCase) IntrospectStandard(ctx context.Context, clientID, clientSecret string, params map[string]string) (string, error) {
+	if m.IntrospectStandardFunc != nil {
+		return m.IntrospectStandardFunc(clientID, clientSecret, params)
+	}
+	return "", nil
+}
+
+func (m *MockAuthUseCase) RevokeToken(ctx context.Context, req entity.RevocationRequest) error {
+	if m.RevokeTokenFunc != nil {
+		return m.RevokeTokenFunc(req)
+	}
+	return nil
+}
+
+func (m *MockAuthUseCase) RefreshSession(ctx context.Context, sessionID, staleAccessToken string) (string, error) {
+	if m.RefreshSessionFunc != nil {
+		return m.RefreshSessionFunc(sessionID, staleAccessToken)
+	}
+	return "", usecase.ErrSessionExpired
+}
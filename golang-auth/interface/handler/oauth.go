@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
+)
+
+// clientCredentials はHTTP Basic認証またはフォームパラメータからクライアントの認証情報を取り出します
+func clientCredentials(c *gin.Context) (string, string) {
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		return clientID, clientSecret
+	}
+	return c.PostForm("client_id"), c.PostForm("client_secret")
+}
+
+// writeOAuthError はRFC 6749形式のエラーレスポンスを書き込みます
+func writeOAuthError(c *gin.Context, status int, code, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// Introspect はRFC 7662のトークンイントロスペクションエンドポイントです
+// リソースサーバーはクライアント認証を行った上で、アクセストークンの状態を問い合わせます
+func (h *AuthHandler) Introspect(c *gin.Context) {
+	clientID, clientSecret := clientCredentials(c)
+	token := c.PostForm("token")
+	if token == "" {
+		writeOAuthError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	params := map[string]string{"token": token}
+	if hint := c.PostForm("token_type_hint"); hint != "" {
+		params["token_type_hint"] = hint
+	}
+
+	content, err := h.authUseCase.IntrospectStandard(c.Request.Context(), clientID, clientSecret, params)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidClient) {
+			writeOAuthError(c, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+			return
+		}
+		if writeAuthleteError(c, err) {
+			return
+		}
+		writeOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.Data(http.StatusOK, "application/json; charset=UTF-8", []byte(content))
+}
+
+// Revoke はRFC 7009のトークン失効エンドポイントです
+// クライアント認証はAuthleteに委ね、結果のアクションをそのままHTTPレスポンスに変換します
+func (h *AuthHandler) Revoke(c *gin.Context) {
+	clientID, clientSecret := clientCredentials(c)
+	if clientID == "" {
+		writeOAuthError(c, http.StatusUnauthorized, "invalid_client", "client authentication is required")
+		return
+	}
+	token := c.PostForm("token")
+	if token == "" {
+		writeOAuthError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	err := h.authUseCase.RevokeToken(c.Request.Context(), entity.RevocationRequest{
+		Token:         token,
+		TokenTypeHint: c.PostForm("token_type_hint"),
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+	})
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidClient) {
+			writeOAuthError(c, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+			return
+		}
+		if writeAuthleteError(c, err) {
+			return
+		}
+		writeOAuthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	// RFC 7009 2.2: 無効なトークンであっても200を返す
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.Status(http.StatusOK)
+}
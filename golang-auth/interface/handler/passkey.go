@@ -1,40 +1,41 @@
 package handler
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yamakenji24/golang-auth/domain/entity"
 	"github.com/yamakenji24/golang-auth/domain/usecase"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
 )
 
 // PasskeyHandler はパスキー認証のHTTPハンドラーを実装します
 type PasskeyHandler struct {
 	passkeyUseCase *usecase.PasskeyUseCase
+	authUseCase    usecase.AuthUseCase
 }
 
 // NewPasskeyHandler は新しいパスキーハンドラーを作成します
-func NewPasskeyHandler(passkeyUseCase *usecase.PasskeyUseCase) *PasskeyHandler {
+func NewPasskeyHandler(passkeyUseCase *usecase.PasskeyUseCase, authUseCase usecase.AuthUseCase) *PasskeyHandler {
 	return &PasskeyHandler{
 		passkeyUseCase: passkeyUseCase,
+		authUseCase:    authUseCase,
 	}
 }
 
-// StartRegistration はパスキー登録を開始するハンドラーです
+// StartRegistration はログイン中のユーザーのパスキー登録を開始するハンドラーです
+// 登録するユーザーはリクエストではなくセッションから決めます
 func (h *PasskeyHandler) StartRegistration(c *gin.Context) {
-	var req struct {
-		Username string `json:"username"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
 		return
 	}
 
-	fmt.Println("StartRegistration: ", req.Username)
-
-	options, err := h.passkeyUseCase.StartRegistration(req.Username)
+	options, err := h.passkeyUseCase.StartRegistration(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(passkeyErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -43,19 +44,22 @@ func (h *PasskeyHandler) StartRegistration(c *gin.Context) {
 
 // CompleteRegistration はパスキー登録を完了するハンドラーです
 func (h *PasskeyHandler) CompleteRegistration(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
 	var req struct {
-		UserID          string `json:"userId"`
-		CredentialID    string `json:"credentialId"`
-		PublicKey       string `json:"publicKey"`
-		AttestationType string `json:"attestationType"`
+		CeremonyID string                          `json:"ceremonyId" binding:"required"`
+		Credential webauthn.RegistrationCredential `json:"credential"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.passkeyUseCase.CompleteRegistration(req.UserID, req.CredentialID, req.PublicKey, req.AttestationType); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.passkeyUseCase.CompleteRegistration(c.Request.Context(), userID, req.CeremonyID, req.Credential); err != nil {
+		c.JSON(passkeyErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -72,29 +76,188 @@ func (h *PasskeyHandler) StartAuthentication(c *gin.Context) {
 		return
 	}
 
-	options, err := h.passkeyUseCase.StartAuthentication(req.Username)
+	options, err := h.passkeyUseCase.StartAuthentication(c.Request.Context(), req.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(passkeyErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, options)
 }
 
-// CompleteAuthentication はパスキー認証を完了するハンドラーです
+// CompleteAuthentication はパスキー認証を完了し、認可リクエストを許可するハンドラーです
+// state は GetAuthorizationURL で発行したもので、パスワードの Login と同じリダイレクト先を返します
 func (h *PasskeyHandler) CompleteAuthentication(c *gin.Context) {
 	var req struct {
-		CredentialID string `json:"credentialId"`
+		State      string                            `json:"state" binding:"required"`
+		CeremonyID string                            `json:"ceremonyId" binding:"required"`
+		Credential webauthn.AuthenticationCredential `json:"credential"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.passkeyUseCase.CompleteAuthentication(req.CredentialID); err != nil {
+	user, err := h.passkeyUseCase.CompleteAuthentication(c.Request.Context(), req.CeremonyID, req.Credential)
+	if err != nil {
+		c.JSON(passkeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	redirectURI, err := h.authUseCase.LoginWithPasskey(c.Request.Context(), req.State, user)
+	if err != nil {
+		if writeAuthleteError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"redirect_url": redirectURI,
+	})
+}
+
+// WellKnown はRelated Origin Requestsのためのオリジン一覧を返すハンドラーです
+func (h *PasskeyHandler) WellKnown(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"origins": h.passkeyUseCase.WebAuthnOrigins()})
+}
+
+// passkeyCredentialResponse はパスキー一覧の1件分のレスポンスです
+type passkeyCredentialResponse struct {
+	ID                string     `json:"id"`
+	Nickname          string     `json:"nickname"`
+	AuthenticatorName string     `json:"authenticatorName"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	LastUsedAt        *time.Time `json:"lastUsedAt"`
+	BackupEligible    bool       `json:"backupEligible"`
+	BackupState       bool       `json:"backupState"`
+	Transports        []string   `json:"transports"`
+}
+
+// sessionUserID Cookieのセッションからログイン中のユーザーIDを取得し、失敗時はレスポンスを書き込む
+func sessionUserID(c *gin.Context, authUseCase usecase.AuthUseCase) (string, bool) {
+	sessionID, err := c.Cookie("poc-authlete")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found"})
+		return "", false
+	}
+
+	userID, err := authUseCase.GetSessionUserID(c.Request.Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrSessionNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+			return "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return userID, true
+}
+
+// ListCredentials はログイン中のユーザーのパスキー一覧を返すハンドラーです
+func (h *PasskeyHandler) ListCredentials(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.passkeyUseCase.ListCredentials(c.Request.Context(), userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	res := make([]passkeyCredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		item := passkeyCredentialResponse{
+			ID:                credential.ID,
+			Nickname:          credential.Nickname,
+			AuthenticatorName: credential.AuthenticatorName,
+			CreatedAt:         credential.CreatedAt,
+			BackupEligible:    credential.BackupEligible,
+			BackupState:       credential.BackupState,
+			Transports:        credential.Transports,
+		}
+		if !credential.LastUsedAt.IsZero() {
+			lastUsedAt := credential.LastUsedAt
+			item.LastUsedAt = &lastUsedAt
+		}
+		if item.Transports == nil {
+			item.Transports = []string{}
+		}
+		res = append(res, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": res})
+}
+
+// RenameCredential はパスキーの表示名を変更するハンドラーです
+func (h *PasskeyHandler) RenameCredential(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Nickname string `json:"nickname"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.passkeyUseCase.RenameCredential(c.Request.Context(), userID, c.Param("id"), req.Nickname); err != nil {
+		c.JSON(credentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
+
+// DeleteCredential はパスキーを削除するハンドラーです
+func (h *PasskeyHandler) DeleteCredential(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	if err := h.passkeyUseCase.DeleteCredential(c.Request.Context(), userID, c.Param("id")); err != nil {
+		c.JSON(credentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// credentialErrorStatus はパスキー管理のエラーをHTTPステータスに変換します
+func credentialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidNickname):
+		return http.StatusBadRequest
+	case errors.Is(err, entity.ErrCredentialNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// passkeyErrorStatus はパスキーのユースケースのエラーをHTTPステータスに変換します
+func passkeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, webauthn.ErrVerification),
+		errors.Is(err, usecase.ErrRegistrationNotStarted),
+		errors.Is(err, usecase.ErrAuthenticationNotStarted):
+		return http.StatusBadRequest
+	case errors.Is(err, entity.ErrCredentialNotFound), errors.Is(err, entity.ErrSignCountNotIncreased):
+		return http.StatusUnauthorized
+	case errors.Is(err, usecase.ErrNoPasskeys):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrCredentialAlreadyRegistered):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrAttestationRejected), errors.Is(err, usecase.ErrAccountInactive):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
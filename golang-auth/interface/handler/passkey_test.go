@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
+	usecasemock "github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/interface/handler/mock"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/webauthntest"
+)
+
+func setupPasskeyTestRouter() (*gin.Engine, *mock.MockAuthUseCase) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	userRepo := usecasemock.NewMockUserRepository()
+	userRepo.Users["test-user-id"] = &entity.User{ID: "test-user-id", Username: "test-user"}
+	passkeyUseCase := usecase.NewPasskeyUseCase(usecasemock.NewMockPasskeyRepository(), userRepo, usecasemock.NewMockCeremonyRepository(), config.WebAuthnConfig{
+		RPID:                  "poc-authlete.local",
+		RPName:                "Passkey Demo",
+		Origins:               []string{"https://poc-authlete.local"},
+		RegistrationTimeout:   5 * time.Minute,
+		AuthenticationTimeout: 5 * time.Minute,
+		Algorithms:            []int{webauthn.AlgES256},
+		Attestation:           "none",
+		UserVerification:      entity.UserVerificationPreferred,
+		ResidentKey:           entity.ResidentKeyRequired,
+	}, nil)
+	mockAuthUseCase := mock.NewMockAuthUseCase()
+	passkeyHandler := NewPasskeyHandler(passkeyUseCase, mockAuthUseCase)
+
+	passkey := router.Group("/api/passkey")
+	{
+		passkey.POST("/register/start", passkeyHandler.StartRegistration)
+		passkey.POST("/register/complete", passkeyHandler.CompleteRegistration)
+		passkey.POST("/authenticate/start", passkeyHandler.StartAuthentication)
+		passkey.POST("/authenticate/complete", passkeyHandler.CompleteAuthentication)
+		passkey.GET("/credentials", passkeyHandler.ListCredentials)
+		passkey.PATCH("/credentials/:id", passkeyHandler.RenameCredential)
+		passkey.DELETE("/credentials/:id", passkeyHandler.DeleteCredential)
+	}
+
+	return router, mockAuthUseCase
+}
+
+// postJSON はJSONのリクエストを送信し、レスポンスを返します
+func postJSON(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// registerTestPasskey はログイン中の test-user として仮想認証器のパスキーをAPI経由で登録し、クレデンシャルIDを返します
+func registerTestPasskey(t *testing.T, router *gin.Engine, mockAuthUseCase *mock.MockAuthUseCase, authenticator *webauthntest.Authenticator) []byte {
+	t.Helper()
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		return "test-user-id", nil
+	}
+	w := sessionRequest(router, "POST", "/api/passkey/register/start", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var options entity.WebAuthnRegistrationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &options))
+
+	challenge, _ := webauthn.DecodeBase64(options.PublicKey.Challenge)
+	userHandle, _ := webauthn.DecodeBase64(options.PublicKey.User.ID)
+	credential, err := authenticator.Create(challenge, userHandle)
+	require.NoError(t, err)
+
+	w = sessionRequest(router, "POST", "/api/passkey/register/complete", gin.H{"ceremonyId": options.CeremonyID, "credential": credential})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	return credential.RawID
+}
+
+// startTestAuthentication は認証を開始し、仮想認証器の認証レスポンスとセレモニーIDを返します
+func startTestAuthentication(t *testing.T, router *gin.Engine, authenticator *webauthntest.Authenticator, credentialID []byte) (string, webauthn.AuthenticationCredential) {
+	t.Helper()
+	w := postJSON(router, "/api/passkey/authenticate/start", gin.H{"username": "test-user"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var options entity.WebAuthnAuthenticationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &options))
+
+	challenge, _ := webauthn.DecodeBase64(options.PublicKey.Challenge)
+	assertion, err := authenticator.Get(credentialID, challenge)
+	require.NoError(t, err)
+	return options.CeremonyID, assertion
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	router, mockAuthUseCase := setupPasskeyTestRouter()
+	authenticator := webauthntest.NewAuthenticator("poc-authlete.local", "https://poc-authlete.local")
+	credentialID := registerTestPasskey(t, router, mockAuthUseCase, authenticator)
+	ceremonyID, assertion := startTestAuthentication(t, router, authenticator, credentialID)
+
+	// モックの設定
+	expectedRedirectURL := "https://poc-authlete.local/callback?code=test-code&state=test-state"
+	var loggedInUser *entity.User
+	mockAuthUseCase.LoginWithPasskeyFunc = func(state string, user *entity.User) (string, error) {
+		assert.Equal(t, "test-state", state)
+		loggedInUser = user
+		return expectedRedirectURL, nil
+	}
+
+	// テストリクエストの作成
+	w := postJSON(router, "/api/passkey/authenticate/complete", gin.H{
+		"state":      "test-state",
+		"ceremonyId": ceremonyID,
+		"credential": assertion,
+	})
+
+	// アサーション
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, expectedRedirectURL, response["redirect_url"])
+	require.NotNil(t, loggedInUser)
+	assert.Equal(t, "test-user-id", loggedInUser.ID)
+}
+
+func TestPasskeyLoginInvalidSignature(t *testing.T) {
+	router, mockAuthUseCase := setupPasskeyTestRouter()
+	authenticator := webauthntest.NewAuthenticator("poc-authlete.local", "https://poc-authlete.local")
+	credentialID := registerTestPasskey(t, router, mockAuthUseCase, authenticator)
+	ceremonyID, assertion := startTestAuthentication(t, router, authenticator, credentialID)
+	assertion.Response.Signature[len(assertion.Response.Signature)-1] ^= 0xff
+
+	// モックの設定
+	mockAuthUseCase.LoginWithPasskeyFunc = func(state string, user *entity.User) (string, error) {
+		t.Fatal("authorization must not be issued for an invalid assertion")
+		return "", nil
+	}
+
+	// テストリクエストの作成
+	w := postJSON(router, "/api/passkey/authenticate/complete", gin.H{
+		"state":      "test-state",
+		"ceremonyId": ceremonyID,
+		"credential": assertion,
+	})
+
+	// アサーション
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// sessionRequest はセッションCookieを付けたリクエストを送信し、レスポンスを返します
+func sessionRequest(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reqBody []byte
+	if body != nil {
+		reqBody, _ = json.Marshal(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "poc-authlete", Value: "test-session-id"})
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPasskeyCredentialManagement(t *testing.T) {
+	router, mockAuthUseCase := setupPasskeyTestRouter()
+	authenticator := webauthntest.NewAuthenticator("poc-authlete.local", "https://poc-authlete.local")
+	credentialID := webauthn.EncodeBase64(registerTestPasskey(t, router, mockAuthUseCase, authenticator))
+
+	// モックの設定
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		assert.Equal(t, "test-session-id", sessionID)
+		return "test-user-id", nil
+	}
+
+	// 表示名の変更
+	w := sessionRequest(router, "PATCH", "/api/passkey/credentials/"+credentialID, gin.H{"nickname": "MacBook"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sessionRequest(router, "PATCH", "/api/passkey/credentials/"+credentialID, gin.H{"nickname": ""})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 一覧の取得
+	w = sessionRequest(router, "GET", "/api/passkey/credentials", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Credentials []struct {
+			ID         string  `json:"id"`
+			Nickname   string  `json:"nickname"`
+			LastUsedAt *string `json:"lastUsedAt"`
+		} `json:"credentials"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Credentials, 1)
+	assert.Equal(t, credentialID, response.Credentials[0].ID)
+	assert.Equal(t, "MacBook", response.Credentials[0].Nickname)
+	assert.Nil(t, response.Credentials[0].LastUsedAt)
+
+	// 削除
+	w = sessionRequest(router, "DELETE", "/api/passkey/credentials/"+credentialID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = sessionRequest(router, "DELETE", "/api/passkey/credentials/"+credentialID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPasskeyCredentialManagementRequiresSession(t *testing.T) {
+	router, _ := setupPasskeyTestRouter()
+
+	// テストリクエストの作成
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/passkey/credentials", nil)
+	router.ServeHTTP(w, req)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 無効なセッション
+	w = sessionRequest(router, "GET", "/api/passkey/credentials", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPasskeyRegistrationRequiresSession(t *testing.T) {
+	router, mockAuthUseCase := setupPasskeyTestRouter()
+
+	// テストリクエストの作成
+	w := postJSON(router, "/api/passkey/register/start", gin.H{"username": "test-user"})
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 無効なセッション
+	w = sessionRequest(router, "POST", "/api/passkey/register/start", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 別のユーザーのセッションでは、開始した登録を完了できない
+	authenticator := webauthntest.NewAuthenticator("poc-authlete.local", "https://poc-authlete.local")
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		return "test-user-id", nil
+	}
+	w = sessionRequest(router, "POST", "/api/passkey/register/start", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var options entity.WebAuthnRegistrationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &options))
+	challenge, _ := webauthn.DecodeBase64(options.PublicKey.Challenge)
+	userHandle, _ := webauthn.DecodeBase64(options.PublicKey.User.ID)
+	credential, err := authenticator.Create(challenge, userHandle)
+	require.NoError(t, err)
+
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		return "another-user-id", nil
+	}
+	w = sessionRequest(router, "POST", "/api/passkey/register/complete", gin.H{"ceremonyId": options.CeremonyID, "credential": credential})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+)
+
+// UserHandler はユーザー登録とアカウント管理のHTTPハンドラーを実装します
+type UserHandler struct {
+	userUseCase    *usecase.UserUseCase
+	accountUseCase *usecase.AccountUseCase
+	authUseCase    usecase.AuthUseCase
+}
+
+// NewUserHandler は新しいユーザーハンドラーを作成します
+func NewUserHandler(userUseCase *usecase.UserUseCase, accountUseCase *usecase.AccountUseCase, authUseCase usecase.AuthUseCase) *UserHandler {
+	return &UserHandler{
+		userUseCase:    userUseCase,
+		accountUseCase: accountUseCase,
+		authUseCase:    authUseCase,
+	}
+}
+
+// userResponse はユーザー情報のレスポンスです（パスワードハッシュは返しません）
+type userResponse struct {
+	ID            string    `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"emailVerified"`
+	DisplayName   string    `json:"displayName"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+func newUserResponse(user *entity.User) userResponse {
+	return userResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: !user.EmailVerifiedAt.IsZero(),
+		DisplayName:   user.DisplayName,
+		Status:        user.Status,
+		CreatedAt:     user.CreatedAt,
+	}
+}
+
+// SignUp はユーザーを登録するハンドラーです
+func (h *UserHandler) SignUp(c *gin.Context) {
+	var req entity.SignUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userUseCase.SignUp(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.sendEmailVerification(c, user)
+
+	c.JSON(http.StatusCreated, newUserResponse(user))
+}
+
+// GetProfile はログイン中のユーザーのプロフィールを返すハンドラーです
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	user, err := h.userUseCase.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// UpdateProfile はログイン中のユーザーのプロフィールを変更するハンドラーです
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	var req entity.ProfileUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userUseCase.UpdateProfile(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if req.Email != nil && user.EmailVerifiedAt.IsZero() {
+		h.sendEmailVerification(c, user)
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ChangePassword はログイン中のユーザーのパスワードを変更するハンドラーです
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"currentPassword" binding:"required"`
+		NewPassword     string `json:"newPassword" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userUseCase.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteAccount はログイン中のユーザーを退会させるハンドラーです
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID, ok := sessionUserID(c, h.authUseCase)
+	if !ok {
+		return
+	}
+
+	if err := h.userUseCase.DeleteAccount(c.Request.Context(), userID); err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// セッションは退会処理で削除済みのため、Cookieだけを消す
+	clearSessionCookie(c)
+	c.Status(http.StatusNoContent)
+}
+
+// sendEmailVerification はメールアドレスの確認のリンクを送信します
+// 送信に失敗しても登録・変更は完了しているため、ログに残すだけにします（POST /api/account/email-verification で送り直せます）
+func (h *UserHandler) sendEmailVerification(c *gin.Context, user *entity.User) {
+	if err := h.accountUseCase.SendEmailVerification(c.Request.Context(), user.ID, c.GetHeader("Accept-Language")); err != nil {
+		logger.LogWarning("failed to send email verification to user %s: %v", user.ID, err)
+	}
+}
+
+// userErrorStatus はユーザーのユースケースのエラーをHTTPステータスに変換します
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidUsername),
+		errors.Is(err, usecase.ErrInvalidEmail),
+		errors.Is(err, usecase.ErrInvalidPassword),
+		errors.Is(err, usecase.ErrPasswordBreached),
+		errors.Is(err, usecase.ErrPasswordReused),
+		errors.Is(err, usecase.ErrInvalidDisplayName):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrUsernameTaken), errors.Is(err, usecase.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrAccountInactive), errors.Is(err, usecase.ErrInvalidCredentials):
+		return http.StatusForbidden
+	case errors.Is(err, entity.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/domain/entity"
+	"github.com/yamakenji24/golang-auth/domain/usecase"
+	usecasemock "github.com/yamakenji24/golang-auth/domain/usecase/mock"
+	"github.com/yamakenji24/golang-auth/interface/handler/mock"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/mailtemplate"
+	"github.com/yamakenji24/golang-auth/pkg/password"
+)
+
+func setupUserTestRouter() (*gin.Engine, *mock.MockAuthUseCase, *usecasemock.MockUserRepository, *usecasemock.MockMailer) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	userRepo := usecasemock.NewMockUserRepository()
+	mockAuthUseCase := mock.NewMockAuthUseCase()
+	hasher, _ := password.NewHasher(password.Params{Memory: 64, Iterations: 1, Parallelism: 1})
+	policy := usecase.PasswordPolicy{MinLength: 8, MaxLength: 128, History: 5}
+	userUseCase := usecase.NewUserUseCase(userRepo, usecasemock.NewMockPasskeyRepository(), usecasemock.NewMockTOTPRepository(), mockAuthUseCase, hasher, policy)
+	renderer, _ := mailtemplate.NewRenderer("ja")
+	mailer := usecasemock.NewMockMailer()
+	accountUseCase := usecase.NewAccountUseCase(userUseCase, usecasemock.NewMockAccountTokenRepository(), mailer, renderer, config.AccountConfig{
+		BaseURL:              "https://poc-authlete.local",
+		TokenSecret:          []byte("0123456789abcdef0123456789abcdef"),
+		EmailVerificationTTL: time.Hour,
+		PasswordResetTTL:     time.Hour,
+	})
+	userHandler := NewUserHandler(userUseCase, accountUseCase, mockAuthUseCase)
+	accountHandler := NewAccountHandler(accountUseCase, mockAuthUseCase)
+
+	users := router.Group("/api/users")
+	{
+		users.POST("", userHandler.SignUp)
+		users.GET("/me", userHandler.GetProfile)
+		users.PATCH("/me", userHandler.UpdateProfile)
+		users.DELETE("/me", userHandler.DeleteAccount)
+		users.PUT("/me/password", userHandler.ChangePassword)
+	}
+	account := router.Group("/api/account")
+	{
+		account.POST("/email-verification", accountHandler.SendEmailVerification)
+		account.POST("/email-verification/confirm", accountHandler.VerifyEmail)
+		account.POST("/password-reset", accountHandler.RequestPasswordReset)
+		account.POST("/password-reset/confirm", accountHandler.ResetPassword)
+	}
+
+	return router, mockAuthUseCase, userRepo, mailer
+}
+
+func TestSignUp(t *testing.T) {
+	router, _, _, mailer := setupUserTestRouter()
+
+	// テスト実行
+	w := postJSON(router, "/api/users", gin.H{
+		"username":    "new-user",
+		"email":       "new@example.com",
+		"password":    "password123",
+		"displayName": "New User",
+	})
+
+	// アサーション
+	require.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "new-user", response["username"])
+	assert.Equal(t, "New User", response["displayName"])
+	assert.Equal(t, "active", response["status"])
+	assert.Equal(t, false, response["emailVerified"])
+	assert.NotContains(t, response, "passwordHash")
+	// 登録したメールアドレスに確認のリンクを送る
+	require.Len(t, mailer.Sent, 1)
+	assert.Equal(t, "new@example.com", mailer.Sent[0].To)
+
+	// 同じメールアドレスでは登録できない
+	w = postJSON(router, "/api/users", gin.H{"username": "another-user", "email": "new@example.com", "password": "password123"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	// 短いパスワード
+	w = postJSON(router, "/api/users", gin.H{"username": "another-user", "email": "another@example.com", "password": "short"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserProfile(t *testing.T) {
+	router, mockAuthUseCase, userRepo, _ := setupUserTestRouter()
+	userRepo.Save(&entity.User{ID: "test-user-id", Username: "test-user", Email: "test@example.com", Status: entity.UserStatusActive})
+
+	// モックの設定
+	var terminated string
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		return "test-user-id", nil
+	}
+	mockAuthUseCase.DeleteUserSessionsFunc = func(userID string) error {
+		terminated = userID
+		return nil
+	}
+
+	// プロフィールの変更
+	w := sessionRequest(router, "PATCH", "/api/users/me", gin.H{"displayName": "Test User"})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = sessionRequest(router, "PATCH", "/api/users/me", gin.H{"email": "invalid"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// プロフィールの取得
+	w = sessionRequest(router, "GET", "/api/users/me", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Test User", response["displayName"])
+	assert.Equal(t, "test@example.com", response["email"])
+
+	// 退会するとセッションを終了し、Cookieを削除する
+	w = sessionRequest(router, "DELETE", "/api/users/me", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "test-user-id", terminated)
+	var cleared bool
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "poc-authlete" && cookie.Value == "" {
+			cleared = true
+		}
+	}
+	assert.True(t, cleared)
+
+	w = sessionRequest(router, "GET", "/api/users/me", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestChangePassword(t *testing.T) {
+	router, mockAuthUseCase, userRepo, _ := setupUserTestRouter()
+	w := postJSON(router, "/api/users", gin.H{"username": "new-user", "email": "new@example.com", "password": "first-password"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	// モックの設定
+	mockAuthUseCase.GetSessionUserIDFunc = func(sessionID string) (string, error) {
+		return created["id"].(string), nil
+	}
+
+	// テスト実行・アサーション
+	w = sessionRequest(router, "PUT", "/api/users/me/password", gin.H{"currentPassword": "wrong-password", "newPassword": "second-password"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = sessionRequest(router, "PUT", "/api/users/me/password", gin.H{"currentPassword": "first-password", "newPassword": "first-password"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sessionRequest(router, "PUT", "/api/users/me/password", gin.H{"currentPassword": "first-password", "newPassword": "second-password"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Len(t, userRepo.Users[created["id"].(string)].PasswordHistory, 1)
+}
+
+func TestUserProfileRequiresSession(t *testing.T) {
+	router, _, _, _ := setupUserTestRouter()
+
+	// テスト実行
+	w := sessionRequest(router, "GET", "/api/users/me", nil)
+
+	// アサーション
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
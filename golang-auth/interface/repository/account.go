@@ -0,0 +1,9 @@
+package repository
+
+import "time"
+
+// AccountTokenRepository はメール確認・パスワード再設定のトークンのうち使用済みのものを記録します
+// MarkUsed は既に使用済みの場合に entity.ErrAccountTokenUsed を返します（期限切れの記録は削除して構いません）
+type AccountTokenRepository interface {
+	MarkUsed(id string, expiresAt time.Time) error
+}
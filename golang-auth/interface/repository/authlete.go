@@ -1,10 +1,18 @@
 package repository
 
-import "github.com/yamakenji24/golang-auth/domain/entity"
+import (
+	"context"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
 
 type AuthleteClient interface {
-	RequestAuthorization(params map[string]string) (*entity.AuthResponse, error)
-	IssueAuthorization(ticket string) (*entity.AuthResponse, error)
-	ExchangeToken(params map[string]string) (*entity.TokenResponse, error)
-	GetUserInfo(accessToken string) (entity.UserInfo, error)
+	RequestAuthorization(ctx context.Context, params map[string]string) (*entity.AuthResponse, error)
+	IssueAuthorization(ctx context.Context, req entity.AuthorizationIssueRequest) (*entity.AuthResponse, error)
+	FailAuthorization(ctx context.Context, ticket, reason string) (*entity.AuthResponse, error)
+	ExchangeToken(ctx context.Context, params map[string]string) (*entity.TokenResponse, error)
+	GetUserInfo(ctx context.Context, accessToken string) (entity.UserInfo, error)
+	Introspect(ctx context.Context, token string, scopes []string, subject string) (*entity.IntrospectionResponse, error)
+	IntrospectStandard(ctx context.Context, params map[string]string) (*entity.StandardIntrospectionResponse, error)
+	Revoke(ctx context.Context, req entity.RevocationRequest) (*entity.RevocationResponse, error)
 }
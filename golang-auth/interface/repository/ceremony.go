@@ -0,0 +1,10 @@
+package repository
+
+import "github.com/yamakenji24/golang-auth/domain/entity"
+
+// CeremonyRepository はWebAuthnのセレモニーを保存します
+// Consume は取り出したセレモニーを削除し、存在しない・期限切れ・使用済みの場合は entity.ErrCeremonyNotFound を返します
+type CeremonyRepository interface {
+	Save(ceremony *entity.WebAuthnCeremony) error
+	Consume(id string) (*entity.WebAuthnCeremony, error)
+}
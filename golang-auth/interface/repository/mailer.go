@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// Mailer はメールを送信します
+type Mailer interface {
+	Send(ctx context.Context, mail entity.Mail) error
+}
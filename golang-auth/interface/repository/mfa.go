@@ -0,0 +1,26 @@
+package repository
+
+import "github.com/yamakenji24/golang-auth/domain/entity"
+
+// TOTPRepository はユーザーのTOTPとリカバリーコードを管理します
+// Get はTOTPが登録されていない場合に entity.ErrTOTPNotFound を返します
+type TOTPRepository interface {
+	Get(userID string) (*entity.TOTPCredential, error)
+	Save(credential *entity.TOTPCredential) error
+	// Delete はTOTPとリカバリーコードを削除します
+	Delete(userID string) error
+	// UpdateLastUsedStep は step が最後に受け付けた時間ステップより後の場合だけ更新し、そうでない場合は entity.ErrTOTPStepUsed を返します
+	UpdateLastUsedStep(userID string, step int64) error
+	// ReplaceRecoveryCodes はリカバリーコードのハッシュをすべて置き換えます
+	ReplaceRecoveryCodes(userID string, hashes []string) error
+	// UseRecoveryCode はリカバリーコードを削除し、存在しない場合は entity.ErrRecoveryCodeNotFound を返します
+	UseRecoveryCode(userID, hash string) error
+	CountRecoveryCodes(userID string) (int, error)
+}
+
+// MFAChallengeRepository は2要素目の入力を待つログインを保存します
+// Consume は取り出したログインを削除し、存在しない・期限切れの場合は entity.ErrMFAChallengeNotFound を返します
+type MFAChallengeRepository interface {
+	Save(challenge *entity.MFAChallenge) error
+	Consume(id string) (*entity.MFAChallenge, error)
+}
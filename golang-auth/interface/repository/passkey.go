@@ -1,9 +1,25 @@
 package repository
 
-import "github.com/yamakenji24/golang-auth/domain/entity"
+import (
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
 
 type PasskeyRepository interface {
+	// SaveCredential は新しいクレデンシャルを追加します
+	// 同じIDが既に登録されている場合は上書きせず、entity.ErrCredentialAlreadyRegistered を返します
 	SaveCredential(credential *entity.Credential) error
 	GetCredential(id string) (*entity.Credential, error)
 	GetCredentialsByUsername(username string) ([]*entity.Credential, error)
+	// GetCredentialsByUserHandle はユーザーのクレデンシャルを登録日時の順に取得します
+	GetCredentialsByUserHandle(userHandle []byte) ([]*entity.Credential, error)
+	// 以下の更新・削除は、対象が存在しない場合に entity.ErrCredentialNotFound を返します
+	UpdateNickname(id, nickname string) error
+	// UpdateLastUsed は認証に使われた日時と、その時点のバックアップ状態を記録します
+	UpdateLastUsed(id string, usedAt time.Time, backupState bool) error
+	DeleteCredential(id string) error
+	// UpdateSignCount は署名カウンターを不可分に更新します
+	// 保存済みの値より増えていない場合は entity.ErrSignCountNotIncreased を返します（両方0の場合はカウンター非対応として許可）
+	UpdateSignCount(id string, signCount uint32) error
 }
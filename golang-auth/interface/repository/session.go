@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yamakenji24/golang-auth/domain/entity"
+)
+
+// SessionRepository はログインセッションを管理します
+// 存在しない、または期限切れのセッションには entity.ErrSessionNotFound を返します
+type SessionRepository interface {
+	Create(session *entity.Session) error
+	Get(id string) (*entity.Session, error)
+	// Touch は最終アクセス日時を更新し、セッションの有効期限を延長します
+	Touch(id string, at time.Time) error
+	UpdateTokens(id string, tokens entity.Tokens) error
+	Delete(id string) error
+	ListByUser(userID string) ([]*entity.Session, error)
+}
@@ -2,9 +2,13 @@ package repository
 
 import "github.com/yamakenji24/golang-auth/domain/entity"
 
+// UserRepository はユーザーを管理します
+// 見つからない場合は entity.ErrUserNotFound を返します
 type UserRepository interface {
 	FindByID(id string) (*entity.User, error)
 	FindByUsername(username string) (*entity.User, error)
+	FindByEmail(email string) (*entity.User, error)
+	// Save は有効なユーザーとユーザー名・メールアドレスが重複する場合に entity.ErrUsernameTaken または entity.ErrEmailTaken を返します
 	Save(user *entity.User) error
 	Delete(id string) error
 }
@@ -1,25 +1,42 @@
 package main
 
 import (
+	"expvar"
 	"log"
+	"net/http"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/yamakenji24/golang-auth/domain/usecase"
 	"github.com/yamakenji24/golang-auth/infrastructure/external/authlete"
+	"github.com/yamakenji24/golang-auth/infrastructure/external/mail"
 	"github.com/yamakenji24/golang-auth/infrastructure/persistence/memory"
+	"github.com/yamakenji24/golang-auth/infrastructure/persistence/postgres"
+	"github.com/yamakenji24/golang-auth/infrastructure/persistence/sqlite"
 	user "github.com/yamakenji24/golang-auth/infrastructure/repository/memory"
 	"github.com/yamakenji24/golang-auth/interface/handler"
+	"github.com/yamakenji24/golang-auth/interface/repository"
 	"github.com/yamakenji24/golang-auth/pkg/config"
+	"github.com/yamakenji24/golang-auth/pkg/mailtemplate"
+	"github.com/yamakenji24/golang-auth/pkg/password"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/metadata"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	r := gin.Default()
 
 	// CORSの設定
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"https://poc-authlete.local"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
@@ -32,37 +49,253 @@ func main() {
 		log.Fatal(err)
 	}
 
+	repos, err := newRepositories(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repos.close()
+	userRepo := repos.user
+
 	authleteClient := authlete.NewClient(cfg)
-	authRepo := memory.NewAuthRepository()
-	authUseCase := usecase.NewAuthUseCase(authRepo, authleteClient, cfg, authleteClient)
+	expvar.Publish("authlete", expvar.Func(func() interface{} {
+		return authleteClient.Metrics().Snapshot()
+	}))
+	hasher, err := password.NewHasher(cfg.Password.Argon2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	passwordPolicy, closePasswordPolicy, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closePasswordPolicy()
+	verifier := usecase.NewPasswordCredentialVerifier(userRepo, hasher)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, repos.totp, cfg.MFA)
+	authUseCase := usecase.NewAuthUseCase(repos.auth, repos.session, authleteClient, cfg, authleteClient, verifier, mfaUseCase, repos.mfaChallenge)
 	authHandler := handler.NewAuthHandler(authUseCase)
+	mfaHandler := handler.NewMFAHandler(mfaUseCase, authUseCase)
 
-	passkeyRepo := memory.NewPasskeyRepository()
-	userRepo := user.NewUserRepository();
-	passkeyUseCase := usecase.NewPasskeyUseCase(passkeyRepo, userRepo)
-	passkeyHandler := handler.NewPasskeyHandler(passkeyUseCase)
+	attestationPolicy, closePolicy, err := newAttestationPolicy(cfg.WebAuthn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closePolicy()
+	passkeyUseCase := usecase.NewPasskeyUseCase(repos.passkey, userRepo, repos.ceremony, cfg.WebAuthn, attestationPolicy)
+	passkeyHandler := handler.NewPasskeyHandler(passkeyUseCase, authUseCase)
+	userUseCase := usecase.NewUserUseCase(userRepo, repos.passkey, repos.totp, authUseCase, hasher, passwordPolicy)
+	mailRenderer, err := mailtemplate.NewRenderer(cfg.Account.DefaultLanguage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	accountUseCase := usecase.NewAccountUseCase(userUseCase, repos.accountToken, mail.NewMailer(cfg.Mail), mailRenderer, cfg.Account)
+	userHandler := handler.NewUserHandler(userUseCase, accountUseCase, authUseCase)
+	accountHandler := handler.NewAccountHandler(accountUseCase, authUseCase)
 
 	// ルーティング
+	r.GET("/.well-known/webauthn", passkeyHandler.WellKnown)
+
 	api := r.Group("/api")
 	{
 		auth := api.Group("/auth")
 		{
 			auth.GET("/authorize", authHandler.Authorize)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/mfa", authHandler.LoginMFA)
 			auth.GET("/callback", authHandler.Callback)
 			auth.GET("/session", authHandler.GetSession)
 			auth.GET("/userinfo", authHandler.GetUserInfo)
 			auth.POST("/logout", authHandler.Logout)
 		}
 
+		oauth := api.Group("/oauth")
+		{
+			oauth.POST("/introspect", authHandler.Introspect)
+			oauth.POST("/revoke", authHandler.Revoke)
+		}
+
+		users := api.Group("/users")
+		{
+			users.POST("", userHandler.SignUp)
+			users.GET("/me", userHandler.GetProfile)
+			users.PATCH("/me", userHandler.UpdateProfile)
+			users.DELETE("/me", userHandler.DeleteAccount)
+			users.PUT("/me/password", userHandler.ChangePassword)
+		}
+
+		account := api.Group("/account")
+		{
+			account.POST("/email-verification", accountHandler.SendEmailVerification)
+			account.POST("/email-verification/confirm", accountHandler.VerifyEmail)
+			account.POST("/password-reset", accountHandler.RequestPasswordReset)
+			account.POST("/password-reset/confirm", accountHandler.ResetPassword)
+		}
+
+		mfa := api.Group("/mfa")
+		{
+			mfa.GET("", mfaHandler.GetStatus)
+			mfa.POST("/totp", mfaHandler.StartTOTPEnrollment)
+			mfa.POST("/totp/confirm", mfaHandler.ConfirmTOTPEnrollment)
+			mfa.DELETE("/totp", mfaHandler.DisableTOTP)
+			mfa.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+		}
+
 		passkey := api.Group("/passkey")
 		{
 			passkey.POST("/register/start", passkeyHandler.StartRegistration)
 			passkey.POST("/register/complete", passkeyHandler.CompleteRegistration)
 			passkey.POST("/authenticate/start", passkeyHandler.StartAuthentication)
 			passkey.POST("/authenticate/complete", passkeyHandler.CompleteAuthentication)
+			passkey.GET("/credentials", passkeyHandler.ListCredentials)
+			passkey.PATCH("/credentials/:id", passkeyHandler.RenameCredential)
+			passkey.DELETE("/credentials/:id", passkeyHandler.DeleteCredential)
 		}
 	}
 
+	go serveMetrics(cfg.MetricsAddr)
+
 	r.Run(":3000")
 }
+
+// serveMetrics は公開用のルーターとは別のリスナーでメトリクス（/debug/vars）を公開します
+// コマンドライン引数やAuthleteの呼び出し状況を含むため、METRICS_ADDR は内部ネットワークに限ってください
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("metrics server stopped: %v", err)
+	}
+}
+
+// newAttestationPolicy は登録を受け付ける認証器のポリシーを作成します
+// WEBAUTHN_MDS_PATH が設定されている場合はFIDO MDSのBLOBを読み込み、定期的に読み直します
+func newAttestationPolicy(cfg config.WebAuthnConfig) (usecase.AttestationPolicy, func(), error) {
+	if cfg.MetadataPath == "" {
+		policy, err := usecase.NewMetadataAttestationPolicy(nil, cfg)
+		return policy, func() {}, err
+	}
+
+	roots, err := metadata.LoadRootCertificates(cfg.MetadataRootCertPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := metadata.NewStore(cfg.MetadataPath, roots, cfg.MetadataReloadInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+	policy, err := usecase.NewMetadataAttestationPolicy(store, cfg)
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	return policy, store.Close, nil
+}
+
+// newPasswordPolicy はパスワードの条件を作成します
+// 漏洩したパスワードの一覧が設定されている場合は、そのファイルを閉じる関数も返します
+func newPasswordPolicy(cfg config.PasswordConfig) (usecase.PasswordPolicy, func(), error) {
+	if cfg.BreachedListPath == "" {
+		return usecase.NewPasswordPolicy(cfg, nil), func() {}, nil
+	}
+
+	breached, err := password.OpenBreachedList(cfg.BreachedListPath)
+	if err != nil {
+		return usecase.PasswordPolicy{}, nil, err
+	}
+	return usecase.NewPasswordPolicy(cfg, breached), func() { breached.Close() }, nil
+}
+
+// repositories は設定で選択した保存先のリポジトリです
+type repositories struct {
+	user     repository.UserRepository
+	passkey  repository.PasskeyRepository
+	auth     repository.AuthRepository
+	session  repository.SessionRepository
+	ceremony repository.CeremonyRepository
+	// accountToken は使用済みのメール確認・パスワード再設定のトークンです
+	accountToken repository.AccountTokenRepository
+	totp         repository.TOTPRepository
+	// mfaChallenge は2要素目の入力を待つログインです
+	mfaChallenge repository.MFAChallengeRepository
+	close        func()
+}
+
+func newRepositories(cfg *config.Config) (*repositories, error) {
+	switch cfg.StorageDriver {
+	case config.StorageSQLite:
+		db, err := sqlite.Open(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		sessionRepo := sqlite.NewSessionRepository(db, cfg.SessionTTL, cfg.SessionJanitorInterval)
+		ceremonyRepo := sqlite.NewCeremonyRepository(db, cfg.SessionJanitorInterval)
+		mfaChallengeRepo := sqlite.NewMFAChallengeRepository(db, cfg.SessionJanitorInterval)
+		return &repositories{
+			user:         sqlite.NewUserRepository(db),
+			passkey:      sqlite.NewPasskeyRepository(db),
+			auth:         sqlite.NewAuthRepository(db),
+			session:      sessionRepo,
+			ceremony:     ceremonyRepo,
+			accountToken: sqlite.NewAccountTokenRepository(db),
+			totp:         sqlite.NewTOTPRepository(db),
+			mfaChallenge: mfaChallengeRepo,
+			close: func() {
+				sessionRepo.Close()
+				ceremonyRepo.Close()
+				mfaChallengeRepo.Close()
+				db.Close()
+			},
+		}, nil
+	case config.StoragePostgres:
+		db, err := postgres.Open(cfg.PostgresDSN)
+		if err != nil {
+			return nil, err
+		}
+		// 複数のレプリカで同時に適用しないよう、マイグレーションは migrate サブコマンドで行う
+		migrator, err := postgres.NewMigrator(db)
+		if err == nil {
+			err = migrator.Check()
+		}
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		sessionRepo := postgres.NewSessionRepository(db, cfg.SessionTTL, cfg.SessionJanitorInterval)
+		ceremonyRepo := postgres.NewCeremonyRepository(db, cfg.SessionJanitorInterval)
+		mfaChallengeRepo := postgres.NewMFAChallengeRepository(db, cfg.SessionJanitorInterval)
+		return &repositories{
+			user:         postgres.NewUserRepository(db),
+			passkey:      postgres.NewPasskeyRepository(db),
+			auth:         postgres.NewAuthRepository(db),
+			session:      sessionRepo,
+			ceremony:     ceremonyRepo,
+			accountToken: postgres.NewAccountTokenRepository(db),
+			totp:         postgres.NewTOTPRepository(db),
+			mfaChallenge: mfaChallengeRepo,
+			close: func() {
+				sessionRepo.Close()
+				ceremonyRepo.Close()
+				mfaChallengeRepo.Close()
+				db.Close()
+			},
+		}, nil
+	default:
+		sessionRepo := memory.NewSessionRepository(cfg.SessionTTL, cfg.SessionJanitorInterval)
+		ceremonyRepo := memory.NewCeremonyRepository(cfg.SessionJanitorInterval)
+		mfaChallengeRepo := memory.NewMFAChallengeRepository(cfg.SessionJanitorInterval)
+		return &repositories{
+			user:         user.NewUserRepository(),
+			passkey:      memory.NewPasskeyRepository(),
+			auth:         memory.NewAuthRepository(),
+			session:      sessionRepo,
+			ceremony:     ceremonyRepo,
+			accountToken: memory.NewAccountTokenRepository(),
+			totp:         memory.NewTOTPRepository(),
+			mfaChallenge: mfaChallengeRepo,
+			close: func() {
+				sessionRepo.Close()
+				ceremonyRepo.Close()
+				mfaChallengeRepo.Close()
+			},
+		}, nil
+	}
+}
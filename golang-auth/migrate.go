@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/yamakenji24/golang-auth/infrastructure/persistence/postgres"
+	"github.com/yamakenji24/golang-auth/pkg/config"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  up          未適用のマイグレーションをすべて適用します
+  down [N]    適用済みのマイグレーションを新しい順にN件（既定1件）取り消します
+  version     適用済みの最新バージョンを表示します`
+
+// runMigrate は migrate サブコマンドを実行します（PostgreSQLのみ対象）
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("migrate: command is required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.StorageDriver != config.StoragePostgres {
+		return fmt.Errorf("migrate: STORAGE_DRIVER must be %s (sqlite is migrated at startup)", config.StoragePostgres)
+	}
+
+	db, err := postgres.Open(cfg.PostgresDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "up":
+		n, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", n)
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			steps, err = strconv.Atoi(fs.Arg(1))
+			if err != nil || steps < 1 {
+				return fmt.Errorf("migrate: invalid number of steps: %s", fs.Arg(1))
+			}
+		}
+		n, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", n)
+	case "version":
+		version, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+	default:
+		fs.Usage()
+		return fmt.Errorf("migrate: unknown command %q", fs.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MailDriver はメールの送信方法です
+const (
+	// MailDriverLog はメールを送信せずログに出力します（ローカル開発用）
+	MailDriverLog = "log"
+	// MailDriverFile はメールを送信せず .eml ファイルとして書き出します（ローカル開発用）
+	MailDriverFile = "file"
+	MailDriverSMTP = "smtp"
+)
+
+// メール確認・パスワード再設定の既定値です
+const (
+	defaultMailFrom             = "no-reply@poc-authlete.local"
+	defaultSMTPPort             = 587
+	defaultAccountBaseURL       = "https://poc-authlete.local"
+	defaultEmailVerificationTTL = 24 * time.Hour
+	defaultPasswordResetTTL     = time.Hour
+	defaultMailLanguage         = "ja"
+	// minAccountTokenSecretLength はトークンの署名鍵に求める最低のバイト数です（HMAC-SHA256の出力長）
+	minAccountTokenSecretLength = 32
+)
+
+// MailConfig はメールの送信の設定です
+type MailConfig struct {
+	// Driver は log、file または smtp です
+	Driver string
+	From   string
+	// FileDir は Driver が file の場合にメールを書き出すディレクトリです
+	FileDir string
+	// SMTPHost と SMTPPort は Driver が smtp の場合の送信先です（STARTTLSに対応していれば使います）
+	SMTPHost string
+	SMTPPort int
+	// SMTPUsername が空の場合は認証しません
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// Validate は設定が矛盾していないかを確認します
+func (c MailConfig) Validate() error {
+	switch c.Driver {
+	case MailDriverLog:
+	case MailDriverFile:
+		if c.FileDir == "" {
+			return fmt.Errorf("MAIL_FILE_DIR is required when MAIL_DRIVER is file")
+		}
+	case MailDriverSMTP:
+		if c.SMTPHost == "" {
+			return fmt.Errorf("SMTP_HOST is required when MAIL_DRIVER is smtp")
+		}
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			return fmt.Errorf("invalid SMTP_PORT: %d", c.SMTPPort)
+		}
+	default:
+		return fmt.Errorf("invalid MAIL_DRIVER: %q", c.Driver)
+	}
+	if strings.ContainsAny(c.From, "\r\n") || !strings.Contains(c.From, "@") {
+		return fmt.Errorf("invalid MAIL_FROM: %q", c.From)
+	}
+	return nil
+}
+
+// AccountConfig はメール確認・パスワード再設定の設定です
+type AccountConfig struct {
+	// BaseURL はメールに載せるリンクの基点（フロントエンドのオリジン）です
+	BaseURL string
+	// TokenSecret はトークンの署名鍵です
+	// 空の場合は起動のたびに生成するため、再起動すると発行済みのリンクは使えなくなります
+	TokenSecret []byte
+	// EmailVerificationTTL と PasswordResetTTL はリンクの有効期間です
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	// DefaultLanguage は Accept-Language に対応する言語が無い場合のメールの言語です
+	DefaultLanguage string
+}
+
+// Validate は設定が矛盾していないかを確認します
+func (c AccountConfig) Validate() error {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid ACCOUNT_BASE_URL: %q", c.BaseURL)
+	}
+	if len(c.TokenSecret) > 0 && len(c.TokenSecret) < minAccountTokenSecretLength {
+		return fmt.Errorf("invalid ACCOUNT_TOKEN_SECRET: must be at least %d bytes", minAccountTokenSecretLength)
+	}
+	return nil
+}
+
+// loadMailConfig は環境変数からメールの送信の設定を読み込み、検証します
+func loadMailConfig() (MailConfig, error) {
+	port, err := getEnvInt("SMTP_PORT", defaultSMTPPort)
+	if err != nil {
+		return MailConfig{}, err
+	}
+
+	c := MailConfig{
+		Driver:       getEnv("MAIL_DRIVER", MailDriverLog),
+		From:         getEnv("MAIL_FROM", defaultMailFrom),
+		FileDir:      getEnv("MAIL_FILE_DIR", ""),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     port,
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+	}
+	if err := c.Validate(); err != nil {
+		return MailConfig{}, err
+	}
+	return c, nil
+}
+
+// loadAccountConfig は環境変数からメール確認・パスワード再設定の設定を読み込み、検証します
+func loadAccountConfig() (AccountConfig, error) {
+	verificationTTL, err := getEnvDuration("EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL)
+	if err != nil {
+		return AccountConfig{}, err
+	}
+	resetTTL, err := getEnvDuration("PASSWORD_RESET_TTL", defaultPasswordResetTTL)
+	if err != nil {
+		return AccountConfig{}, err
+	}
+
+	c := AccountConfig{
+		BaseURL:              strings.TrimSuffix(getEnv("ACCOUNT_BASE_URL", defaultAccountBaseURL), "/"),
+		TokenSecret:          []byte(getEnv("ACCOUNT_TOKEN_SECRET", "")),
+		EmailVerificationTTL: verificationTTL,
+		PasswordResetTTL:     resetTTL,
+		DefaultLanguage:      getEnv("MAIL_DEFAULT_LANGUAGE", defaultMailLanguage),
+	}
+	if err := c.Validate(); err != nil {
+		return AccountConfig{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMailConfig(t *testing.T) {
+	// テスト実行
+	c, err := loadMailConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, MailDriverLog, c.Driver)
+	assert.Equal(t, "no-reply@poc-authlete.local", c.From)
+
+	// テストケースの準備
+	t.Setenv("MAIL_DRIVER", "smtp")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "2525")
+
+	// テスト実行
+	c, err = loadMailConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com", c.SMTPHost)
+	assert.Equal(t, 2525, c.SMTPPort)
+}
+
+func TestLoadAccountConfig(t *testing.T) {
+	// テスト実行
+	c, err := loadAccountConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "https://poc-authlete.local", c.BaseURL)
+	assert.Empty(t, c.TokenSecret)
+	assert.Equal(t, 24*time.Hour, c.EmailVerificationTTL)
+	assert.Equal(t, time.Hour, c.PasswordResetTTL)
+
+	// テストケースの準備
+	t.Setenv("ACCOUNT_BASE_URL", "http://localhost:5173/")
+	t.Setenv("ACCOUNT_TOKEN_SECRET", "0123456789abcdef0123456789abcdef")
+	t.Setenv("PASSWORD_RESET_TTL", "15m")
+
+	// テスト実行
+	c, err = loadAccountConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:5173", c.BaseURL)
+	assert.Len(t, c.TokenSecret, 32)
+	assert.Equal(t, 15*time.Minute, c.PasswordResetTTL)
+}
+
+func TestLoadAccountConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"未対応の送信方法", map[string]string{"MAIL_DRIVER": "sendmail"}},
+		{"書き出し先のないfile", map[string]string{"MAIL_DRIVER": "file"}},
+		{"送信先のないsmtp", map[string]string{"MAIL_DRIVER": "smtp"}},
+		{"改行を含む送信元", map[string]string{"MAIL_FROM": "a@example.com\r\nBcc: b@example.com"}},
+		{"スキームのないリンクの基点", map[string]string{"ACCOUNT_BASE_URL": "poc-authlete.local"}},
+		{"短い署名鍵", map[string]string{"ACCOUNT_TOKEN_SECRET": "secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テストケースの準備
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			// テスト実行
+			_, mailErr := loadMailConfig()
+			_, accountErr := loadAccountConfig()
+
+			// アサーション
+			assert.True(t, mailErr != nil || accountErr != nil)
+		})
+	}
+}
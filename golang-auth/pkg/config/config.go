@@ -1,11 +1,50 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// DefaultAuthleteRequestTimeout はAUTHLETE_REQUEST_TIMEOUT未設定時に使うAuthlete API呼び出しの期限です
+const DefaultAuthleteRequestTimeout = 10 * time.Second
+
+// DefaultTokenRefreshLeeway はTOKEN_REFRESH_LEEWAY未設定時に、期限切れのどれだけ前にアクセストークンを更新するかです
+const DefaultTokenRefreshLeeway = time.Minute
+
+// StorageDriver はリポジトリの保存先の種類です
+const (
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
+// defaultMetricsAddr は METRICS_ADDR 未設定時にメトリクス（/debug/vars）を公開するアドレスです
+// 公開用のルーターとは分け、既定ではローカルホストからのみ参照できます
+const defaultMetricsAddr = "127.0.0.1:9090"
+
+// セッションの既定値です
+const (
+	defaultSessionTTL             = time.Hour
+	defaultSessionJanitorInterval = time.Minute
+)
+
+// Authlete API呼び出しの再試行とサーキットブレーカーの既定値です
+const (
+	defaultAuthleteMaxRetries       = 2
+	defaultAuthleteRetryBaseDelay   = 100 * time.Millisecond
+	defaultAuthleteRetryMaxDelay    = 2 * time.Second
+	defaultAuthleteBreakerThreshold = 5
+	defaultAuthleteBreakerCooldown  = 30 * time.Second
+)
+
 type Config struct {
 	AuthleteBaseURL      string
 	AuthleteServiceID    string
@@ -13,19 +52,213 @@ type Config struct {
 	AuthleteClientSecret string
 	AuthleteRedirectURI  string
 	AuthleteAccessToken  string
+	// AuthleteRequestTimeout はAuthlete API呼び出し1回あたりの期限です
+	AuthleteRequestTimeout time.Duration
+	// AuthleteMaxRetries は冪等なAPIを一時的な障害時に再試行する最大回数です
+	AuthleteMaxRetries     int
+	AuthleteRetryBaseDelay time.Duration
+	AuthleteRetryMaxDelay  time.Duration
+	// AuthleteBreakerThreshold 回連続で失敗するとAuthleteBreakerCooldown の間呼び出しを止めます（0で無効）
+	AuthleteBreakerThreshold int
+	AuthleteBreakerCooldown  time.Duration
+	// IntrospectionClients はトークンイントロスペクションを許可するリソースサーバーのクライアントIDとシークレットです
+	IntrospectionClients map[string]string
+	// TokenRefreshLeeway はセッションのアクセストークンを期限切れのどれだけ前に更新するかです
+	TokenRefreshLeeway time.Duration
+	// SessionTTL は最終アクセスからセッションが無効になるまでの時間です
+	SessionTTL time.Duration
+	// SessionJanitorInterval は期限切れのセッションを削除する間隔です
+	SessionJanitorInterval time.Duration
+	// MetricsAddr はメトリクス（/debug/vars）を公開する内部向けのアドレスです
+	MetricsAddr string
+	// StorageDriver はユーザー・パスキー・認可トランザクション・セッションの保存先です（memory、sqlite または postgres）
+	StorageDriver string
+	// SQLitePath はStorageDriverがsqliteの場合のデータベースファイルのパスです
+	SQLitePath string
+	// PostgresDSN はStorageDriverがpostgresの場合の接続文字列です
+	PostgresDSN string
+	// WebAuthn はパスキーのRelying Partyの設定です
+	WebAuthn WebAuthnConfig
+	// Password はパスワードのハッシュ化とパスワードの条件の設定です
+	Password PasswordConfig
+	// Mail はメールの送信の設定です
+	Mail MailConfig
+	// Account はメール確認・パスワード再設定の設定です
+	Account AccountConfig
+	// MFA はTOTPによる2要素認証の設定です
+	MFA MFAConfig
 }
 
 func LoadConfig() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
+	// .env が無い場合は環境変数のみで設定する（docker-compose など）
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	requestTimeout, err := getEnvDuration("AUTHLETE_REQUEST_TIMEOUT", DefaultAuthleteRequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	maxRetries, err := getEnvInt("AUTHLETE_MAX_RETRIES", defaultAuthleteMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	retryBaseDelay, err := getEnvDuration("AUTHLETE_RETRY_BASE_DELAY", defaultAuthleteRetryBaseDelay)
+	if err != nil {
+		return nil, err
+	}
+	retryMaxDelay, err := getEnvDuration("AUTHLETE_RETRY_MAX_DELAY", defaultAuthleteRetryMaxDelay)
+	if err != nil {
+		return nil, err
+	}
+	breakerThreshold, err := getEnvInt("AUTHLETE_BREAKER_THRESHOLD", defaultAuthleteBreakerThreshold)
+	if err != nil {
+		return nil, err
+	}
+	breakerCooldown, err := getEnvDuration("AUTHLETE_BREAKER_COOLDOWN", defaultAuthleteBreakerCooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	introspectionClients, err := getEnvCredentials("INTROSPECTION_CLIENTS")
+	if err != nil {
+		return nil, err
+	}
+	tokenRefreshLeeway, err := getEnvDuration("TOKEN_REFRESH_LEEWAY", DefaultTokenRefreshLeeway)
+	if err != nil {
+		return nil, err
+	}
+	sessionTTL, err := getEnvDuration("SESSION_TTL", defaultSessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	sessionJanitorInterval, err := getEnvDuration("SESSION_JANITOR_INTERVAL", defaultSessionJanitorInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsAddr := getEnv("METRICS_ADDR", defaultMetricsAddr)
+	if _, _, err := net.SplitHostPort(metricsAddr); err != nil {
+		return nil, fmt.Errorf("invalid METRICS_ADDR: %q", metricsAddr)
+	}
+
+	storageDriver := getEnv("STORAGE_DRIVER", StorageMemory)
+	switch storageDriver {
+	case StorageMemory, StorageSQLite:
+	case StoragePostgres:
+		if os.Getenv("POSTGRES_DSN") == "" {
+			return nil, fmt.Errorf("POSTGRES_DSN is required when STORAGE_DRIVER is postgres")
+		}
+	default:
+		return nil, fmt.Errorf("invalid STORAGE_DRIVER: %q", storageDriver)
+	}
+
+	webauthnConfig, err := loadWebAuthnConfig()
+	if err != nil {
+		return nil, err
+	}
+	passwordConfig, err := loadPasswordConfig()
+	if err != nil {
+		return nil, err
+	}
+	mailConfig, err := loadMailConfig()
+	if err != nil {
+		return nil, err
+	}
+	accountConfig, err := loadAccountConfig()
+	if err != nil {
+		return nil, err
+	}
+	mfaConfig, err := loadMFAConfig()
+	if err != nil {
 		return nil, err
 	}
 
 	return &Config{
-		AuthleteBaseURL:      os.Getenv("AUTHLETE_BASE_URL"),
-		AuthleteServiceID:    os.Getenv("AUTHLETE_SERVICE_ID"),
-		AuthleteClientID:     os.Getenv("AUTHLETE_CLIENT_ID"),
-		AuthleteClientSecret: os.Getenv("AUTHLETE_CLIENT_SECRET"),
-		AuthleteRedirectURI:  os.Getenv("AUTHLETE_REDIRECT_URI"),
-		AuthleteAccessToken:  os.Getenv("AUTHLETE_ACCESS_TOKEN"),
+		AuthleteBaseURL:          os.Getenv("AUTHLETE_BASE_URL"),
+		AuthleteServiceID:        os.Getenv("AUTHLETE_SERVICE_ID"),
+		AuthleteClientID:         os.Getenv("AUTHLETE_CLIENT_ID"),
+		AuthleteClientSecret:     os.Getenv("AUTHLETE_CLIENT_SECRET"),
+		AuthleteRedirectURI:      os.Getenv("AUTHLETE_REDIRECT_URI"),
+		AuthleteAccessToken:      os.Getenv("AUTHLETE_ACCESS_TOKEN"),
+		AuthleteRequestTimeout:   requestTimeout,
+		AuthleteMaxRetries:       maxRetries,
+		AuthleteRetryBaseDelay:   retryBaseDelay,
+		AuthleteRetryMaxDelay:    retryMaxDelay,
+		AuthleteBreakerThreshold: breakerThreshold,
+		AuthleteBreakerCooldown:  breakerCooldown,
+		IntrospectionClients:     introspectionClients,
+		TokenRefreshLeeway:       tokenRefreshLeeway,
+		SessionTTL:               sessionTTL,
+		SessionJanitorInterval:   sessionJanitorInterval,
+		MetricsAddr:              metricsAddr,
+		StorageDriver:            storageDriver,
+		SQLitePath:               getEnv("SQLITE_PATH", "poc-authlete.db"),
+		PostgresDSN:              os.Getenv("POSTGRES_DSN"),
+		WebAuthn:                 webauthnConfig,
+		Password:                 passwordConfig,
+		Mail:                     mailConfig,
+		Account:                  accountConfig,
+		MFA:                      mfaConfig,
 	}, nil
 }
+
+// getEnv は環境変数を読み込み、未設定の場合は既定値を返します
+func getEnv(key, defaultValue string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// getEnvDuration は環境変数を time.Duration として読み込みます（例: "5s", "500ms"）
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive", key)
+	}
+	return d, nil
+}
+
+// getEnvInt は環境変数を0以上の整数として読み込みます
+func getEnvInt(key string, defaultValue int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid %s: must not be negative", key)
+	}
+	return n, nil
+}
+
+// getEnvCredentials は "id1:secret1,id2:secret2" 形式の環境変数をクライアントIDとシークレットの組として読み込みます
+func getEnvCredentials(key string) (map[string]string, error) {
+	credentials := make(map[string]string)
+	v := os.Getenv(key)
+	if v == "" {
+		return credentials, nil
+	}
+
+	for _, pair := range strings.Split(v, ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("invalid %s: expected id:secret pairs", key)
+		}
+		credentials[id] = secret
+	}
+	return credentials, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 2要素認証の既定値です
+const (
+	defaultTOTPIssuer      = "Passkey Demo"
+	defaultTOTPSkew        = 1
+	defaultMFAChallengeTTL = 5 * time.Minute
+	defaultMFAMaxAttempts  = 5
+	// maxTOTPSkew は許容する時刻のずれの上限（前後の時間ステップ数）です
+	maxTOTPSkew = 10
+)
+
+// MFAConfig はTOTPによる2要素認証の設定です
+type MFAConfig struct {
+	// TOTPIssuer は認証アプリに表示するサービス名です
+	TOTPIssuer string
+	// TOTPSkew は端末の時刻のずれとして前後に許容する時間ステップ（30秒）の数です
+	TOTPSkew int
+	// ChallengeTTL はパスワードを確認してから2要素目を入力するまでの有効期間です
+	ChallengeTTL time.Duration
+	// MaxAttempts は2要素目を誤って入力できる回数で、超えるとログインを失敗させます
+	MaxAttempts int
+}
+
+// Validate は設定が矛盾していないかを確認します
+func (c MFAConfig) Validate() error {
+	if c.TOTPIssuer == "" || strings.Contains(c.TOTPIssuer, ":") {
+		return fmt.Errorf("invalid MFA_TOTP_ISSUER: %q", c.TOTPIssuer)
+	}
+	if c.TOTPSkew < 0 || c.TOTPSkew > maxTOTPSkew {
+		return fmt.Errorf("invalid MFA_TOTP_SKEW: %d", c.TOTPSkew)
+	}
+	if c.ChallengeTTL <= 0 {
+		return fmt.Errorf("invalid MFA_CHALLENGE_TTL: %s", c.ChallengeTTL)
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("invalid MFA_MAX_ATTEMPTS: %d", c.MaxAttempts)
+	}
+	return nil
+}
+
+// loadMFAConfig は環境変数から2要素認証の設定を読み込み、検証します
+func loadMFAConfig() (MFAConfig, error) {
+	skew, err := getEnvInt("MFA_TOTP_SKEW", defaultTOTPSkew)
+	if err != nil {
+		return MFAConfig{}, err
+	}
+	challengeTTL, err := getEnvDuration("MFA_CHALLENGE_TTL", defaultMFAChallengeTTL)
+	if err != nil {
+		return MFAConfig{}, err
+	}
+	maxAttempts, err := getEnvInt("MFA_MAX_ATTEMPTS", defaultMFAMaxAttempts)
+	if err != nil {
+		return MFAConfig{}, err
+	}
+
+	c := MFAConfig{
+		TOTPIssuer:   getEnv("MFA_TOTP_ISSUER", defaultTOTPIssuer),
+		TOTPSkew:     skew,
+		ChallengeTTL: challengeTTL,
+		MaxAttempts:  maxAttempts,
+	}
+	if err := c.Validate(); err != nil {
+		return MFAConfig{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMFAConfig(t *testing.T) {
+	// テスト実行
+	c, err := loadMFAConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "Passkey Demo", c.TOTPIssuer)
+	assert.Equal(t, 1, c.TOTPSkew)
+	assert.Equal(t, 5*time.Minute, c.ChallengeTTL)
+	assert.Equal(t, 5, c.MaxAttempts)
+
+	// テストケースの準備
+	t.Setenv("MFA_TOTP_SKEW", "2")
+	t.Setenv("MFA_MAX_ATTEMPTS", "3")
+
+	// テスト実行
+	c, err = loadMFAConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.TOTPSkew)
+	assert.Equal(t, 3, c.MaxAttempts)
+}
+
+func TestLoadMFAConfigInvalid(t *testing.T) {
+	// テストケースの準備
+	t.Setenv("MFA_TOTP_ISSUER", "Demo:Service")
+
+	// テスト実行
+	_, err := loadMFAConfig()
+
+	// アサーション
+	assert.Error(t, err)
+}
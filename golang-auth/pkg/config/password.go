@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/yamakenji24/golang-auth/pkg/password"
+)
+
+// パスワードの既定値です
+const (
+	defaultPasswordMinLength = 8
+	defaultPasswordMaxLength = 128
+	defaultPasswordHistory   = 5
+)
+
+// PasswordConfig はパスワードのハッシュ化とパスワードの条件の設定です
+type PasswordConfig struct {
+	// Argon2 は新しいパスワードのハッシュに使うargon2idのパラメータです
+	// 保存済みのハッシュとパラメータが異なる場合は、ログイン時にハッシュを作り直します
+	Argon2 password.Params
+	// MinLength と MaxLength はパスワードの文字数の範囲です
+	MinLength int
+	MaxLength int
+	// BreachedListPath は漏洩したパスワードの一覧（Pwned PasswordsのSHA-1ハッシュ）のファイルで、空の場合は確認しません
+	BreachedListPath string
+	// History は再利用を禁止する直近のパスワードの数です（0で無効）
+	History int
+}
+
+// Validate は設定が矛盾していないかを確認します
+func (c PasswordConfig) Validate() error {
+	if err := c.Argon2.Validate(); err != nil {
+		return fmt.Errorf("invalid PASSWORD_ARGON2_*: %w", err)
+	}
+	if c.MinLength < 1 {
+		return fmt.Errorf("invalid PASSWORD_MIN_LENGTH: must be at least 1")
+	}
+	if c.MaxLength < c.MinLength {
+		return fmt.Errorf("invalid PASSWORD_MAX_LENGTH: must not be less than PASSWORD_MIN_LENGTH")
+	}
+	return nil
+}
+
+// loadPasswordConfig は環境変数からパスワードの設定を読み込み、検証します
+func loadPasswordConfig() (PasswordConfig, error) {
+	memory, err := getEnvInt("PASSWORD_ARGON2_MEMORY", int(password.DefaultParams.Memory))
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	iterations, err := getEnvInt("PASSWORD_ARGON2_ITERATIONS", int(password.DefaultParams.Iterations))
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	parallelism, err := getEnvInt("PASSWORD_ARGON2_PARALLELISM", int(password.DefaultParams.Parallelism))
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	if parallelism > 255 {
+		return PasswordConfig{}, fmt.Errorf("invalid PASSWORD_ARGON2_PARALLELISM: must be at most 255")
+	}
+	minLength, err := getEnvInt("PASSWORD_MIN_LENGTH", defaultPasswordMinLength)
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	maxLength, err := getEnvInt("PASSWORD_MAX_LENGTH", defaultPasswordMaxLength)
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	history, err := getEnvInt("PASSWORD_HISTORY", defaultPasswordHistory)
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+
+	c := PasswordConfig{
+		Argon2: password.Params{
+			Memory:      uint32(memory),
+			Iterations:  uint32(iterations),
+			Parallelism: uint8(parallelism),
+			SaltLength:  password.DefaultParams.SaltLength,
+			KeyLength:   password.DefaultParams.KeyLength,
+		},
+		MinLength:        minLength,
+		MaxLength:        maxLength,
+		BreachedListPath: getEnv("PASSWORD_BREACHED_LIST", ""),
+		History:          history,
+	}
+	if err := c.Validate(); err != nil {
+		return PasswordConfig{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/pkg/password"
+)
+
+func TestLoadPasswordConfig(t *testing.T) {
+	// テスト実行
+	c, err := loadPasswordConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, password.DefaultParams, c.Argon2)
+	assert.Equal(t, 8, c.MinLength)
+	assert.Equal(t, 5, c.History)
+
+	// テストケースの準備
+	t.Setenv("PASSWORD_ARGON2_MEMORY", "19456")
+	t.Setenv("PASSWORD_ARGON2_ITERATIONS", "2")
+	t.Setenv("PASSWORD_ARGON2_PARALLELISM", "1")
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_HISTORY", "0")
+
+	// テスト実行
+	c, err = loadPasswordConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, uint32(19456), c.Argon2.Memory)
+	assert.Equal(t, uint32(2), c.Argon2.Iterations)
+	assert.Equal(t, uint8(1), c.Argon2.Parallelism)
+	assert.Equal(t, 12, c.MinLength)
+	assert.Equal(t, 0, c.History)
+}
+
+func TestLoadPasswordConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"反復回数が0", map[string]string{"PASSWORD_ARGON2_ITERATIONS": "0"}},
+		{"並列数が大きすぎる", map[string]string{"PASSWORD_ARGON2_PARALLELISM": "256"}},
+		{"メモリが少なすぎる", map[string]string{"PASSWORD_ARGON2_MEMORY": "16"}},
+		{"最大の文字数が最小より短い", map[string]string{"PASSWORD_MIN_LENGTH": "16", "PASSWORD_MAX_LENGTH": "12"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テストケースの準備
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			// テスト実行
+			_, err := loadPasswordConfig()
+
+			// アサーション
+			assert.Error(t, err)
+		})
+	}
+}
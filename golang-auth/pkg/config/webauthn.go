@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/metadata"
+)
+
+// パスキー（WebAuthn）の既定値です
+const (
+	defaultWebAuthnRPID             = "poc-authlete.local"
+	defaultWebAuthnRPName           = "Passkey Demo"
+	defaultWebAuthnOrigins          = "https://poc-authlete.local"
+	defaultWebAuthnTimeout          = 5 * time.Minute
+	defaultWebAuthnAlgorithms       = "ES256,EdDSA,RS256"
+	defaultWebAuthnAttestation      = "none"
+	defaultWebAuthnUserVerification = "preferred"
+	defaultWebAuthnResidentKey      = "required"
+	defaultWebAuthnMDSReload        = time.Hour
+)
+
+// webauthnAlgorithms はWEBAUTHN_ALGORITHMSで指定できるアルゴリズム名とCOSEの識別子です
+var webauthnAlgorithms = map[string]int{
+	"ES256": webauthn.AlgES256,
+	"EdDSA": webauthn.AlgEdDSA,
+	"RS256": webauthn.AlgRS256,
+}
+
+// WebAuthnConfig はパスキー（WebAuthn）のRelying Partyの設定です
+type WebAuthnConfig struct {
+	// RPID はパスキーを紐づけるドメインです（スキームやポートは含めません）
+	RPID   string
+	RPName string
+	// Origins はクレデンシャルの作成・利用を許可するオリジンで、RPIDと同じドメインかそのサブドメインに限ります
+	Origins []string
+	// RelatedOrigins はRPIDとは別のドメインで同じパスキーを使うオリジンです（/.well-known/webauthn で公開します）
+	RelatedOrigins []string
+	// RegistrationTimeout と AuthenticationTimeout はブラウザに渡すタイムアウトで、セレモニーもこの期間だけ保持します
+	RegistrationTimeout   time.Duration
+	AuthenticationTimeout time.Duration
+	// Algorithms は登録を受け付ける公開鍵のアルゴリズム（COSEの識別子）で、優先する順に並べます
+	Algorithms []int
+	// Attestation は登録時にブラウザへ要求するアテステーションです（none、indirect、direct または enterprise）
+	Attestation string
+	// UserVerification と ResidentKey は required、preferred または discouraged です
+	UserVerification string
+	ResidentKey      string
+	// MetadataPath はFIDO Metadata Service（MDS3）のBLOBのファイルで、MetadataRootCertPath のルート証明書で署名を検証します
+	MetadataPath           string
+	MetadataRootCertPath   string
+	MetadataReloadInterval time.Duration
+	// AllowedAAGUIDs が空でない場合は、これらの認証器だけ登録を受け付けます（DeniedAAGUIDs は常に拒否します）
+	AllowedAAGUIDs []string
+	DeniedAAGUIDs  []string
+	// MinCertificationLevel はFIDO認定の最低レベル（L1、L1plus、L2 など）で、空の場合は認定を求めません
+	MinCertificationLevel string
+}
+
+// RequiresCertifiedAuthenticator は認証器の機種をアテステーションで確認する必要があるかを返します
+func (c WebAuthnConfig) RequiresCertifiedAuthenticator() bool {
+	return len(c.AllowedAAGUIDs) > 0 || c.MinCertificationLevel != ""
+}
+
+// AllowedOrigins はクライアントデータの検証で受け付けるオリジンを返します
+func (c WebAuthnConfig) AllowedOrigins() []string {
+	origins := make([]string, 0, len(c.Origins)+len(c.RelatedOrigins))
+	origins = append(origins, c.Origins...)
+	return append(origins, c.RelatedOrigins...)
+}
+
+// Validate は設定が矛盾していないかを確認します
+func (c WebAuthnConfig) Validate() error {
+	if c.RPID == "" || strings.ContainsAny(c.RPID, ":/") || c.RPID != strings.ToLower(c.RPID) {
+		return fmt.Errorf("invalid WEBAUTHN_RP_ID: %q must be a lowercase domain without scheme or port", c.RPID)
+	}
+	if c.RPName == "" {
+		return fmt.Errorf("invalid WEBAUTHN_RP_NAME: must not be empty")
+	}
+	if len(c.Origins) == 0 {
+		return fmt.Errorf("invalid WEBAUTHN_ORIGINS: at least one origin is required")
+	}
+	for _, origin := range c.Origins {
+		host, err := parseOrigin(origin)
+		if err != nil {
+			return fmt.Errorf("invalid WEBAUTHN_ORIGINS: %w", err)
+		}
+		if host != c.RPID && !strings.HasSuffix(host, "."+c.RPID) {
+			return fmt.Errorf("invalid WEBAUTHN_ORIGINS: %q is not within WEBAUTHN_RP_ID %q", origin, c.RPID)
+		}
+	}
+	for _, origin := range c.RelatedOrigins {
+		if _, err := parseOrigin(origin); err != nil {
+			return fmt.Errorf("invalid WEBAUTHN_RELATED_ORIGINS: %w", err)
+		}
+	}
+	if c.RegistrationTimeout <= 0 || c.AuthenticationTimeout <= 0 {
+		return fmt.Errorf("invalid WebAuthn timeout: must be positive")
+	}
+	if len(c.Algorithms) == 0 {
+		return fmt.Errorf("invalid WEBAUTHN_ALGORITHMS: at least one algorithm is required")
+	}
+	for _, alg := range c.Algorithms {
+		if !containsInt(webauthn.SupportedAlgorithms, alg) {
+			return fmt.Errorf("invalid WEBAUTHN_ALGORITHMS: unsupported algorithm %d", alg)
+		}
+	}
+	switch c.Attestation {
+	case "none", "indirect", "direct", "enterprise":
+	default:
+		return fmt.Errorf("invalid WEBAUTHN_ATTESTATION: %q", c.Attestation)
+	}
+	if !isRequirement(c.UserVerification) {
+		return fmt.Errorf("invalid WEBAUTHN_USER_VERIFICATION: %q", c.UserVerification)
+	}
+	if !isRequirement(c.ResidentKey) {
+		return fmt.Errorf("invalid WEBAUTHN_RESIDENT_KEY: %q", c.ResidentKey)
+	}
+	return c.validateMetadata()
+}
+
+// validateMetadata は認証器のポリシーの設定を確認します
+func (c WebAuthnConfig) validateMetadata() error {
+	if c.MetadataPath != "" && c.MetadataRootCertPath == "" {
+		return fmt.Errorf("WEBAUTHN_MDS_ROOT_CERT is required when WEBAUTHN_MDS_PATH is set")
+	}
+	for _, aaguid := range append(append([]string{}, c.AllowedAAGUIDs...), c.DeniedAAGUIDs...) {
+		if _, err := metadata.NormalizeAAGUID(aaguid); err != nil {
+			return fmt.Errorf("invalid WEBAUTHN_ALLOWED_AAGUIDS or WEBAUTHN_DENIED_AAGUIDS: %w", err)
+		}
+	}
+	if c.MinCertificationLevel != "" {
+		if _, err := metadata.ParseCertificationLevel(c.MinCertificationLevel); err != nil {
+			return fmt.Errorf("invalid WEBAUTHN_MIN_CERTIFICATION_LEVEL: %w", err)
+		}
+	}
+	if c.RequiresCertifiedAuthenticator() {
+		// 機種の確認にはMDSのルート証明書につながるアテステーションが必要
+		if c.MetadataPath == "" {
+			return fmt.Errorf("WEBAUTHN_MDS_PATH is required when WEBAUTHN_ALLOWED_AAGUIDS or WEBAUTHN_MIN_CERTIFICATION_LEVEL is set")
+		}
+		if c.Attestation != "direct" && c.Attestation != "enterprise" {
+			return fmt.Errorf("WEBAUTHN_ATTESTATION must be direct or enterprise when WEBAUTHN_ALLOWED_AAGUIDS or WEBAUTHN_MIN_CERTIFICATION_LEVEL is set")
+		}
+	}
+	return nil
+}
+
+// loadWebAuthnConfig は環境変数からパスキーの設定を読み込み、検証します
+func loadWebAuthnConfig() (WebAuthnConfig, error) {
+	timeout, err := getEnvDuration("WEBAUTHN_TIMEOUT", defaultWebAuthnTimeout)
+	if err != nil {
+		return WebAuthnConfig{}, err
+	}
+	registrationTimeout, err := getEnvDuration("WEBAUTHN_REGISTRATION_TIMEOUT", timeout)
+	if err != nil {
+		return WebAuthnConfig{}, err
+	}
+	authenticationTimeout, err := getEnvDuration("WEBAUTHN_AUTHENTICATION_TIMEOUT", timeout)
+	if err != nil {
+		return WebAuthnConfig{}, err
+	}
+	metadataReloadInterval, err := getEnvDuration("WEBAUTHN_MDS_RELOAD_INTERVAL", defaultWebAuthnMDSReload)
+	if err != nil {
+		return WebAuthnConfig{}, err
+	}
+
+	var algorithms []int
+	for _, name := range getEnvList("WEBAUTHN_ALGORITHMS", defaultWebAuthnAlgorithms) {
+		alg, ok := webauthnAlgorithms[name]
+		if !ok {
+			return WebAuthnConfig{}, fmt.Errorf("invalid WEBAUTHN_ALGORITHMS: unknown algorithm %q", name)
+		}
+		algorithms = append(algorithms, alg)
+	}
+
+	c := WebAuthnConfig{
+		RPID:                  getEnv("WEBAUTHN_RP_ID", defaultWebAuthnRPID),
+		RPName:                getEnv("WEBAUTHN_RP_NAME", defaultWebAuthnRPName),
+		Origins:               getEnvList("WEBAUTHN_ORIGINS", defaultWebAuthnOrigins),
+		RelatedOrigins:        getEnvList("WEBAUTHN_RELATED_ORIGINS", ""),
+		RegistrationTimeout:   registrationTimeout,
+		AuthenticationTimeout: authenticationTimeout,
+		Algorithms:            algorithms,
+		Attestation:           getEnv("WEBAUTHN_ATTESTATION", defaultWebAuthnAttestation),
+		UserVerification:      getEnv("WEBAUTHN_USER_VERIFICATION", defaultWebAuthnUserVerification),
+		ResidentKey:           getEnv("WEBAUTHN_RESIDENT_KEY", defaultWebAuthnResidentKey),
+
+		MetadataPath:           getEnv("WEBAUTHN_MDS_PATH", ""),
+		MetadataRootCertPath:   getEnv("WEBAUTHN_MDS_ROOT_CERT", ""),
+		MetadataReloadInterval: metadataReloadInterval,
+		AllowedAAGUIDs:         getEnvList("WEBAUTHN_ALLOWED_AAGUIDS", ""),
+		DeniedAAGUIDs:          getEnvList("WEBAUTHN_DENIED_AAGUIDS", ""),
+		MinCertificationLevel:  getEnv("WEBAUTHN_MIN_CERTIFICATION_LEVEL", ""),
+	}
+	if err := c.Validate(); err != nil {
+		return WebAuthnConfig{}, err
+	}
+	return c, nil
+}
+
+// parseOrigin はオリジン（スキーム・ホスト・ポートのみ）を検証し、ホスト名を返します
+// クライアントデータのoriginと文字列で比較するため、末尾のスラッシュも許可しません
+// httpはローカル開発用にlocalhostだけ許可します
+func parseOrigin(origin string) (string, error) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return "", fmt.Errorf("%q: %w", origin, err)
+	}
+	if u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("%q is not an origin", origin)
+	}
+	host := u.Hostname()
+	if u.Scheme != "https" && !(u.Scheme == "http" && host == "localhost") {
+		return "", fmt.Errorf("%q must use https", origin)
+	}
+	return host, nil
+}
+
+// getEnvList はカンマ区切りの環境変数を読み込みます（空の要素は無視します）
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, v := range strings.Split(getEnv(key, defaultValue), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// isRequirement は UserVerification・ResidentKey に指定できる値かを判定します
+func isRequirement(v string) bool {
+	return v == "required" || v == "preferred" || v == "discouraged"
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+)
+
+func TestLoadWebAuthnConfigDefaults(t *testing.T) {
+	// テスト実行
+	c, err := loadWebAuthnConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "poc-authlete.local", c.RPID)
+	assert.Equal(t, []string{"https://poc-authlete.local"}, c.Origins)
+	assert.Equal(t, 5*time.Minute, c.RegistrationTimeout)
+	assert.Equal(t, []int{webauthn.AlgES256, webauthn.AlgEdDSA, webauthn.AlgRS256}, c.Algorithms)
+}
+
+func TestLoadWebAuthnConfig(t *testing.T) {
+	// テストケースの準備
+	t.Setenv("WEBAUTHN_RP_ID", "example.com")
+	t.Setenv("WEBAUTHN_ORIGINS", "https://example.com, https://login.example.com:8443")
+	t.Setenv("WEBAUTHN_RELATED_ORIGINS", "https://example.co.jp")
+	t.Setenv("WEBAUTHN_TIMEOUT", "2m")
+	t.Setenv("WEBAUTHN_AUTHENTICATION_TIMEOUT", "30s")
+	t.Setenv("WEBAUTHN_ALGORITHMS", "EdDSA,ES256")
+	t.Setenv("WEBAUTHN_USER_VERIFICATION", "required")
+
+	// テスト実行
+	c, err := loadWebAuthnConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com", "https://login.example.com:8443", "https://example.co.jp"}, c.AllowedOrigins())
+	assert.Equal(t, 2*time.Minute, c.RegistrationTimeout)
+	assert.Equal(t, 30*time.Second, c.AuthenticationTimeout)
+	assert.Equal(t, []int{webauthn.AlgEdDSA, webauthn.AlgES256}, c.Algorithms)
+	assert.Equal(t, "required", c.UserVerification)
+}
+
+func TestLoadWebAuthnConfigMetadata(t *testing.T) {
+	// テストケースの準備
+	t.Setenv("WEBAUTHN_ATTESTATION", "direct")
+	t.Setenv("WEBAUTHN_MDS_PATH", "blob.jwt")
+	t.Setenv("WEBAUTHN_MDS_ROOT_CERT", "root.pem")
+	t.Setenv("WEBAUTHN_MDS_RELOAD_INTERVAL", "6h")
+	t.Setenv("WEBAUTHN_ALLOWED_AAGUIDS", "cb69481e-8ff7-4039-93ec-0a2729a154a8")
+	t.Setenv("WEBAUTHN_MIN_CERTIFICATION_LEVEL", "L1")
+
+	// テスト実行
+	c, err := loadWebAuthnConfig()
+
+	// アサーション
+	require.NoError(t, err)
+	assert.True(t, c.RequiresCertifiedAuthenticator())
+	assert.Equal(t, 6*time.Hour, c.MetadataReloadInterval)
+	assert.Equal(t, []string{"cb69481e-8ff7-4039-93ec-0a2729a154a8"}, c.AllowedAAGUIDs)
+}
+
+func TestLoadWebAuthnConfigInvalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"RP IDにスキームを含む":    {"WEBAUTHN_RP_ID": "https://example.com"},
+		"RP IDの外のオリジン":     {"WEBAUTHN_RP_ID": "example.com", "WEBAUTHN_ORIGINS": "https://example.org"},
+		"ドメインの途中で一致するオリジン": {"WEBAUTHN_RP_ID": "example.com", "WEBAUTHN_ORIGINS": "https://badexample.com"},
+		"httpのオリジン":        {"WEBAUTHN_ORIGINS": "http://poc-authlete.local"},
+		"パスを含むオリジン":        {"WEBAUTHN_ORIGINS": "https://poc-authlete.local/login"},
+		"不正な関連オリジン":        {"WEBAUTHN_RELATED_ORIGINS": "example.co.jp"},
+		"未対応のアルゴリズム":       {"WEBAUTHN_ALGORITHMS": "ES256,PS256"},
+		"不正なアテステーション":      {"WEBAUTHN_ATTESTATION": "full"},
+		"不正なユーザー検証":        {"WEBAUTHN_USER_VERIFICATION": "always"},
+		"不正なresidentKey":   {"WEBAUTHN_RESIDENT_KEY": "yes"},
+		"不正なタイムアウト":        {"WEBAUTHN_TIMEOUT": "-1s"},
+		"ルート証明書の無いMDS":     {"WEBAUTHN_MDS_PATH": "blob.jwt"},
+		"不正なAAGUID":        {"WEBAUTHN_DENIED_AAGUIDS": "yubikey"},
+		"不正な認定レベル":         {"WEBAUTHN_MIN_CERTIFICATION_LEVEL": "L4"},
+		"MDSの無い許可リスト": {
+			"WEBAUTHN_ALLOWED_AAGUIDS": "cb69481e-8ff7-4039-93ec-0a2729a154a8",
+			"WEBAUTHN_ATTESTATION":     "direct",
+		},
+		"アテステーションを要求しない認定レベル": {
+			"WEBAUTHN_MDS_PATH":                "blob.jwt",
+			"WEBAUTHN_MDS_ROOT_CERT":           "root.pem",
+			"WEBAUTHN_MIN_CERTIFICATION_LEVEL": "L1",
+		},
+	}
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			// テストケースの準備
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			// テスト実行
+			_, err := loadWebAuthnConfig()
+
+			// アサーション
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Package mailtemplate はアカウントのメールをhtml/templateで言語ごとに描画します
+package mailtemplate
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// テンプレートの名前です
+const (
+	EmailVerification = "email_verification"
+	PasswordReset     = "password_reset"
+)
+
+// templates/<言語>/<名前>.html に、件名の "subject" と本文の "body" を定義したテンプレートを置きます
+//
+//go:embed templates
+var files embed.FS
+
+// Data はテンプレートに渡す値です
+type Data struct {
+	DisplayName string
+	// URL はメールに載せるリンクです
+	URL       string
+	ExpiresAt time.Time
+}
+
+// Renderer はテンプレートを言語ごとに保持します
+type Renderer struct {
+	templates       map[string]map[string]*template.Template
+	defaultLanguage string
+}
+
+// NewRenderer は埋め込んだテンプレートを読み込みます
+// defaultLanguage のテンプレートが無い場合や、言語によってテンプレートが欠けている場合はエラーを返します
+func NewRenderer(defaultLanguage string) (*Renderer, error) {
+	r := &Renderer{
+		templates:       make(map[string]map[string]*template.Template),
+		defaultLanguage: defaultLanguage,
+	}
+
+	paths, err := fs.Glob(files, "templates/*/*.html")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		lang := path.Base(path.Dir(p))
+		name := strings.TrimSuffix(path.Base(p), ".html")
+		t, err := template.ParseFS(files, p)
+		if err != nil {
+			return nil, err
+		}
+		if t.Lookup("subject") == nil || t.Lookup("body") == nil {
+			return nil, fmt.Errorf("mail template %s must define subject and body", p)
+		}
+		if r.templates[lang] == nil {
+			r.templates[lang] = make(map[string]*template.Template)
+		}
+		r.templates[lang][name] = t
+	}
+
+	if _, ok := r.templates[defaultLanguage]; !ok {
+		return nil, fmt.Errorf("unsupported mail language %q", defaultLanguage)
+	}
+	for lang, templates := range r.templates {
+		for _, name := range []string{EmailVerification, PasswordReset} {
+			if _, ok := templates[name]; !ok {
+				return nil, fmt.Errorf("mail template %s is missing for %s", name, lang)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Languages は対応している言語を返します
+func (r *Renderer) Languages() []string {
+	languages := make([]string, 0, len(r.templates))
+	for lang := range r.templates {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Render は name のテンプレートを Accept-Language に合う言語で描画し、件名とHTMLの本文を返します
+func (r *Renderer) Render(name, acceptLanguage string, data Data) (subject, body string, err error) {
+	t, ok := r.templates[r.Language(acceptLanguage)][name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+	buf.Reset()
+	if err := t.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", err
+	}
+	return subject, buf.String(), nil
+}
+
+// Language は Accept-Language（例: "en-US,en;q=0.9,ja;q=0.8"）から、対応している言語のうち最も優先度の高いものを選びます
+// 地域を指定した言語は主言語で照合し、対応する言語が無い場合は既定の言語を返します
+func (r *Renderer) Language(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag == "" || q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{strings.ToLower(strings.TrimSpace(tag)), q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		primary, _, _ := strings.Cut(t.tag, "-")
+		if _, ok := r.templates[primary]; ok {
+			return primary
+		}
+	}
+	return r.defaultLanguage
+}
@@ -0,0 +1,66 @@
+package mailtemplate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	// テストケースの準備
+	r, err := NewRenderer("ja")
+	require.NoError(t, err)
+	data := Data{
+		DisplayName: `<script>alert("x")</script>`,
+		URL:         "https://poc-authlete.local/account/verify-email?token=abc.def",
+		ExpiresAt:   time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC),
+	}
+
+	// テスト実行
+	subject, body, err := r.Render(EmailVerification, "en-US,en;q=0.9", data)
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, "Verify your email address", subject)
+	assert.Contains(t, body, `href="https://poc-authlete.local/account/verify-email?token=abc.def"`)
+	assert.Contains(t, body, "2024-01-02 03:04 UTC")
+	// 表示名はエスケープする
+	assert.NotContains(t, body, "<script>")
+
+	// 対応していない言語は既定の言語で描画する
+	subject, _, err = r.Render(PasswordReset, "fr", data)
+	require.NoError(t, err)
+	assert.Equal(t, "パスワードの再設定", subject)
+
+	_, _, err = r.Render("unknown", "ja", data)
+	assert.Error(t, err)
+}
+
+func TestLanguage(t *testing.T) {
+	r, err := NewRenderer("ja")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en", "ja"}, r.Languages())
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "ja"},
+		{"en", "en"},
+		{"en-GB", "en"},
+		{"fr,en;q=0.5", "en"},
+		{"en;q=0.5,ja;q=0.8", "ja"},
+		{"en;q=0", "ja"},
+		{"*", "ja"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.Language(tt.acceptLanguage))
+		})
+	}
+
+	_, err = NewRenderer("fr")
+	assert.Error(t, err)
+}
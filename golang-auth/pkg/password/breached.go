@@ -0,0 +1,93 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedList は漏洩したパスワードの一覧（Have I Been Pwned の Pwned Passwords）をローカルのファイルから引きます
+// ファイルは k-匿名性のレンジAPIからダウンロードしたSHA-1ハッシュを "HASH:COUNT" の形式で1行ずつ昇順に並べたものです
+// （haveibeenpwned-downloader の単一ファイルの出力と同じ形式）
+// 数十GBになるため全体は読み込まず、ファイル上で二分探索します
+type BreachedList struct {
+	file *os.File
+	size int64
+}
+
+// OpenBreachedList は漏洩したパスワードの一覧のファイルを開きます
+func OpenBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &BreachedList{file: f, size: info.Size()}, nil
+}
+
+// Close はファイルを閉じます
+func (l *BreachedList) Close() error {
+	return l.file.Close()
+}
+
+// Contains はパスワードが一覧に含まれるかを返します
+func (l *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// lo は常に行の先頭を指し、一致する行があればその先頭は [lo, hi) にある
+	lo, hi := int64(0), l.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, next, hash, err := l.lineFrom(mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+		switch c := strings.Compare(hash, target); {
+		case c == 0:
+			return true, nil
+		case c < 0:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineFrom は offset 以降で最初に始まる行を読み、その先頭・次の行の先頭・ハッシュを返します
+func (l *BreachedList) lineFrom(offset int64) (start, next int64, hash string, err error) {
+	start = offset
+	if offset > 0 {
+		// 直前の文字から読み、行の途中であれば改行まで読み飛ばす
+		r := bufio.NewReader(io.NewSectionReader(l.file, offset-1, l.size-offset+1))
+		skipped, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return 0, 0, "", err
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+	if start >= l.size {
+		return l.size, l.size, "", nil
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(l.file, start, l.size-start))
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, 0, "", err
+	}
+	next = start + int64(len(line))
+	hash, _, _ = strings.Cut(strings.TrimRight(line, "\r\n"), ":")
+	return start, next, strings.ToUpper(hash), nil
+}
@@ -0,0 +1,210 @@
+// Package password はパスワードのハッシュ化と検証を行います
+// 新しいハッシュはargon2idで作成し、移行元から取り込んだbcrypt・PBKDF2のハッシュも検証できます
+// ハッシュはPHC文字列形式（$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>）で保存します
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	// ErrMismatch はパスワードがハッシュと一致しないことを表します
+	ErrMismatch = errors.New("password does not match")
+	// ErrUnsupportedHash は検証できない形式のハッシュであることを表します
+	ErrUnsupportedHash = errors.New("unsupported password hash")
+)
+
+// Params はargon2idのパラメータです
+type Params struct {
+	// Memory は使用するメモリ（KiB）です
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams はRFC 9106で推奨されているパラメータです
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Validate はargon2idで使えるパラメータかを確認します
+func (p Params) Validate() error {
+	if p.Iterations < 1 {
+		return errors.New("argon2id iterations must be at least 1")
+	}
+	if p.Parallelism < 1 {
+		return errors.New("argon2id parallelism must be at least 1")
+	}
+	if p.Memory < 8*uint32(p.Parallelism) {
+		return errors.New("argon2id memory must be at least 8 KiB per lane")
+	}
+	return nil
+}
+
+// Hasher は設定したパラメータでパスワードをハッシュ化し、保存されたハッシュを検証します
+type Hasher struct {
+	params Params
+}
+
+// NewHasher は新しいHasherを作成します（SaltLength・KeyLength が0の場合は既定値を使います）
+func NewHasher(params Params) (*Hasher, error) {
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultParams.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultParams.KeyLength
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &Hasher{params: params}, nil
+}
+
+// Hash はパスワードをargon2idでハッシュ化し、PHC文字列形式で返します
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify はパスワードがハッシュと一致するかを確認します
+// 一致した場合、ハッシュを現在のパラメータのargon2idで作り直すべきか（rehash）を返します
+// 一致しない場合は ErrMismatch、形式が不明な場合は ErrUnsupportedHash を返します
+func (h *Hasher) Verify(password, encoded string) (rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return h.verifyArgon2id(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, ErrMismatch
+			}
+			return false, fmt.Errorf("%w: %v", ErrUnsupportedHash, err)
+		}
+		return true, nil
+	case strings.HasPrefix(encoded, "$pbkdf2-"):
+		if err := verifyPBKDF2(password, encoded); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, ErrUnsupportedHash
+	}
+}
+
+func (h *Hasher) verifyArgon2id(password, encoded string) (bool, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, ErrUnsupportedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrUnsupportedHash
+	}
+	var p Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return false, ErrUnsupportedHash
+	}
+	if err := p.Validate(); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrUnsupportedHash, err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrUnsupportedHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false, ErrUnsupportedHash
+	}
+
+	got := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return false, ErrMismatch
+	}
+	rehash := p.Memory != h.params.Memory || p.Iterations != h.params.Iterations || p.Parallelism != h.params.Parallelism ||
+		uint32(len(salt)) < h.params.SaltLength || uint32(len(want)) != h.params.KeyLength
+	return rehash, nil
+}
+
+// verifyPBKDF2 はPBKDF2のハッシュを検証します
+// PHC形式（$pbkdf2-sha256$i=600000,l=32$<salt>$<hash>）と、passlibの形式（$pbkdf2-sha256$29000$<salt>$<hash>）に対応します
+func verifyPBKDF2(password, encoded string) error {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return ErrUnsupportedHash
+	}
+	var newHash func() hash.Hash
+	switch parts[1] {
+	case "pbkdf2-sha256":
+		newHash = sha256.New
+	case "pbkdf2-sha512":
+		newHash = sha512.New
+	default:
+		return ErrUnsupportedHash
+	}
+
+	iterations, err := pbkdf2Iterations(parts[2])
+	if err != nil {
+		return err
+	}
+	salt, err := decodePBKDF2Base64(parts[3])
+	if err != nil {
+		return ErrUnsupportedHash
+	}
+	want, err := decodePBKDF2Base64(parts[4])
+	if err != nil || len(want) == 0 {
+		return ErrUnsupportedHash
+	}
+
+	got := pbkdf2.Key([]byte(password), salt, iterations, len(want), newHash)
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// pbkdf2Iterations は "i=600000,l=32" または "29000" 形式の反復回数を読み取ります
+// 鍵の長さ（l）はハッシュの長さから決まるため読み飛ばします
+func pbkdf2Iterations(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return n, nil
+	}
+	for _, param := range strings.Split(s, ",") {
+		if v, ok := strings.CutPrefix(param, "i="); ok {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				return n, nil
+			}
+		}
+	}
+	return 0, ErrUnsupportedHash
+}
+
+// decodePBKDF2Base64 はPHCのBase64（パディングなし）と、「+」の代わりに「.」を使うpasslibのBase64を読み取ります
+func decodePBKDF2Base64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(strings.TrimRight(s, "="), ".", "+"))
+}
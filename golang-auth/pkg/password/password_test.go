@@ -0,0 +1,156 @@
+package password
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// testParams はテストを速くするための軽いパラメータです
+var testParams = Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestHashAndVerify(t *testing.T) {
+	// テストケースの準備
+	hasher, err := NewHasher(testParams)
+	require.NoError(t, err)
+
+	// テスト実行
+	encoded, err := hasher.Hash("correct horse battery staple")
+
+	// アサーション
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"))
+	rehash, err := hasher.Verify("correct horse battery staple", encoded)
+	assert.NoError(t, err)
+	assert.False(t, rehash)
+	_, err = hasher.Verify("wrong password", encoded)
+	assert.ErrorIs(t, err, ErrMismatch)
+
+	// ソルトが異なるため、同じパスワードでも別のハッシュになる
+	again, err := hasher.Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, again)
+}
+
+func TestVerifyRehash(t *testing.T) {
+	weak, err := NewHasher(testParams)
+	require.NoError(t, err)
+	argon2Hash, err := weak.Hash("password123")
+	require.NoError(t, err)
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	salt := []byte("0123456789abcdef")
+	key := pbkdf2.Key([]byte("password123"), salt, 1000, 32, sha256.New)
+	phcPBKDF2 := "$pbkdf2-sha256$i=1000,l=32$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key)
+	passlibPBKDF2 := "$pbkdf2-sha256$1000$" + strings.ReplaceAll(base64.RawStdEncoding.EncodeToString(salt), "+", ".") + "$" + strings.ReplaceAll(base64.RawStdEncoding.EncodeToString(key), "+", ".")
+
+	hasher, err := NewHasher(Params{Memory: 128, Iterations: 2, Parallelism: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"パラメータの異なるargon2id", argon2Hash},
+		{"bcrypt", string(bcryptHash)},
+		{"PHC形式のPBKDF2", phcPBKDF2},
+		{"passlib形式のPBKDF2", passlibPBKDF2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テスト実行
+			rehash, err := hasher.Verify("password123", tt.encoded)
+
+			// アサーション
+			require.NoError(t, err)
+			assert.True(t, rehash)
+			_, err = hasher.Verify("password124", tt.encoded)
+			assert.ErrorIs(t, err, ErrMismatch)
+		})
+	}
+}
+
+func TestVerifyUnsupportedHash(t *testing.T) {
+	hasher, err := NewHasher(testParams)
+	require.NoError(t, err)
+
+	for _, encoded := range []string{
+		"",
+		"plain-text",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$pbkdf2-md5$1000$c2FsdA$aGFzaA",
+	} {
+		// テスト実行・アサーション
+		_, err := hasher.Verify("password123", encoded)
+		assert.ErrorIs(t, err, ErrUnsupportedHash, encoded)
+	}
+}
+
+func TestBreachedList(t *testing.T) {
+	// テストケースの準備
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	// SHA-1("123456")   = 7C4A8D09CA3762AF61E59520943DC26494F8941B
+	lines := []string{
+		"000000005AD76BD555C1D6D771DE417A4B87E4B4:4",
+		"00000000A8DAE4228F821FB418F59826079BF368:3",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+		"7C4A8D09CA3762AF61E59520943DC26494F8941B:37359195",
+		"FFFFFFFEE791CBAC0F6305CAF0CEE06BBE131160:2",
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600))
+	list, err := OpenBreachedList(path)
+	require.NoError(t, err)
+	defer list.Close()
+
+	// テスト実行・アサーション
+	for _, password := range []string{"password", "123456"} {
+		found, err := list.Contains(password)
+		require.NoError(t, err)
+		assert.True(t, found, password)
+	}
+	for _, password := range []string{"correct horse battery staple", ""} {
+		found, err := list.Contains(password)
+		require.NoError(t, err)
+		assert.False(t, found, password)
+	}
+}
+
+func TestBreachedListLarge(t *testing.T) {
+	// テストケースの準備
+	var lines []string
+	for i := 0; i < 1000; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("breached-%d", i)))
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":1")
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+	list, err := OpenBreachedList(path)
+	require.NoError(t, err)
+	defer list.Close()
+
+	// テスト実行・アサーション
+	for i := 0; i < 1000; i++ {
+		found, err := list.Contains(fmt.Sprintf("breached-%d", i))
+		require.NoError(t, err)
+		require.True(t, found, i)
+
+		found, err = list.Contains(fmt.Sprintf("safe-%d", i))
+		require.NoError(t, err)
+		require.False(t, found, i)
+	}
+}
@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"bytes"
+	"image/png"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret は RFC 6238 Appendix B のSHA1のテストベクターの秘密鍵です
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestCode(t *testing.T) {
+	// RFC 6238 の8桁のコードの下6桁
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, Code(rfc6238Secret, Step(time.Unix(tt.unix, 0))))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// テストケースの準備
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"現在のコード", Code(rfc6238Secret, step), 1, step, true},
+		{"1つ前のコード", Code(rfc6238Secret, step-1), 1, step - 1, true},
+		{"1つ後のコード", Code(rfc6238Secret, step+1), 1, step + 1, true},
+		{"許容範囲外のコード", Code(rfc6238Secret, step-2), 1, 0, false},
+		{"ずれを許容しない", Code(rfc6238Secret, step-1), 0, 0, false},
+		{"桁数が違う", "12345", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テスト実行
+			got, ok := Validate(rfc6238Secret, tt.code, now, tt.skew)
+
+			// アサーション
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStep, got)
+		})
+	}
+}
+
+func TestURIAndQRCode(t *testing.T) {
+	// テストケースの準備
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, SecretSize)
+
+	// テスト実行
+	uri := URI(secret, "Passkey Demo", "alice@example.com")
+	image, err := QRCode(uri)
+
+	// アサーション
+	u, err2 := url.Parse(uri)
+	require.NoError(t, err2)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Passkey Demo:alice@example.com", u.Path)
+	assert.Contains(t, uri, "issuer=Passkey%20Demo")
+	assert.Equal(t, EncodeSecret(secret), u.Query().Get("secret"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+
+	require.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(image))
+	assert.NoError(t, err)
+}
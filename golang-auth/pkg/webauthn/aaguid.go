@@ -0,0 +1,24 @@
+package webauthn
+
+import "encoding/hex"
+
+// knownAuthenticators はAAGUIDから認証器の名前を引くための表です
+// 一覧画面の表示用で、認証器の信頼性の判断には使いません
+var knownAuthenticators = map[string]string{
+	"ea9b8d664d011d213ce4b6b48cb575d4": "Google Password Manager",
+	"fbfc3007154e4ecc8c0b6e020557d7bd": "iCloud Keychain",
+	"08987058cadc4b81b6e130de50dcbe96": "Windows Hello",
+	"9ddd1817af5a4672a2b93e3dd95000a9": "Windows Hello",
+	"6028b017b1d44c02b4b3afcdafc96bb2": "Windows Hello",
+	"adce000235bcc60a648b0b25f1f05503": "Chrome on Mac",
+	"bada5566a7aa401fbd9645619a55120d": "1Password",
+	"d548826e79b4db40a3d811116f7e8349": "Bitwarden",
+	"531126d6e717415c93203d9aa6981239": "Dashlane",
+	"cb69481e8ff7403993ec0a2729a154a8": "YubiKey 5 Series",
+	"fa2b99dc9e3942578f924a30d23c4118": "YubiKey 5 Series with NFC",
+}
+
+// AuthenticatorName はAAGUIDに対応する認証器の名前を返します（不明な場合は空文字）
+func AuthenticatorName(aaguid []byte) string {
+	return knownAuthenticators[hex.EncodeToString(aaguid)]
+}
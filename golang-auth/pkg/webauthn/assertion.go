@@ -0,0 +1,102 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// AssertionOptions は認証レスポンスの検証に使うRelying Partyの期待値です
+type AssertionOptions struct {
+	// Challenge は認証開始時に発行したチャレンジです
+	Challenge               []byte
+	RPID                    string
+	Origins                 []string
+	RequireUserVerification bool
+	// AllowCredentials は認証開始時に allowCredentials で指定したクレデンシャルIDです（空の場合は制限しません）
+	AllowCredentials [][]byte
+}
+
+// StoredCredential は登録時に保存したクレデンシャルの情報です
+type StoredCredential struct {
+	ID []byte
+	// PublicKey はCOSE形式の公開鍵です
+	PublicKey  []byte
+	UserHandle []byte
+}
+
+// Assertion は検証済みの認証結果です
+type Assertion struct {
+	CredentialID []byte
+	// SignCount は認証器が返した署名カウンターです
+	// 保存済みの値より増えているかの判定は、保存先で不可分に行う必要があるため呼び出し側の責務です
+	SignCount      uint32
+	UserVerified   bool
+	BackupEligible bool
+	BackupState    bool
+}
+
+// VerifyAssertion は navigator.credentials.get() のレスポンスを保存済みのクレデンシャルで検証します
+// https://www.w3.org/TR/webauthn-2/#sctn-verifying-assertion
+func VerifyAssertion(opts AssertionOptions, stored StoredCredential, credential AuthenticationCredential) (*Assertion, error) {
+	if err := checkCredential(credential.Type, credential.ID, credential.RawID); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(credential.RawID, stored.ID) {
+		return nil, verificationError("credential ID does not match the stored credential")
+	}
+	if len(opts.AllowCredentials) > 0 && !containsBytes(opts.AllowCredentials, credential.RawID) {
+		return nil, verificationError("credential was not allowed for this ceremony")
+	}
+
+	// userHandle はクレデンシャルの所有者と一致しなければならない
+	// allowCredentials を指定しない（discoverable credential の）場合はユーザーを特定するために必須
+	userHandle := credential.Response.UserHandle
+	if len(userHandle) == 0 && len(opts.AllowCredentials) == 0 {
+		return nil, verificationError("user handle is required for discoverable credentials")
+	}
+	if len(userHandle) > 0 && !bytes.Equal(userHandle, stored.UserHandle) {
+		return nil, verificationError("user handle does not match the credential owner")
+	}
+
+	if _, err := verifyClientData(credential.Response.ClientDataJSON, ClientDataTypeGet, opts.Challenge, opts.Origins); err != nil {
+		return nil, err
+	}
+
+	authData, err := ParseAuthenticatorData(credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	if err := authData.verifyRPIDHash(opts.RPID); err != nil {
+		return nil, err
+	}
+	if err := authData.verifyUser(opts.RequireUserVerification); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := ParsePublicKey(stored.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(credential.Response.ClientDataJSON)
+	signed := append(append([]byte{}, authData.Raw...), clientDataHash[:]...)
+	if err := publicKey.Verify(signed, credential.Response.Signature); err != nil {
+		return nil, err
+	}
+
+	return &Assertion{
+		CredentialID:   credential.RawID,
+		SignCount:      authData.SignCount,
+		UserVerified:   authData.UserVerified(),
+		BackupEligible: authData.BackupEligible(),
+		BackupState:    authData.BackupState(),
+	}, nil
+}
+
+func containsBytes(list [][]byte, v []byte) bool {
+	for _, item := range list {
+		if bytes.Equal(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package webauthn_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/webauthntest"
+)
+
+// register は仮想認証器でクレデンシャルを作成し、サーバーが保存する情報を返します
+func register(t *testing.T, authenticator *webauthntest.Authenticator) webauthn.StoredCredential {
+	t.Helper()
+	challenge := []byte("registration-challenge-0123456789")
+	credential, err := authenticator.Create(challenge, []byte("user-1"))
+	require.NoError(t, err)
+
+	registration, err := webauthn.VerifyRegistration(webauthn.RegistrationOptions{
+		Challenge: challenge,
+		RPID:      authenticator.RPID,
+		Origins:   []string{authenticator.Origin},
+	}, credential)
+	require.NoError(t, err)
+
+	return webauthn.StoredCredential{
+		ID:         registration.CredentialID,
+		PublicKey:  registration.PublicKey,
+		UserHandle: []byte("user-1"),
+	}
+}
+
+func assertionOptions(challenge []byte) webauthn.AssertionOptions {
+	return webauthn.AssertionOptions{
+		Challenge:               challenge,
+		RPID:                    testRPID,
+		Origins:                 []string{testOrigin},
+		RequireUserVerification: true,
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	for _, alg := range webauthn.SupportedAlgorithms {
+		authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+		authenticator.Algorithm = alg
+		stored := register(t, authenticator)
+		challenge := []byte("authentication-challenge-0123456789")
+
+		credential, err := authenticator.Get(stored.ID, challenge)
+		require.NoError(t, err)
+
+		// テスト実行
+		assertion, err := webauthn.VerifyAssertion(assertionOptions(challenge), stored, credential)
+		require.NoError(t, err, "alg %d", alg)
+		assert.Equal(t, stored.ID, assertion.CredentialID)
+		assert.Equal(t, uint32(1), assertion.SignCount)
+		assert.True(t, assertion.UserVerified)
+	}
+}
+
+func TestVerifyAssertionRejectsInvalidResponses(t *testing.T) {
+	challenge := []byte("authentication-challenge-0123456789")
+
+	tests := []struct {
+		name   string
+		modify func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions)
+	}{
+		{"wrong challenge", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			opts.Challenge = []byte("another-challenge")
+		}},
+		{"wrong origin", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			opts.Origins = []string{"https://another.example"}
+		}},
+		{"wrong RP ID", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			opts.RPID = "another.example"
+		}},
+		{"tampered authenticator data", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			credential.Response.AuthenticatorData[36]++
+		}},
+		{"tampered signature", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			credential.Response.Signature[len(credential.Response.Signature)-1] ^= 0xff
+		}},
+		{"another user's handle", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			credential.Response.UserHandle = []byte("user-2")
+		}},
+		{"credential not allowed", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			opts.AllowCredentials = [][]byte{[]byte("another-credential")}
+		}},
+		{"another credential's key", func(credential *webauthn.AuthenticationCredential, stored *webauthn.StoredCredential, opts *webauthn.AssertionOptions) {
+			stored.PublicKey = register(t, webauthntest.NewAuthenticator(testRPID, testOrigin)).PublicKey
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+			stored := register(t, authenticator)
+			credential, err := authenticator.Get(stored.ID, challenge)
+			require.NoError(t, err)
+			opts := assertionOptions(challenge)
+			tt.modify(&credential, &stored, &opts)
+
+			// テスト実行
+			_, err = webauthn.VerifyAssertion(opts, stored, credential)
+			assert.ErrorIs(t, err, webauthn.ErrVerification)
+		})
+	}
+}
+
+func TestVerifyAssertionRequiresUserVerification(t *testing.T) {
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	stored := register(t, authenticator)
+	authenticator.Flags = webauthn.FlagUserPresent
+	challenge := []byte("authentication-challenge-0123456789")
+	credential, err := authenticator.Get(stored.ID, challenge)
+	require.NoError(t, err)
+
+	// テスト実行
+	_, err = webauthn.VerifyAssertion(assertionOptions(challenge), stored, credential)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+
+	opts := assertionOptions(challenge)
+	opts.RequireUserVerification = false
+	_, err = webauthn.VerifyAssertion(opts, stored, credential)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,299 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// attestationObject は登録時に認証器が返すCBORのオブジェクトです
+type attestationObject struct {
+	Format    string          `cbor:"fmt"`
+	Statement cbor.RawMessage `cbor:"attStmt"`
+	AuthData  []byte          `cbor:"authData"`
+
+	parsedData     *AuthenticatorData
+	clientDataHash []byte
+	credentialKey  *PublicKey
+}
+
+// アテステーションの種類です
+// https://www.w3.org/TR/webauthn-2/#sctn-attestation-types
+const (
+	AttestationTypeNone = "none"
+	// AttestationTypeSelf はクレデンシャル自身の鍵で署名されたもので、認証器の機種は証明しません
+	AttestationTypeSelf = "self"
+	// AttestationTypeBasic は機種ごとのアテステーション鍵で署名されたものです
+	AttestationTypeBasic = "basic"
+	// AttestationTypeAttCA はTPMのようにアテステーションCAが発行した鍵で署名されたものです
+	AttestationTypeAttCA = "attca"
+	// AttestationTypeAnonCA はAppleのように匿名化CAがクレデンシャルごとに発行した証明書によるものです
+	AttestationTypeAnonCA = "anonca"
+)
+
+// AttestationResult はアテステーションステートメントの検証結果です
+type AttestationResult struct {
+	// Type はアテステーションの種類（none、self、basic など）です
+	Type string
+	// TrustPath はアテステーション証明書のチェーン（DER形式、末端の証明書が先頭）です
+	// ステートメントの署名は検証済みですが、チェーンがどのルートにつながるかはポリシーで判断します
+	TrustPath [][]byte
+}
+
+// attestationVerifier はアテステーションの形式ごとの検証処理です
+type attestationVerifier func(obj *attestationObject) (*AttestationResult, error)
+
+// attestationFormats は対応しているアテステーションの形式です
+var attestationFormats = map[string]attestationVerifier{
+	"none":              verifyNoneAttestation,
+	"packed":            verifyPackedAttestation,
+	"fido-u2f":          verifyFIDOU2FAttestation,
+	"tpm":               verifyTPMAttestation,
+	"android-key":       verifyAndroidKeyAttestation,
+	"android-safetynet": verifySafetyNetAttestation,
+	"apple":             verifyAppleAttestation,
+}
+
+// timeNow は現在時刻です（テストで差し替えます）
+var timeNow = time.Now
+
+// oidFIDOGenCEAAGUID はアテステーション証明書に含まれる認証器のAAGUIDの拡張です
+var oidFIDOGenCEAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+func parseAttestationObject(raw []byte, clientDataHash []byte) (*attestationObject, error) {
+	var obj attestationObject
+	if err := cbor.Unmarshal(raw, &obj); err != nil {
+		return nil, verificationError("malformed attestation object: %v", err)
+	}
+	if obj.Format == "" || len(obj.AuthData) == 0 || len(obj.Statement) == 0 {
+		return nil, verificationError("attestation object is incomplete")
+	}
+
+	data, err := ParseAuthenticatorData(obj.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	obj.parsedData = data
+	obj.clientDataHash = clientDataHash
+	return &obj, nil
+}
+
+// verifyStatement はアテステーションの形式に応じてステートメントを検証します
+func (obj *attestationObject) verifyStatement() (*AttestationResult, error) {
+	verify, ok := attestationFormats[obj.Format]
+	if !ok {
+		return nil, verificationError("unsupported attestation format %q", obj.Format)
+	}
+	return verify(obj)
+}
+
+// decodeStatement はステートメントを形式ごとの構造体にデコードします
+func (obj *attestationObject) decodeStatement(v interface{}) error {
+	if err := cbor.Unmarshal(obj.Statement, v); err != nil {
+		return verificationError("malformed %s attestation statement: %v", obj.Format, err)
+	}
+	return nil
+}
+
+// signedData は packed などの形式で署名対象となる authenticatorData || clientDataHash です
+func (obj *attestationObject) signedData() []byte {
+	data := make([]byte, 0, len(obj.AuthData)+len(obj.clientDataHash))
+	data = append(data, obj.AuthData...)
+	return append(data, obj.clientDataHash...)
+}
+
+// verifyNoneAttestation は "none" 形式を検証します（ステートメントは空でなければなりません）
+func verifyNoneAttestation(obj *attestationObject) (*AttestationResult, error) {
+	var statement map[string]cbor.RawMessage
+	if err := obj.decodeStatement(&statement); err != nil {
+		return nil, err
+	}
+	if len(statement) != 0 {
+		return nil, verificationError("none attestation must have an empty statement")
+	}
+	return &AttestationResult{Type: AttestationTypeNone}, nil
+}
+
+// parseTrustPath は x5c の証明書を解析します（先頭がアテステーション証明書です）
+func parseTrustPath(format string, x5c [][]byte) ([]*x509.Certificate, error) {
+	if len(x5c) == 0 {
+		return nil, verificationError("%s attestation requires x5c", format)
+	}
+	certs := make([]*x509.Certificate, 0, len(x5c))
+	for _, der := range x5c {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, verificationError("malformed %s attestation certificate: %v", format, err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// verifyCertificateAAGUID は証明書にAAGUIDの拡張がある場合、authenticatorData のAAGUIDと一致するかを確認します
+func verifyCertificateAAGUID(cert *x509.Certificate, aaguid []byte) error {
+	ext, ok := findExtension(cert, oidFIDOGenCEAAGUID)
+	if !ok {
+		return nil
+	}
+	if ext.Critical {
+		return verificationError("AAGUID extension must not be critical")
+	}
+	var value []byte
+	if rest, err := asn1.Unmarshal(ext.Value, &value); err != nil || len(rest) != 0 {
+		return verificationError("malformed AAGUID extension")
+	}
+	if !bytes.Equal(value, aaguid) {
+		return verificationError("AAGUID in attestation certificate does not match authenticator data")
+	}
+	return nil
+}
+
+// verifyNotCA はアテステーション証明書がCA証明書でないことを確認します
+func verifyNotCA(cert *x509.Certificate) error {
+	if cert.BasicConstraintsValid && cert.IsCA {
+		return verificationError("attestation certificate must not be a CA certificate")
+	}
+	return nil
+}
+
+func findExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) (pkix.Extension, bool) {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return ext, true
+		}
+	}
+	return pkix.Extension{}, false
+}
+
+// sameKey は2つの公開鍵が同じかを判定します
+func sameKey(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// アテステーションの署名で使われるCOSEアルゴリズムです
+const (
+	algES384 = -35
+	algES512 = -36
+	algPS256 = -37
+	algPS384 = -38
+	algPS512 = -39
+	algRS384 = -258
+	algRS512 = -259
+	// algRS1 はTPMのアテステーションでのみ使われます
+	algRS1 = -65535
+)
+
+// signatureAlgorithm はCOSEアルゴリズムの鍵の種類とハッシュ関数です
+type signatureAlgorithm struct {
+	keyType string
+	hash    crypto.Hash
+}
+
+var signatureAlgorithms = map[int]signatureAlgorithm{
+	AlgES256: {"ecdsa", crypto.SHA256},
+	algES384: {"ecdsa", crypto.SHA384},
+	algES512: {"ecdsa", crypto.SHA512},
+	AlgRS256: {"rsa", crypto.SHA256},
+	algRS384: {"rsa", crypto.SHA384},
+	algRS512: {"rsa", crypto.SHA512},
+	algRS1:   {"rsa", crypto.SHA1},
+	algPS256: {"rsa-pss", crypto.SHA256},
+	algPS384: {"rsa-pss", crypto.SHA384},
+	algPS512: {"rsa-pss", crypto.SHA512},
+	AlgEdDSA: {"ed25519", 0},
+}
+
+// digest はCOSEアルゴリズム alg のハッシュ関数で data のハッシュ値を計算します
+func digest(alg int, data []byte) ([]byte, crypto.Hash, error) {
+	sigAlg, ok := signatureAlgorithms[alg]
+	if !ok || sigAlg.hash == 0 {
+		return nil, 0, verificationError("unsupported hash algorithm for %d", alg)
+	}
+	h := sigAlg.hash.New()
+	h.Write(data)
+	return h.Sum(nil), sigAlg.hash, nil
+}
+
+// verifySignature はCOSEアルゴリズム alg で data に対する署名を検証します
+func verifySignature(alg int, key crypto.PublicKey, data, signature []byte) error {
+	sigAlg, ok := signatureAlgorithms[alg]
+	if !ok {
+		return verificationError("unsupported signature algorithm %d", alg)
+	}
+
+	var valid bool
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if sigAlg.keyType == "ecdsa" {
+			hashed, _, _ := digest(alg, data)
+			valid = ecdsa.VerifyASN1(key, hashed, signature)
+		}
+	case *rsa.PublicKey:
+		hashed, hash, _ := digest(alg, data)
+		switch sigAlg.keyType {
+		case "rsa":
+			valid = rsa.VerifyPKCS1v15(key, hash, hashed, signature) == nil
+		case "rsa-pss":
+			valid = rsa.VerifyPSS(key, hash, hashed, signature, nil) == nil
+		}
+	case ed25519.PublicKey:
+		if sigAlg.keyType == "ed25519" {
+			valid = ed25519.Verify(key, data, signature)
+		}
+	}
+	if !valid {
+		return verificationError("invalid attestation signature")
+	}
+	return nil
+}
+
+// VerifyTrustPath はアテステーション証明書のチェーンが roots のいずれかにつながるかを検証します
+// ポリシーで信頼するルート（FIDOメタデータの attestationRootCertificates など）を確認するために使います
+func VerifyTrustPath(trustPath [][]byte, roots *x509.CertPool, at time.Time) error {
+	certs, err := parseTrustPath("trust path", trustPath)
+	if err != nil {
+		return err
+	}
+	allowTPMSubjectAltName(certs[0])
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return verificationError("attestation trust path is not trusted: %v", err)
+	}
+	return nil
+}
+
+// allowTPMSubjectAltName はAIK証明書の必須で重要な拡張であるサブジェクト代替名を処理済みとして扱います
+// TPMの製造元などを表す directoryName は crypto/x509 が解釈しないため、そのままでは検証に失敗します
+func allowTPMSubjectAltName(cert *x509.Certificate) {
+	if !hasUnknownExtKeyUsage(cert, oidTCGKPAIKCertificate) {
+		return
+	}
+	unhandled := cert.UnhandledCriticalExtensions[:0]
+	for _, oid := range cert.UnhandledCriticalExtensions {
+		if !oid.Equal(oidSubjectAltName) {
+			unhandled = append(unhandled, oid)
+		}
+	}
+	cert.UnhandledCriticalExtensions = unhandled
+}
@@ -0,0 +1,269 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// oidAndroidKeyDescription はAndroid Keystoreのアテステーション証明書に含まれる鍵の説明の拡張です
+var oidAndroidKeyDescription = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 1, 17}
+
+// Android Keystoreの AuthorizationList のタグと値です
+const (
+	androidTagPurpose         = 1
+	androidTagAllApplications = 600
+	androidTagOrigin          = 702
+
+	androidPurposeSign     = 2
+	androidOriginGenerated = 0
+)
+
+// androidKeyStatement は "android-key" 形式のステートメントです
+type androidKeyStatement struct {
+	Alg int      `cbor:"alg"`
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c"`
+}
+
+// androidKeyDescription はAndroid Keystoreの KeyDescription です
+// https://source.android.com/docs/security/features/keystore/attestation#schema
+type androidKeyDescription struct {
+	AttestationVersion       int
+	AttestationSecurityLevel asn1.Enumerated
+	KeymasterVersion         int
+	KeymasterSecurityLevel   asn1.Enumerated
+	AttestationChallenge     []byte
+	UniqueID                 []byte
+	SoftwareEnforced         asn1.RawValue
+	TeeEnforced              asn1.RawValue
+}
+
+// androidAuthorizationList は AuthorizationList のうち検証に使う項目です
+type androidAuthorizationList struct {
+	purposes        []int
+	allApplications bool
+	origin          *int
+}
+
+// verifyAndroidKeyAttestation は "android-key" 形式（Android Keystore）を検証します
+// https://www.w3.org/TR/webauthn-2/#sctn-android-key-attestation
+func verifyAndroidKeyAttestation(obj *attestationObject) (*AttestationResult, error) {
+	var statement androidKeyStatement
+	if err := obj.decodeStatement(&statement); err != nil {
+		return nil, err
+	}
+	certs, err := parseTrustPath(obj.Format, statement.X5C)
+	if err != nil {
+		return nil, err
+	}
+	credCert := certs[0]
+	if err := verifySignature(statement.Alg, credCert.PublicKey, obj.signedData(), statement.Sig); err != nil {
+		return nil, err
+	}
+	if !sameKey(credCert.PublicKey, obj.credentialKey.Key) {
+		return nil, verificationError("android-key attestation certificate key does not match the credential key")
+	}
+
+	ext, ok := findExtension(credCert, oidAndroidKeyDescription)
+	if !ok {
+		return nil, verificationError("android-key attestation certificate has no key description")
+	}
+	var description androidKeyDescription
+	if _, err := asn1.Unmarshal(ext.Value, &description); err != nil {
+		return nil, verificationError("malformed android key description: %v", err)
+	}
+	if !bytes.Equal(description.AttestationChallenge, obj.clientDataHash) {
+		return nil, verificationError("android-key attestation challenge does not match")
+	}
+
+	software, err := parseAndroidAuthorizationList(description.SoftwareEnforced)
+	if err != nil {
+		return nil, err
+	}
+	tee, err := parseAndroidAuthorizationList(description.TeeEnforced)
+	if err != nil {
+		return nil, err
+	}
+	// 鍵はこのRP専用で、Keystore内で生成された署名用の鍵でなければならない
+	if software.allApplications || tee.allApplications {
+		return nil, verificationError("android-key credential must be scoped to the relying party")
+	}
+	if !software.hasOrigin(androidOriginGenerated) && !tee.hasOrigin(androidOriginGenerated) {
+		return nil, verificationError("android-key credential was not generated in the keystore")
+	}
+	if !software.hasPurpose(androidPurposeSign) && !tee.hasPurpose(androidPurposeSign) {
+		return nil, verificationError("android-key credential is not a signing key")
+	}
+
+	return &AttestationResult{Type: AttestationTypeBasic, TrustPath: statement.X5C}, nil
+}
+
+// parseAndroidAuthorizationList は AuthorizationList を解析します
+// 項目はすべて省略可能で、未知の項目は読み飛ばします
+func parseAndroidAuthorizationList(raw asn1.RawValue) (androidAuthorizationList, error) {
+	var list androidAuthorizationList
+	rest := raw.Bytes
+	for len(rest) > 0 {
+		var field asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &field); err != nil {
+			return list, verificationError("malformed android authorization list: %v", err)
+		}
+		if field.Class != asn1.ClassContextSpecific {
+			continue
+		}
+		switch field.Tag {
+		case androidTagPurpose:
+			var purposes []int
+			if _, err := asn1.UnmarshalWithParams(field.Bytes, &purposes, "set"); err != nil {
+				return list, verificationError("malformed android key purpose: %v", err)
+			}
+			list.purposes = append(list.purposes, purposes...)
+		case androidTagAllApplications:
+			list.allApplications = true
+		case androidTagOrigin:
+			var origin int
+			if _, err := asn1.Unmarshal(field.Bytes, &origin); err != nil {
+				return list, verificationError("malformed android key origin: %v", err)
+			}
+			list.origin = &origin
+		}
+	}
+	return list, nil
+}
+
+func (l androidAuthorizationList) hasOrigin(origin int) bool {
+	return l.origin != nil && *l.origin == origin
+}
+
+func (l androidAuthorizationList) hasPurpose(purpose int) bool {
+	return containsInt(l.purposes, purpose)
+}
+
+// SafetyNetの応答の有効期間です
+const (
+	safetyNetMaxAge    = time.Minute
+	safetyNetClockSkew = time.Minute
+)
+
+// safetyNetStatement は "android-safetynet" 形式のステートメントです
+type safetyNetStatement struct {
+	Ver      string `cbor:"ver"`
+	Response []byte `cbor:"response"`
+}
+
+// safetyNetHeader と safetyNetPayload はSafetyNetの応答（JWS）のヘッダーとペイロードです
+type safetyNetHeader struct {
+	Alg string   `json:"alg"`
+	X5C []string `json:"x5c"`
+}
+
+type safetyNetPayload struct {
+	Nonce           string `json:"nonce"`
+	TimestampMs     int64  `json:"timestampMs"`
+	CTSProfileMatch bool   `json:"ctsProfileMatch"`
+}
+
+// verifySafetyNetAttestation は "android-safetynet" 形式を検証します
+// https://www.w3.org/TR/webauthn-2/#sctn-android-safetynet-attestation
+func verifySafetyNetAttestation(obj *attestationObject) (*AttestationResult, error) {
+	var statement safetyNetStatement
+	if err := obj.decodeStatement(&statement); err != nil {
+		return nil, err
+	}
+	if statement.Ver == "" {
+		return nil, verificationError("android-safetynet attestation version is missing")
+	}
+
+	parts := strings.Split(string(statement.Response), ".")
+	if len(parts) != 3 {
+		return nil, verificationError("android-safetynet response is not a JWS")
+	}
+	var header safetyNetHeader
+	if err := decodeJWSPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	var payload safetyNetPayload
+	if err := decodeJWSPart(parts[1], &payload); err != nil {
+		return nil, err
+	}
+	signature, err := DecodeBase64(parts[2])
+	if err != nil {
+		return nil, verificationError("malformed android-safetynet signature")
+	}
+
+	x5c := make([][]byte, 0, len(header.X5C))
+	for _, cert := range header.X5C {
+		der, err := base64.StdEncoding.DecodeString(cert)
+		if err != nil {
+			return nil, verificationError("malformed android-safetynet certificate")
+		}
+		x5c = append(x5c, der)
+	}
+	certs, err := parseTrustPath(obj.Format, x5c)
+	if err != nil {
+		return nil, err
+	}
+	if err := certs[0].VerifyHostname("attest.android.com"); err != nil {
+		return nil, verificationError("android-safetynet certificate is not issued to attest.android.com")
+	}
+	if err := verifyJWSSignature(header.Alg, certs[0], []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	nonce := sha256.Sum256(obj.signedData())
+	if payload.Nonce != base64.StdEncoding.EncodeToString(nonce[:]) {
+		return nil, verificationError("android-safetynet nonce does not match")
+	}
+	if !payload.CTSProfileMatch {
+		return nil, verificationError("android-safetynet device does not pass the CTS profile")
+	}
+	issuedAt := time.UnixMilli(payload.TimestampMs)
+	now := timeNow()
+	if issuedAt.After(now.Add(safetyNetClockSkew)) || issuedAt.Before(now.Add(-safetyNetMaxAge)) {
+		return nil, verificationError("android-safetynet response is expired")
+	}
+
+	return &AttestationResult{Type: AttestationTypeBasic, TrustPath: x5c}, nil
+}
+
+func decodeJWSPart(part string, v interface{}) error {
+	b, err := DecodeBase64(part)
+	if err != nil {
+		return verificationError("malformed android-safetynet response")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return verificationError("malformed android-safetynet response: %v", err)
+	}
+	return nil
+}
+
+// verifyJWSSignature はJWSの署名を検証します（RS256 と ES256 に対応します）
+// JWSのES256の署名はDERではなく r || s の64バイトです
+func verifyJWSSignature(alg string, cert *x509.Certificate, signingInput, signature []byte) error {
+	var valid bool
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		valid = alg == "RS256" && verifySignature(AlgRS256, key, signingInput, signature) == nil
+	case *ecdsa.PublicKey:
+		if alg == "ES256" && len(signature) == 64 {
+			hashed := sha256.Sum256(signingInput)
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			valid = ecdsa.Verify(key, hashed[:], r, s)
+		}
+	}
+	if !valid {
+		return verificationError("invalid android-safetynet signature")
+	}
+	return nil
+}
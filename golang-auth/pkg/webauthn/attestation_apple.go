@@ -0,0 +1,52 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+)
+
+// oidAppleNonce はAppleのアテステーション証明書に含まれるnonceの拡張です
+var oidAppleNonce = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 8, 2}
+
+// appleStatement は "apple" 形式のステートメントです
+type appleStatement struct {
+	X5C [][]byte `cbor:"x5c"`
+}
+
+// appleNonceExtension は nonce の拡張の値です（SEQUENCE { [1] EXPLICIT OCTET STRING }）
+type appleNonceExtension struct {
+	Nonce []byte `asn1:"tag:1,explicit"`
+}
+
+// verifyAppleAttestation は "apple" 形式（Appleの匿名アテステーション）を検証します
+// https://www.w3.org/TR/webauthn-2/#sctn-apple-anonymous-attestation
+func verifyAppleAttestation(obj *attestationObject) (*AttestationResult, error) {
+	var statement appleStatement
+	if err := obj.decodeStatement(&statement); err != nil {
+		return nil, err
+	}
+	certs, err := parseTrustPath(obj.Format, statement.X5C)
+	if err != nil {
+		return nil, err
+	}
+	credCert := certs[0]
+
+	ext, ok := findExtension(credCert, oidAppleNonce)
+	if !ok {
+		return nil, verificationError("apple attestation certificate has no nonce extension")
+	}
+	var value appleNonceExtension
+	if rest, err := asn1.Unmarshal(ext.Value, &value); err != nil || len(rest) != 0 {
+		return nil, verificationError("malformed apple nonce extension")
+	}
+	nonce := sha256.Sum256(obj.signedData())
+	if !bytes.Equal(value.Nonce, nonce[:]) {
+		return nil, verificationError("apple attestation nonce does not match")
+	}
+
+	if !sameKey(credCert.PublicKey, obj.credentialKey.Key) {
+		return nil, verificationError("apple attestation certificate key does not match the credential key")
+	}
+	return &AttestationResult{Type: AttestationTypeAnonCA, TrustPath: statement.X5C}, nil
+}
@@ -0,0 +1,67 @@
+package webauthn
+
+import (
+	"crypto/x509"
+)
+
+// packedStatement は "packed" 形式のステートメントです
+type packedStatement struct {
+	Alg int      `cbor:"alg"`
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c"`
+}
+
+// verifyPackedAttestation は "packed" 形式を検証します
+// x5c がある場合はアテステーション証明書（basic）、無い場合はクレデンシャル自身の鍵（self）で署名されています
+// https://www.w3.org/TR/webauthn-2/#sctn-packed-attestation
+func verifyPackedAttestation(obj *attestationObject) (*AttestationResult, error) {
+	var statement packedStatement
+	if err := obj.decodeStatement(&statement); err != nil {
+		return nil, err
+	}
+	if len(statement.Sig) == 0 {
+		return nil, verificationError("packed attestation signature is missing")
+	}
+
+	if len(statement.X5C) == 0 {
+		if statement.Alg != obj.credentialKey.Algorithm {
+			return nil, verificationError("packed self attestation algorithm does not match the credential key")
+		}
+		if err := verifySignature(statement.Alg, obj.credentialKey.Key, obj.signedData(), statement.Sig); err != nil {
+			return nil, err
+		}
+		return &AttestationResult{Type: AttestationTypeSelf}, nil
+	}
+
+	certs, err := parseTrustPath(obj.Format, statement.X5C)
+	if err != nil {
+		return nil, err
+	}
+	attestnCert := certs[0]
+	if err := verifySignature(statement.Alg, attestnCert.PublicKey, obj.signedData(), statement.Sig); err != nil {
+		return nil, err
+	}
+	if err := verifyPackedCertificate(attestnCert, obj.parsedData.AttestedCredential.AAGUID); err != nil {
+		return nil, err
+	}
+	return &AttestationResult{Type: AttestationTypeBasic, TrustPath: statement.X5C}, nil
+}
+
+// verifyPackedCertificate はアテステーション証明書の要件を確認します
+// https://www.w3.org/TR/webauthn-2/#sctn-packed-attestation-cert-requirements
+func verifyPackedCertificate(cert *x509.Certificate, aaguid []byte) error {
+	if cert.Version != 3 {
+		return verificationError("packed attestation certificate must be version 3")
+	}
+	subject := cert.Subject
+	if len(subject.Country) == 0 || len(subject.Organization) == 0 || subject.CommonName == "" {
+		return verificationError("packed attestation certificate subject is incomplete")
+	}
+	if len(subject.OrganizationalUnit) != 1 || subject.OrganizationalUnit[0] != "Authenticator Attestation" {
+		return verificationError("packed attestation certificate OU must be \"Authenticator Attestation\"")
+	}
+	if err := verifyNotCA(cert); err != nil {
+		return err
+	}
+	return verifyCertificateAAGUID(cert, aaguid)
+}
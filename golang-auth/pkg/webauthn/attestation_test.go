@@ -0,0 +1,119 @@
+package webauthn_test
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/webauthntest"
+)
+
+func TestVerifyRegistrationAttestationFormats(t *testing.T) {
+	tests := []struct {
+		format          string
+		algorithm       int
+		selfAttestation bool
+		expectedType    string
+	}{
+		{"packed", webauthn.AlgES256, false, webauthn.AttestationTypeBasic},
+		{"packed", webauthn.AlgEdDSA, true, webauthn.AttestationTypeSelf},
+		{"fido-u2f", webauthn.AlgES256, false, webauthn.AttestationTypeBasic},
+		{"tpm", webauthn.AlgRS256, false, webauthn.AttestationTypeAttCA},
+		{"tpm", webauthn.AlgES256, false, webauthn.AttestationTypeAttCA},
+		{"android-key", webauthn.AlgES256, false, webauthn.AttestationTypeBasic},
+		{"android-safetynet", webauthn.AlgES256, false, webauthn.AttestationTypeBasic},
+		{"apple", webauthn.AlgES256, false, webauthn.AttestationTypeAnonCA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.expectedType, func(t *testing.T) {
+			// テストケースの準備
+			authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+			authenticator.Format = tt.format
+			authenticator.Algorithm = tt.algorithm
+			authenticator.SelfAttestation = tt.selfAttestation
+			authenticator.AAGUID = []byte("0123456789abcdef")
+			challenge := []byte("registration-challenge-0123456789")
+
+			credential, err := authenticator.Create(challenge, []byte("user-1"))
+			require.NoError(t, err)
+
+			// テスト実行
+			registration, err := webauthn.VerifyRegistration(registrationOptions(challenge), credential)
+
+			// アサーション
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedType, registration.AttestationType)
+			if tt.selfAttestation {
+				assert.Empty(t, registration.AttestationTrustPath)
+				return
+			}
+			require.NotEmpty(t, registration.AttestationTrustPath)
+
+			root, err := authenticator.AttestationRoot()
+			require.NoError(t, err)
+			roots := x509.NewCertPool()
+			roots.AddCert(root)
+			assert.NoError(t, webauthn.VerifyTrustPath(registration.AttestationTrustPath, roots, time.Now()))
+			assert.ErrorIs(t, webauthn.VerifyTrustPath(registration.AttestationTrustPath, x509.NewCertPool(), time.Now()), webauthn.ErrVerification)
+		})
+	}
+}
+
+func TestVerifyRegistrationRejectsInvalidAttestation(t *testing.T) {
+	formats := []string{"packed", "fido-u2f", "tpm", "android-key", "android-safetynet", "apple"}
+
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			// テストケースの準備
+			authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+			authenticator.Format = format
+			challenge := []byte("registration-challenge-0123456789")
+			credential, err := authenticator.Create(challenge, []byte("user-1"))
+			require.NoError(t, err)
+
+			// 別のクライアントデータで作成したステートメントに差し替えると署名やnonceが一致しない
+			other, err := authenticator.Create([]byte("another-challenge-0123456789"), []byte("user-1"))
+			require.NoError(t, err)
+			credential.Response.AttestationObject = replaceStatement(t, credential.Response.AttestationObject, other.Response.AttestationObject)
+
+			// テスト実行
+			_, err = webauthn.VerifyRegistration(registrationOptions(challenge), credential)
+
+			// アサーション
+			assert.ErrorIs(t, err, webauthn.ErrVerification)
+		})
+	}
+}
+
+func TestVerifyRegistrationRejectsUnknownAttestationFormat(t *testing.T) {
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	challenge := []byte("registration-challenge-0123456789")
+	credential, err := authenticator.Create(challenge, []byte("user-1"))
+	require.NoError(t, err)
+
+	var obj map[string]cbor.RawMessage
+	require.NoError(t, cbor.Unmarshal(credential.Response.AttestationObject, &obj))
+	obj["fmt"], _ = cbor.Marshal("unknown")
+	credential.Response.AttestationObject, _ = cbor.Marshal(obj)
+
+	// テスト実行
+	_, err = webauthn.VerifyRegistration(registrationOptions(challenge), credential)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+// replaceStatement は attestationObject の attStmt を other のものに差し替えます
+func replaceStatement(t *testing.T, attestationObject, other []byte) []byte {
+	t.Helper()
+	var obj, otherObj map[string]cbor.RawMessage
+	require.NoError(t, cbor.Unmarshal(attestationObject, &obj))
+	require.NoError(t, cbor.Unmarshal(other, &otherObj))
+	obj["attStmt"] = otherObj["attStmt"]
+	b, err := cbor.Marshal(obj)
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,265 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+)
+
+// TPM 2.0 の定数です（TPM 2.0 Library Part 2: Structures）
+const (
+	tpmGeneratedValue   = 0xff544347
+	tpmSTAttestCertify  = 0x8017
+	tpmAlgRSA           = 0x0001
+	tpmAlgSHA1          = 0x0004
+	tpmAlgSHA256        = 0x000B
+	tpmAlgSHA384        = 0x000C
+	tpmAlgSHA512        = 0x000D
+	tpmAlgNull          = 0x0010
+	tpmAlgECC           = 0x0023
+	tpmECCNistP256      = 0x0003
+	tpmECCNistP384      = 0x0004
+	tpmECCNistP521      = 0x0005
+	tpmDefaultExponent  = 65537
+	tpmClockInfoLength  = 17
+	tpmFirmwareVerBytes = 8
+)
+
+// oidTCGKPAIKCertificate はTPMのアテステーション鍵（AIK）証明書の拡張鍵用途です
+var oidTCGKPAIKCertificate = asn1.ObjectIdentifier{2, 23, 133, 8, 3}
+
+// oidSubjectAltName はサブジェクト代替名の拡張です
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+var tpmNameHashes = map[uint16]crypto.Hash{
+	tpmAlgSHA1:   crypto.SHA1,
+	tpmAlgSHA256: crypto.SHA256,
+	tpmAlgSHA384: crypto.SHA384,
+	tpmAlgSHA512: crypto.SHA512,
+}
+
+var tpmCurves = map[uint16]elliptic.Curve{
+	tpmECCNistP256: elliptic.P256(),
+	tpmECCNistP384: elliptic.P384(),
+	tpmECCNistP521: elliptic.P521(),
+}
+
+// tpmStatement は "tpm" 形式のステートメントです
+type tpmStatement struct {
+	Ver      string   `cbor:"ver"`
+	Alg      int      `cbor:"alg"`
+	X5C      [][]byte `cbor:"x5c"`
+	Sig      []byte   `cbor:"sig"`
+	CertInfo []byte   `cbor:"certInfo"`
+	PubArea  []byte   `cbor:"pubArea"`
+}
+
+// tpmPublic は TPMT_PUBLIC のうち検証に使う項目です
+type tpmPublic struct {
+	nameAlg uint16
+	key     crypto.PublicKey
+}
+
+// tpmAttest は TPMS_ATTEST（TPM_ST_ATTEST_CERTIFY）のうち検証に使う項目です
+type tpmAttest struct {
+	extraData    []byte
+	attestedName []byte
+}
+
+// verifyTPMAttestation は "tpm" 形式（Windows Hello などのTPM）を検証します
+// https://www.w3.org/TR/webauthn-2/#sctn-tpm-attestation
+func verifyTPMAttestation(obj *attestationObject) (*AttestationResult, error) {
+	var statement tpmStatement
+	if err := obj.decodeStatement(&statement); err != nil {
+		return nil, err
+	}
+	if statement.Ver != "2.0" {
+		return nil, verificationError("unsupported tpm attestation version %q", statement.Ver)
+	}
+
+	pub, err := parseTPMPublic(statement.PubArea)
+	if err != nil {
+		return nil, err
+	}
+	if !sameKey(pub.key, obj.credentialKey.Key) {
+		return nil, verificationError("tpm pubArea does not match the credential key")
+	}
+
+	attest, err := parseTPMAttest(statement.CertInfo)
+	if err != nil {
+		return nil, err
+	}
+	expectedExtraData, _, err := digest(statement.Alg, obj.signedData())
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(attest.extraData, expectedExtraData) {
+		return nil, verificationError("tpm certInfo extraData does not match")
+	}
+	nameHash, ok := tpmNameHashes[pub.nameAlg]
+	if !ok {
+		return nil, verificationError("unsupported tpm name algorithm %#x", pub.nameAlg)
+	}
+	h := nameHash.New()
+	h.Write(statement.PubArea)
+	expectedName := binary.BigEndian.AppendUint16(nil, pub.nameAlg)
+	expectedName = append(expectedName, h.Sum(nil)...)
+	if !bytes.Equal(attest.attestedName, expectedName) {
+		return nil, verificationError("tpm certInfo name does not match pubArea")
+	}
+
+	certs, err := parseTrustPath(obj.Format, statement.X5C)
+	if err != nil {
+		return nil, err
+	}
+	aikCert := certs[0]
+	if err := verifySignature(statement.Alg, aikCert.PublicKey, statement.CertInfo, statement.Sig); err != nil {
+		return nil, err
+	}
+	if err := verifyTPMCertificate(aikCert, obj.parsedData.AttestedCredential.AAGUID); err != nil {
+		return nil, err
+	}
+	return &AttestationResult{Type: AttestationTypeAttCA, TrustPath: statement.X5C}, nil
+}
+
+// verifyTPMCertificate はAIK証明書の要件を確認します
+// https://www.w3.org/TR/webauthn-2/#sctn-tpm-cert-requirements
+func verifyTPMCertificate(cert *x509.Certificate, aaguid []byte) error {
+	if cert.Version != 3 {
+		return verificationError("tpm attestation certificate must be version 3")
+	}
+	if len(cert.Subject.Names) != 0 {
+		return verificationError("tpm attestation certificate subject must be empty")
+	}
+	if _, ok := findExtension(cert, oidSubjectAltName); !ok {
+		return verificationError("tpm attestation certificate has no subject alternative name")
+	}
+	if !hasUnknownExtKeyUsage(cert, oidTCGKPAIKCertificate) {
+		return verificationError("tpm attestation certificate is not an AIK certificate")
+	}
+	if err := verifyNotCA(cert); err != nil {
+		return err
+	}
+	return verifyCertificateAAGUID(cert, aaguid)
+}
+
+// tpmReader はTPMの構造体（ビッグエンディアン）を順に読み出します
+type tpmReader struct {
+	b   []byte
+	err bool
+}
+
+func (r *tpmReader) next(n int) []byte {
+	if r.err || len(r.b) < n {
+		r.err = true
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *tpmReader) u16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *tpmReader) u32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// tpm2b は長さ（2バイト）付きのバイト列を読み出します
+func (r *tpmReader) tpm2b() []byte {
+	return r.next(int(r.u16()))
+}
+
+// scheme はアルゴリズムを読み出し、NULLでなければ続くハッシュアルゴリズムを読み飛ばします
+func (r *tpmReader) scheme() {
+	if r.u16() != tpmAlgNull {
+		r.u16()
+	}
+}
+
+// parseTPMPublic は pubArea（TPMT_PUBLIC）から公開鍵を取り出します
+func parseTPMPublic(pubArea []byte) (*tpmPublic, error) {
+	r := &tpmReader{b: pubArea}
+	keyType := r.u16()
+	pub := &tpmPublic{nameAlg: r.u16()}
+	r.u32()   // objectAttributes
+	r.tpm2b() // authPolicy
+
+	// symmetric はNULLでなければ鍵長とモードが続く
+	if r.u16() != tpmAlgNull {
+		r.u16()
+		r.u16()
+	}
+	r.scheme()
+
+	switch keyType {
+	case tpmAlgRSA:
+		r.u16() // keyBits
+		exponent := r.u32()
+		n := r.tpm2b()
+		if exponent == 0 {
+			exponent = tpmDefaultExponent
+		}
+		pub.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent)}
+	case tpmAlgECC:
+		curve, ok := tpmCurves[r.u16()]
+		r.scheme() // kdf
+		x, y := r.tpm2b(), r.tpm2b()
+		if !ok {
+			return nil, verificationError("unsupported tpm curve")
+		}
+		pub.key = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	default:
+		return nil, verificationError("unsupported tpm key type %#x", keyType)
+	}
+	if r.err {
+		return nil, verificationError("malformed tpm pubArea")
+	}
+	return pub, nil
+}
+
+// parseTPMAttest は certInfo（TPMS_ATTEST）を解析します
+func parseTPMAttest(certInfo []byte) (*tpmAttest, error) {
+	r := &tpmReader{b: certInfo}
+	magic := r.u32()
+	attestType := r.u16()
+	r.tpm2b() // qualifiedSigner
+	attest := &tpmAttest{extraData: r.tpm2b()}
+	r.next(tpmClockInfoLength)
+	r.next(tpmFirmwareVerBytes)
+	attest.attestedName = r.tpm2b()
+	r.tpm2b() // qualifiedName
+	if r.err {
+		return nil, verificationError("malformed tpm certInfo")
+	}
+	if magic != tpmGeneratedValue {
+		return nil, verificationError("tpm certInfo was not generated by the TPM")
+	}
+	if attestType != tpmSTAttestCertify {
+		return nil, verificationError("tpm certInfo is not a certify structure")
+	}
+	return attest, nil
+}
+
+func hasUnknownExtKeyUsage(cert *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	for _, usage := range cert.UnknownExtKeyUsage {
+		if usage.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
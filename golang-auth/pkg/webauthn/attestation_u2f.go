@@ -0,0 +1,58 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+)
+
+// fidoU2FStatement は "fido-u2f" 形式のステートメントです
+type fidoU2FStatement struct {
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c"`
+}
+
+// verifyFIDOU2FAttestation は "fido-u2f" 形式（U2F互換のセキュリティキー）を検証します
+// https://www.w3.org/TR/webauthn-2/#sctn-fido-u2f-attestation
+func verifyFIDOU2FAttestation(obj *attestationObject) (*AttestationResult, error) {
+	var statement fidoU2FStatement
+	if err := obj.decodeStatement(&statement); err != nil {
+		return nil, err
+	}
+	if len(statement.X5C) != 1 || len(statement.Sig) == 0 {
+		return nil, verificationError("fido-u2f attestation requires exactly one certificate and a signature")
+	}
+
+	certs, err := parseTrustPath(obj.Format, statement.X5C)
+	if err != nil {
+		return nil, err
+	}
+	certKey, ok := certs[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok || certKey.Curve != elliptic.P256() {
+		return nil, verificationError("fido-u2f attestation certificate must have a P-256 key")
+	}
+	credentialKey, ok := obj.credentialKey.Key.(*ecdsa.PublicKey)
+	if !ok || obj.credentialKey.Algorithm != AlgES256 {
+		return nil, verificationError("fido-u2f credential key must be ES256")
+	}
+
+	// U2Fの登録レスポンスと同じ形式の署名対象を組み立てる
+	attested := obj.parsedData.AttestedCredential
+	publicKeyU2F := make([]byte, 0, 65)
+	publicKeyU2F = append(publicKeyU2F, 0x04)
+	publicKeyU2F = append(publicKeyU2F, credentialKey.X.FillBytes(make([]byte, 32))...)
+	publicKeyU2F = append(publicKeyU2F, credentialKey.Y.FillBytes(make([]byte, 32))...)
+
+	verificationData := make([]byte, 0, 1+32+32+len(attested.CredentialID)+65)
+	verificationData = append(verificationData, 0x00)
+	verificationData = append(verificationData, obj.parsedData.RPIDHash...)
+	verificationData = append(verificationData, obj.clientDataHash...)
+	verificationData = append(verificationData, attested.CredentialID...)
+	verificationData = append(verificationData, publicKeyU2F...)
+
+	hashed := sha256.Sum256(verificationData)
+	if !ecdsa.VerifyASN1(certKey, hashed[:], statement.Sig) {
+		return nil, verificationError("invalid attestation signature")
+	}
+	return &AttestationResult{Type: AttestationTypeBasic, TrustPath: statement.X5C}, nil
+}
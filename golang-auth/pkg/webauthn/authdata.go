@@ -0,0 +1,119 @@
+package webauthn
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// authenticatorData のフラグです
+const (
+	FlagUserPresent    byte = 0x01
+	FlagUserVerified   byte = 0x04
+	FlagBackupEligible byte = 0x08
+	FlagBackupState    byte = 0x10
+	FlagAttestedData   byte = 0x40
+	FlagExtensionData  byte = 0x80
+)
+
+// maxCredentialIDLength はクレデンシャルIDの最大長です
+const maxCredentialIDLength = 1023
+
+// AuthenticatorData は認証器が署名対象として生成するデータです
+type AuthenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+	// AttestedCredential は登録時のみ含まれます
+	AttestedCredential *AttestedCredentialData
+	// Raw は署名検証に使う元のバイト列です
+	Raw []byte
+}
+
+// AttestedCredentialData は登録時に作成されたクレデンシャルの情報です
+type AttestedCredentialData struct {
+	AAGUID       []byte
+	CredentialID []byte
+	// PublicKey はCOSE形式の公開鍵です
+	PublicKey []byte
+}
+
+func (d *AuthenticatorData) UserPresent() bool    { return d.Flags&FlagUserPresent != 0 }
+func (d *AuthenticatorData) UserVerified() bool   { return d.Flags&FlagUserVerified != 0 }
+func (d *AuthenticatorData) BackupEligible() bool { return d.Flags&FlagBackupEligible != 0 }
+func (d *AuthenticatorData) BackupState() bool    { return d.Flags&FlagBackupState != 0 }
+
+// ParseAuthenticatorData は authenticatorData のバイナリを解析します
+func ParseAuthenticatorData(raw []byte) (*AuthenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, verificationError("authenticator data is too short")
+	}
+
+	data := &AuthenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+		Raw:       raw,
+	}
+	rest := raw[37:]
+
+	if data.Flags&FlagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, verificationError("attested credential data is too short")
+		}
+		attested := &AttestedCredentialData{AAGUID: rest[:16]}
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength > maxCredentialIDLength || len(rest) < idLength {
+			return nil, verificationError("invalid credential ID length")
+		}
+		attested.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		// 公開鍵はCBORで、後ろに拡張データが続くことがあるため先頭の1要素だけを読む
+		var key cbor.RawMessage
+		remaining, err := cbor.UnmarshalFirst(rest, &key)
+		if err != nil {
+			return nil, verificationError("malformed credential public key: %v", err)
+		}
+		attested.PublicKey = []byte(key)
+		rest = remaining
+		data.AttestedCredential = attested
+	}
+
+	if data.Flags&FlagExtensionData != 0 {
+		var extensions cbor.RawMessage
+		remaining, err := cbor.UnmarshalFirst(rest, &extensions)
+		if err != nil {
+			return nil, verificationError("malformed extensions: %v", err)
+		}
+		rest = remaining
+	}
+
+	if len(rest) != 0 {
+		return nil, verificationError("unexpected trailing bytes in authenticator data")
+	}
+	return data, nil
+}
+
+// verifyRPIDHash は rpIdHash がRP IDのSHA-256と一致するかを検証します
+func (d *AuthenticatorData) verifyRPIDHash(rpID string) error {
+	expected := sha256.Sum256([]byte(rpID))
+	if subtle.ConstantTimeCompare(d.RPIDHash, expected[:]) != 1 {
+		return verificationError("RP ID hash mismatch")
+	}
+	return nil
+}
+
+// verifyUser はユーザーの存在確認（UP）と、必要に応じてユーザー検証（UV）のフラグを検証します
+func (d *AuthenticatorData) verifyUser(requireUserVerification bool) error {
+	if !d.UserPresent() {
+		return verificationError("user presence flag is not set")
+	}
+	if requireUserVerification && !d.UserVerified() {
+		return verificationError("user verification flag is not set")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package webauthn
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+)
+
+// clientDataJSON の type です
+const (
+	ClientDataTypeCreate = "webauthn.create"
+	ClientDataTypeGet    = "webauthn.get"
+)
+
+// CollectedClientData はブラウザが署名対象として生成する clientDataJSON です
+type CollectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin,omitempty"`
+}
+
+// ParseClientData は clientDataJSON を解析します（検証は行いません）
+// 保存したチャレンジを検索するために、検証より前にチャレンジを取り出す用途で使います
+func ParseClientData(raw []byte) (*CollectedClientData, error) {
+	var clientData CollectedClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, verificationError("malformed clientDataJSON: %v", err)
+	}
+	return &clientData, nil
+}
+
+// verifyClientData は clientDataJSON の type、challenge、origin を検証します
+func verifyClientData(raw []byte, expectedType string, challenge []byte, origins []string) (*CollectedClientData, error) {
+	clientData, err := ParseClientData(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientData.Type != expectedType {
+		return nil, verificationError("unexpected client data type %q", clientData.Type)
+	}
+
+	received, err := DecodeBase64(clientData.Challenge)
+	if err != nil || len(challenge) == 0 || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return nil, verificationError("challenge mismatch")
+	}
+
+	if !containsString(origins, clientData.Origin) {
+		return nil, verificationError("unexpected origin %q", clientData.Origin)
+	}
+	if clientData.CrossOrigin {
+		return nil, verificationError("cross-origin requests are not allowed")
+	}
+
+	return clientData, nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
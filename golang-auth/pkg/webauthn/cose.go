@@ -0,0 +1,139 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSEの鍵の種類と曲線です（RFC 8152）
+const (
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// COSEの鍵のパラメータのラベルです
+const (
+	coseLabelKty = 1
+	coseLabelAlg = 3
+	// EC2とOKPでは曲線とX座標（OKPは公開鍵）、RSAではnとe
+	coseLabelCrvOrN = -1
+	coseLabelXOrE   = -2
+	coseLabelY      = -3
+)
+
+// PublicKey はCOSE形式から変換した公開鍵です
+type PublicKey struct {
+	Algorithm int
+	Key       crypto.PublicKey
+}
+
+// ParsePublicKey はCOSE形式の公開鍵を解析します
+// ES256（P-256）、EdDSA（Ed25519）、RS256 に対応します
+func ParsePublicKey(coseKey []byte) (*PublicKey, error) {
+	var params map[int]cbor.RawMessage
+	if err := cbor.Unmarshal(coseKey, &params); err != nil {
+		return nil, verificationError("malformed COSE key: %v", err)
+	}
+
+	var kty, alg int
+	if err := decodeParam(params, coseLabelKty, &kty); err != nil {
+		return nil, err
+	}
+	if err := decodeParam(params, coseLabelAlg, &alg); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		var crv int
+		var x, y []byte
+		if err := decodeParams(params, map[int]interface{}{coseLabelCrvOrN: &crv, coseLabelXOrE: &x, coseLabelY: &y}); err != nil {
+			return nil, err
+		}
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, verificationError("invalid EC2 key parameters")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, verificationError("EC2 point is not on the curve")
+		}
+		return &PublicKey{Algorithm: alg, Key: key}, nil
+
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		var crv int
+		var x []byte
+		if err := decodeParams(params, map[int]interface{}{coseLabelCrvOrN: &crv, coseLabelXOrE: &x}); err != nil {
+			return nil, err
+		}
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, verificationError("invalid OKP key parameters")
+		}
+		return &PublicKey{Algorithm: alg, Key: ed25519.PublicKey(x)}, nil
+
+	case kty == coseKtyRSA && alg == AlgRS256:
+		var n, e []byte
+		if err := decodeParams(params, map[int]interface{}{coseLabelCrvOrN: &n, coseLabelXOrE: &e}); err != nil {
+			return nil, err
+		}
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, verificationError("invalid RSA key parameters")
+		}
+		return &PublicKey{Algorithm: alg, Key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	}
+
+	return nil, verificationError("unsupported COSE key (kty=%d, alg=%d)", kty, alg)
+}
+
+// Verify は data に対する署名を検証します
+// ES256 の署名はASN.1 DER形式、RS256 はPKCS#1 v1.5形式です
+func (k *PublicKey) Verify(data, signature []byte) error {
+	var ok bool
+	switch key := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		ok = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	if !ok {
+		return verificationError("invalid signature")
+	}
+	return nil
+}
+
+func decodeParam(params map[int]cbor.RawMessage, label int, v interface{}) error {
+	raw, ok := params[label]
+	if !ok {
+		return verificationError("COSE key parameter %d is missing", label)
+	}
+	if err := cbor.Unmarshal(raw, v); err != nil {
+		return verificationError("malformed COSE key parameter %d: %v", label, err)
+	}
+	return nil
+}
+
+func decodeParams(params map[int]cbor.RawMessage, targets map[int]interface{}) error {
+	for label, v := range targets {
+		if err := decodeParam(params, label, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
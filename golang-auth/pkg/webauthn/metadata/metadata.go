@@ -0,0 +1,320 @@
+// Package metadata はFIDO Metadata Service（MDS3）のBLOBを読み込みます
+// https://fidoalliance.org/specs/mds/fido-metadata-service-v3.0-ps-20210518.html
+package metadata
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidBLOB はMDSのBLOBの形式・署名・証明書チェーンが不正であることを表します
+var ErrInvalidBLOB = errors.New("invalid metadata BLOB")
+
+func invalidBLOB(format string, v ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidBLOB, fmt.Sprintf(format, v...))
+}
+
+// 認証器のステータスです
+// https://fidoalliance.org/specs/mds/fido-metadata-service-v3.0-ps-20210518.html#authenticatorstatus-enum
+const (
+	StatusNotFIDOCertified               = "NOT_FIDO_CERTIFIED"
+	StatusFIDOCertified                  = "FIDO_CERTIFIED"
+	StatusFIDOCertifiedL1                = "FIDO_CERTIFIED_L1"
+	StatusFIDOCertifiedL1Plus            = "FIDO_CERTIFIED_L1plus"
+	StatusFIDOCertifiedL2                = "FIDO_CERTIFIED_L2"
+	StatusFIDOCertifiedL2Plus            = "FIDO_CERTIFIED_L2plus"
+	StatusFIDOCertifiedL3                = "FIDO_CERTIFIED_L3"
+	StatusFIDOCertifiedL3Plus            = "FIDO_CERTIFIED_L3plus"
+	StatusRevoked                        = "REVOKED"
+	StatusUserVerificationBypass         = "USER_VERIFICATION_BYPASS"
+	StatusAttestationKeyCompromise       = "ATTESTATION_KEY_COMPROMISE"
+	StatusUserKeyRemoteCompromise        = "USER_KEY_REMOTE_COMPROMISE"
+	StatusUserKeyPhysicalCompromise      = "USER_KEY_PHYSICAL_COMPROMISE"
+	StatusUpdateAvailable                = "UPDATE_AVAILABLE"
+	StatusSelfAssertionSubmitted         = "SELF_ASSERTION_SUBMITTED"
+	StatusCertificationDescriptorChanged = "CERTIFICATION_DESCRIPTOR_CHANGED"
+)
+
+// certificationLevels は認定のステータスとレベルの対応です（FIDO_CERTIFIED は L1 と同じです）
+var certificationLevels = map[string]int{
+	StatusFIDOCertified:       1,
+	StatusFIDOCertifiedL1:     1,
+	StatusFIDOCertifiedL1Plus: 2,
+	StatusFIDOCertifiedL2:     3,
+	StatusFIDOCertifiedL2Plus: 4,
+	StatusFIDOCertifiedL3:     5,
+	StatusFIDOCertifiedL3Plus: 6,
+}
+
+// revokedStatuses は認証器を信頼してはならないステータスです
+var revokedStatuses = map[string]bool{
+	StatusRevoked:                   true,
+	StatusUserVerificationBypass:    true,
+	StatusAttestationKeyCompromise:  true,
+	StatusUserKeyRemoteCompromise:   true,
+	StatusUserKeyPhysicalCompromise: true,
+}
+
+// ParseCertificationLevel は "L1"、"L2plus" などの認定レベルを比較できる値に変換します
+func ParseCertificationLevel(level string) (int, error) {
+	n, ok := certificationLevels["FIDO_CERTIFIED_"+level]
+	if !ok {
+		return 0, fmt.Errorf("unknown certification level %q", level)
+	}
+	return n, nil
+}
+
+// NormalizeAAGUID はAAGUID（"cb69481e-8ff7-..." またはハイフン無し）を小文字の16進数32文字に揃えます
+func NormalizeAAGUID(aaguid string) (string, error) {
+	s := strings.ToLower(strings.ReplaceAll(aaguid, "-", ""))
+	if b, err := hex.DecodeString(s); err != nil || len(b) != 16 {
+		return "", fmt.Errorf("invalid AAGUID %q", aaguid)
+	}
+	return s, nil
+}
+
+// BLOB は署名を検証済みのMDSのBLOBです
+type BLOB struct {
+	// No はBLOBの通し番号で、新しいBLOBほど大きくなります
+	No         int
+	NextUpdate string
+	entries    map[string]*Entry
+}
+
+// Entry はAAGUIDに対応する認証器のメタデータを返します（登録されていない場合は nil）
+func (b *BLOB) Entry(aaguid []byte) *Entry {
+	return b.entries[hex.EncodeToString(aaguid)]
+}
+
+// Len はAAGUIDで引ける認証器の数を返します
+func (b *BLOB) Len() int {
+	return len(b.entries)
+}
+
+// payload はBLOBのJWTのペイロードです
+type payload struct {
+	LegalHeader string   `json:"legalHeader"`
+	No          int      `json:"no"`
+	NextUpdate  string   `json:"nextUpdate"`
+	Entries     []*Entry `json:"entries"`
+}
+
+// Entry は認証器1機種分のメタデータです
+type Entry struct {
+	AAGUID                 string         `json:"aaguid"`
+	MetadataStatement      *Statement     `json:"metadataStatement"`
+	StatusReports          []StatusReport `json:"statusReports"`
+	TimeOfLastStatusChange string         `json:"timeOfLastStatusChange"`
+}
+
+// Statement はメタデータステートメントのうち検証・表示に使う項目です
+type Statement struct {
+	Description string `json:"description"`
+	// AttestationRootCertificates はアテステーション証明書のルート（base64のDER）です
+	AttestationRootCertificates []string `json:"attestationRootCertificates"`
+	AttestationTypes            []string `json:"attestationTypes"`
+}
+
+// StatusReport は認証器のステータスの変更履歴です
+type StatusReport struct {
+	Status        string `json:"status"`
+	EffectiveDate string `json:"effectiveDate"`
+}
+
+// Description は認証器の名前を返します
+func (e *Entry) Description() string {
+	if e.MetadataStatement == nil {
+		return ""
+	}
+	return e.MetadataStatement.Description
+}
+
+// CertificationLevel は最新の認定レベルを返します（認定されていない場合は 0）
+func (e *Entry) CertificationLevel() int {
+	reports := append([]StatusReport(nil), e.StatusReports...)
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].EffectiveDate < reports[j].EffectiveDate
+	})
+
+	level := 0
+	for _, report := range reports {
+		if n, ok := certificationLevels[report.Status]; ok {
+			level = n
+		} else if report.Status == StatusNotFIDOCertified {
+			level = 0
+		}
+	}
+	return level
+}
+
+// Revoked は認証器が失効、または鍵の漏洩などで信頼できないと報告されているかを返します
+func (e *Entry) Revoked() bool {
+	for _, report := range e.StatusReports {
+		if revokedStatuses[report.Status] {
+			return true
+		}
+	}
+	return false
+}
+
+// AttestationRoots はアテステーション証明書のルートを返します
+func (e *Entry) AttestationRoots() (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if e.MetadataStatement == nil {
+		return pool, nil
+	}
+	for _, encoded := range e.MetadataStatement.AttestationRootCertificates {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("malformed attestation root certificate for %s: %w", e.AAGUID, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("malformed attestation root certificate for %s: %w", e.AAGUID, err)
+		}
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// jwtHeader はBLOBのJWTのヘッダーです
+type jwtHeader struct {
+	Alg string   `json:"alg"`
+	X5C []string `json:"x5c"`
+}
+
+// Parse はBLOB（JWT）の署名と証明書チェーンを roots で検証し、AAGUIDで引けるように読み込みます
+// オフラインで使うため、証明書の失効（CRL）は確認しません
+func Parse(blob []byte, roots *x509.CertPool, now time.Time) (*BLOB, error) {
+	parts := strings.Split(strings.TrimSpace(string(blob)), ".")
+	if len(parts) != 3 {
+		return nil, invalidBLOB("not a JWT")
+	}
+	var header jwtHeader
+	if err := decodePart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidBLOB("malformed signature")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(header.X5C))
+	for _, encoded := range header.X5C {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, invalidBLOB("malformed certificate")
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, invalidBLOB("malformed certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, invalidBLOB("x5c is required")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, invalidBLOB("signing certificate is not trusted: %v", err)
+	}
+	if err := verifyJWS(header.Alg, certs[0], []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var p payload
+	if err := decodePart(parts[1], &p); err != nil {
+		return nil, err
+	}
+	b := &BLOB{No: p.No, NextUpdate: p.NextUpdate, entries: make(map[string]*Entry)}
+	for _, entry := range p.Entries {
+		// U2F・UAFの認証器は aaguid を持たないため対象外
+		if entry.AAGUID == "" {
+			continue
+		}
+		aaguid, err := NormalizeAAGUID(entry.AAGUID)
+		if err != nil {
+			return nil, invalidBLOB("%v", err)
+		}
+		b.entries[aaguid] = entry
+	}
+	return b, nil
+}
+
+func decodePart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return invalidBLOB("malformed JWT")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return invalidBLOB("malformed JWT: %v", err)
+	}
+	return nil
+}
+
+// verifyJWS はJWSの署名を検証します（RS256 と ES256 に対応します）
+func verifyJWS(alg string, cert *x509.Certificate, signingInput, signature []byte) error {
+	hashed := sha256.Sum256(signingInput)
+	var valid bool
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		valid = alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if alg == "ES256" && len(signature) == 64 {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			valid = ecdsa.Verify(key, hashed[:], r, s)
+		}
+	}
+	if !valid {
+		return invalidBLOB("invalid signature")
+	}
+	return nil
+}
+
+// LoadRootCertificates はPEM形式のファイルからBLOBの署名を検証するルート証明書を読み込みます
+func LoadRootCertificates(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("malformed root certificate in %s: %w", path, err)
+		}
+		pool.AddCert(cert)
+	}
+	if pool.Equal(x509.NewCertPool()) {
+		return nil, fmt.Errorf("no root certificate in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,212 @@
+package metadata
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAAGUID = "cb69481e-8ff7-4039-93ec-0a2729a154a8"
+
+var testAAGUIDBytes = []byte{0xcb, 0x69, 0x48, 0x1e, 0x8f, 0xf7, 0x40, 0x39, 0x93, 0xec, 0x0a, 0x27, 0x29, 0xa1, 0x54, 0xa8}
+
+// testSigner はBLOBに署名するテスト用のルートCAと署名用の証明書です
+type testSigner struct {
+	root    *x509.Certificate
+	leaf    []byte
+	leafKey *ecdsa.PrivateKey
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test MDS Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	require.NoError(t, err)
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leaf, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test MDS Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, root, leafKey.Public(), rootKey)
+	require.NoError(t, err)
+	return &testSigner{root: root, leaf: leaf, leafKey: leafKey}
+}
+
+func (s *testSigner) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.root)
+	return pool
+}
+
+// sign はペイロードをES256で署名したBLOBを作成します
+func (s *testSigner) sign(t *testing.T, p payload) []byte {
+	t.Helper()
+	header, err := json.Marshal(jwtHeader{Alg: "ES256", X5C: []string{base64.StdEncoding.EncodeToString(s.leaf)}})
+	require.NoError(t, err)
+	body, err := json.Marshal(p)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	hashed := sha256.Sum256([]byte(signingInput))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.leafKey, hashed[:])
+	require.NoError(t, err)
+	signature := append(r.FillBytes(make([]byte, 32)), sig.FillBytes(make([]byte, 32))...)
+	return []byte(signingInput + "." + base64.RawURLEncoding.EncodeToString(signature))
+}
+
+func testPayload(no int, reports ...StatusReport) payload {
+	return payload{
+		No:         no,
+		NextUpdate: time.Now().AddDate(0, 1, 0).Format("2006-01-02"),
+		Entries: []*Entry{
+			{
+				AAGUID:            testAAGUID,
+				MetadataStatement: &Statement{Description: "YubiKey 5 Series"},
+				StatusReports:     reports,
+			},
+			// U2Fの認証器はAAGUIDを持たない
+			{MetadataStatement: &Statement{Description: "U2F Key"}},
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	// テストケースの準備
+	signer := newTestSigner(t)
+	blob := signer.sign(t, testPayload(7, StatusReport{Status: StatusFIDOCertifiedL1, EffectiveDate: "2020-01-01"}))
+
+	// テスト実行
+	parsed, err := Parse(blob, signer.roots(), time.Now())
+
+	// アサーション
+	require.NoError(t, err)
+	assert.Equal(t, 7, parsed.No)
+	assert.Equal(t, 1, parsed.Len())
+	entry := parsed.Entry(testAAGUIDBytes)
+	require.NotNil(t, entry)
+	assert.Equal(t, "YubiKey 5 Series", entry.Description())
+	assert.Nil(t, parsed.Entry(make([]byte, 16)))
+}
+
+func TestParseRejectsInvalidBLOB(t *testing.T) {
+	signer := newTestSigner(t)
+	blob := signer.sign(t, testPayload(1))
+	// 別のBLOBのペイロードに差し替える
+	parts := strings.Split(string(blob), ".")
+	parts[1] = strings.Split(string(signer.sign(t, testPayload(2))), ".")[1]
+	tampered := []byte(strings.Join(parts, "."))
+
+	tests := []struct {
+		name  string
+		blob  []byte
+		roots *x509.CertPool
+		now   time.Time
+	}{
+		{"untrusted root", blob, newTestSigner(t).roots(), time.Now()},
+		{"tampered payload", tampered, signer.roots(), time.Now()},
+		{"expired signing certificate", blob, signer.roots(), time.Now().Add(2 * time.Hour)},
+		{"not a JWT", []byte("not-a-jwt"), signer.roots(), time.Now()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テスト実行
+			_, err := Parse(tt.blob, tt.roots, tt.now)
+
+			// アサーション
+			assert.ErrorIs(t, err, ErrInvalidBLOB)
+		})
+	}
+}
+
+func TestEntryStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		reports []StatusReport
+		level   int
+		revoked bool
+	}{
+		{"not certified", nil, 0, false},
+		{"certified", []StatusReport{{Status: StatusFIDOCertified, EffectiveDate: "2020-01-01"}}, 1, false},
+		{"latest level", []StatusReport{
+			{Status: StatusFIDOCertifiedL2, EffectiveDate: "2021-06-01"},
+			{Status: StatusFIDOCertifiedL1, EffectiveDate: "2020-01-01"},
+			{Status: StatusUpdateAvailable, EffectiveDate: "2022-01-01"},
+		}, 3, false},
+		{"revoked", []StatusReport{
+			{Status: StatusFIDOCertifiedL1, EffectiveDate: "2020-01-01"},
+			{Status: StatusAttestationKeyCompromise, EffectiveDate: "2021-01-01"},
+		}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &Entry{AAGUID: testAAGUID, StatusReports: tt.reports}
+
+			// テスト実行・アサーション
+			assert.Equal(t, tt.level, entry.CertificationLevel())
+			assert.Equal(t, tt.revoked, entry.Revoked())
+		})
+	}
+}
+
+func TestNormalizeAAGUID(t *testing.T) {
+	aaguid, err := NormalizeAAGUID("CB69481E-8FF7-4039-93EC-0A2729A154A8")
+	require.NoError(t, err)
+	assert.Equal(t, "cb69481e8ff7403993ec0a2729a154a8", aaguid)
+
+	_, err = NormalizeAAGUID("cb69481e")
+	assert.Error(t, err)
+}
+
+func TestStoreReload(t *testing.T) {
+	// テストケースの準備
+	signer := newTestSigner(t)
+	path := filepath.Join(t.TempDir(), "blob.jwt")
+	require.NoError(t, os.WriteFile(path, signer.sign(t, testPayload(2)), 0o600))
+
+	store, err := NewStore(path, signer.roots(), 0)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.False(t, store.Entry(testAAGUIDBytes).Revoked())
+
+	// テスト実行・アサーション
+	// 新しいBLOBは読み直しで反映される
+	require.NoError(t, os.WriteFile(path, signer.sign(t, testPayload(3, StatusReport{Status: StatusRevoked})), 0o600))
+	require.NoError(t, store.reload())
+	assert.True(t, store.Entry(testAAGUIDBytes).Revoked())
+
+	// 古いBLOBや不正なBLOBに差し替えられても、読み込み済みのBLOBを使い続ける
+	require.NoError(t, os.WriteFile(path, signer.sign(t, testPayload(1)), 0o600))
+	assert.Error(t, store.reload())
+	require.NoError(t, os.WriteFile(path, []byte("broken"), 0o600))
+	assert.Error(t, store.reload())
+	assert.True(t, store.Entry(testAAGUIDBytes).Revoked())
+}
@@ -0,0 +1,102 @@
+package metadata
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/pkg/logger"
+)
+
+// Store はローカルのファイルから読み込んだMDSのBLOBを保持し、定期的に読み直します
+// ネットワークに接続できない環境でも使えるよう、BLOBのダウンロードは別の手段（cron など）で行います
+type Store struct {
+	path  string
+	roots *x509.CertPool
+	now   func() time.Time
+
+	mu   sync.RWMutex
+	blob *BLOB
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStore は path のBLOBを読み込み、interval ごとに読み直す Store を作成します（0の場合は読み直しません）
+// 最初の読み込みに失敗した場合はエラーを返します
+func NewStore(path string, roots *x509.CertPool, interval time.Duration) (*Store, error) {
+	s := &Store{
+		path:  path,
+		roots: roots,
+		now:   time.Now,
+		stop:  make(chan struct{}),
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go s.watch(interval)
+	}
+	return s, nil
+}
+
+// Close は定期的な読み直しを停止します
+func (s *Store) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Entry はAAGUIDに対応する認証器のメタデータを返します（登録されていない場合は nil）
+func (s *Store) Entry(aaguid []byte) *Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.blob.Entry(aaguid)
+}
+
+func (s *Store) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// 読み直しに失敗した場合は、それまでのBLOBを使い続ける
+			if err := s.reload(); err != nil {
+				logger.LogWarning("failed to reload metadata BLOB: %v", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// reload はファイルからBLOBを読み込みます
+// 通し番号が現在のBLOBより小さいBLOBは、古いBLOBへの差し戻しを防ぐため受け付けません
+func (s *Store) reload() error {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	blob, err := Parse(raw, s.roots, now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blob != nil {
+		if blob.No < s.blob.No {
+			return fmt.Errorf("metadata BLOB %d is older than the loaded BLOB %d", blob.No, s.blob.No)
+		}
+		if blob.No == s.blob.No {
+			return nil
+		}
+	}
+	if nextUpdate, err := time.Parse("2006-01-02", blob.NextUpdate); err == nil && now.After(nextUpdate) {
+		logger.LogWarning("metadata BLOB %d is stale (nextUpdate %s)", blob.No, blob.NextUpdate)
+	}
+	s.blob = blob
+	logger.LogInfo("loaded metadata BLOB %d with %d authenticators", blob.No, blob.Len())
+	return nil
+}
@@ -0,0 +1,111 @@
+package webauthn
+
+import (
+	"crypto/sha256"
+)
+
+// RegistrationOptions は登録レスポンスの検証に使うRelying Partyの期待値です
+type RegistrationOptions struct {
+	// Challenge は登録開始時に発行したチャレンジです
+	Challenge []byte
+	RPID      string
+	// Origins は許可するオリジン（例: https://poc-authlete.local）です
+	Origins                 []string
+	RequireUserVerification bool
+	// Algorithms は登録開始時に pubKeyCredParams で要求したアルゴリズムです（空の場合は SupportedAlgorithms）
+	Algorithms []int
+}
+
+// Registration は検証済みの新しいクレデンシャルです
+type Registration struct {
+	CredentialID []byte
+	// PublicKey はCOSE形式の公開鍵です
+	PublicKey       []byte
+	Algorithm       int
+	AAGUID          []byte
+	SignCount       uint32
+	UserVerified    bool
+	BackupEligible  bool
+	BackupState     bool
+	AttestationType string
+	AttestationFmt  string
+	// AttestationTrustPath はアテステーション証明書のチェーン（DER形式）で、none と self では空です
+	AttestationTrustPath [][]byte
+	Transports           []string
+}
+
+// VerifyRegistration は navigator.credentials.create() のレスポンスを検証します
+// https://www.w3.org/TR/webauthn-2/#sctn-registering-a-new-credential
+func VerifyRegistration(opts RegistrationOptions, credential RegistrationCredential) (*Registration, error) {
+	if err := checkCredential(credential.Type, credential.ID, credential.RawID); err != nil {
+		return nil, err
+	}
+
+	if _, err := verifyClientData(credential.Response.ClientDataJSON, ClientDataTypeCreate, opts.Challenge, opts.Origins); err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(credential.Response.ClientDataJSON)
+
+	obj, err := parseAttestationObject(credential.Response.AttestationObject, clientDataHash[:])
+	if err != nil {
+		return nil, err
+	}
+	authData := obj.parsedData
+
+	if err := authData.verifyRPIDHash(opts.RPID); err != nil {
+		return nil, err
+	}
+	if err := authData.verifyUser(opts.RequireUserVerification); err != nil {
+		return nil, err
+	}
+
+	attested := authData.AttestedCredential
+	if attested == nil {
+		return nil, verificationError("attested credential data is missing")
+	}
+	if string(attested.CredentialID) != string(credential.RawID) {
+		return nil, verificationError("credential ID does not match rawId")
+	}
+
+	publicKey, err := ParsePublicKey(attested.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	algorithms := opts.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = SupportedAlgorithms
+	}
+	if !containsInt(algorithms, publicKey.Algorithm) {
+		return nil, verificationError("algorithm %d was not requested", publicKey.Algorithm)
+	}
+	obj.credentialKey = publicKey
+
+	attestation, err := obj.verifyStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registration{
+		CredentialID:         attested.CredentialID,
+		PublicKey:            attested.PublicKey,
+		Algorithm:            publicKey.Algorithm,
+		AAGUID:               attested.AAGUID,
+		SignCount:            authData.SignCount,
+		UserVerified:         authData.UserVerified(),
+		BackupEligible:       authData.BackupEligible(),
+		BackupState:          authData.BackupState(),
+		AttestationType:      attestation.Type,
+		AttestationFmt:       obj.Format,
+		AttestationTrustPath: attestation.TrustPath,
+		Transports:           credential.Response.Transports,
+	}, nil
+}
+
+func containsInt(list []int, v int) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package webauthn_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "poc-authlete.local"
+	testOrigin = "https://poc-authlete.local"
+)
+
+func registrationOptions(challenge []byte) webauthn.RegistrationOptions {
+	return webauthn.RegistrationOptions{
+		Challenge:               challenge,
+		RPID:                    testRPID,
+		Origins:                 []string{testOrigin},
+		RequireUserVerification: true,
+	}
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	for _, alg := range webauthn.SupportedAlgorithms {
+		authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+		authenticator.Algorithm = alg
+		challenge := []byte("registration-challenge-0123456789")
+
+		credential, err := authenticator.Create(challenge, []byte("user-1"))
+		require.NoError(t, err)
+
+		// JSONを経由してもブラウザから受け取った場合と同じ値になる
+		b, err := json.Marshal(credential)
+		require.NoError(t, err)
+		var received webauthn.RegistrationCredential
+		require.NoError(t, json.Unmarshal(b, &received))
+
+		// テスト実行
+		registration, err := webauthn.VerifyRegistration(registrationOptions(challenge), received)
+		require.NoError(t, err, "alg %d", alg)
+		assert.Equal(t, []byte(credential.RawID), registration.CredentialID)
+		assert.Equal(t, alg, registration.Algorithm)
+		assert.Equal(t, webauthn.AttestationTypeNone, registration.AttestationType)
+		assert.True(t, registration.UserVerified)
+		assert.Len(t, registration.AAGUID, 16)
+
+		_, err = webauthn.ParsePublicKey(registration.PublicKey)
+		assert.NoError(t, err)
+	}
+}
+
+func TestVerifyRegistrationRejectsInvalidResponses(t *testing.T) {
+	challenge := []byte("registration-challenge-0123456789")
+
+	tests := []struct {
+		name   string
+		modify func(a *webauthntest.Authenticator, opts *webauthn.RegistrationOptions)
+	}{
+		{"wrong challenge", func(a *webauthntest.Authenticator, opts *webauthn.RegistrationOptions) {
+			opts.Challenge = []byte("another-challenge")
+		}},
+		{"wrong origin", func(a *webauthntest.Authenticator, opts *webauthn.RegistrationOptions) {
+			a.Origin = "https://evil.example"
+		}},
+		{"wrong RP ID", func(a *webauthntest.Authenticator, opts *webauthn.RegistrationOptions) {
+			a.RPID = "evil.example"
+		}},
+		{"user not present", func(a *webauthntest.Authenticator, opts *webauthn.RegistrationOptions) {
+			a.Flags = webauthn.FlagUserVerified
+		}},
+		{"user not verified", func(a *webauthntest.Authenticator, opts *webauthn.RegistrationOptions) {
+			a.Flags = webauthn.FlagUserPresent
+		}},
+		{"algorithm not requested", func(a *webauthntest.Authenticator, opts *webauthn.RegistrationOptions) {
+			opts.Algorithms = []int{webauthn.AlgRS256}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+			opts := registrationOptions(challenge)
+			tt.modify(authenticator, &opts)
+
+			credential, err := authenticator.Create(challenge, []byte("user-1"))
+			require.NoError(t, err)
+
+			// テスト実行
+			_, err = webauthn.VerifyRegistration(opts, credential)
+			assert.ErrorIs(t, err, webauthn.ErrVerification)
+		})
+	}
+}
+
+func TestVerifyRegistrationRejectsWrongClientDataType(t *testing.T) {
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	challenge := []byte("registration-challenge-0123456789")
+	credential, err := authenticator.Create(challenge, []byte("user-1"))
+	require.NoError(t, err)
+
+	credential.Response.ClientDataJSON, _ = json.Marshal(webauthn.CollectedClientData{
+		Type:      webauthn.ClientDataTypeGet,
+		Challenge: webauthn.EncodeBase64(challenge),
+		Origin:    testOrigin,
+	})
+
+	// テスト実行
+	_, err = webauthn.VerifyRegistration(registrationOptions(challenge), credential)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestVerifyRegistrationRejectsMismatchedCredentialID(t *testing.T) {
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	challenge := []byte("registration-challenge-0123456789")
+	credential, err := authenticator.Create(challenge, []byte("user-1"))
+	require.NoError(t, err)
+
+	credential.RawID = []byte("another-credential-id")
+	credential.ID = webauthn.EncodeBase64(credential.RawID)
+
+	// テスト実行
+	_, err = webauthn.VerifyRegistration(registrationOptions(challenge), credential)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
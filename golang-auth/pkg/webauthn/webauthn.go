@@ -0,0 +1,109 @@
+// Package webauthn はWebAuthnの登録（attestation）と認証（assertion）のレスポンスを検証します
+// https://www.w3.org/TR/webauthn-2/ の Relying Party の手順に従います
+package webauthn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrVerification はクライアントから送られたレスポンスの検証に失敗したことを表します
+// 個別の理由はエラーメッセージに含まれます
+var ErrVerification = errors.New("webauthn verification failed")
+
+func verificationError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(format, args...))
+}
+
+// COSEアルゴリズム識別子です
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// SupportedAlgorithms は検証できる公開鍵のアルゴリズムです
+var SupportedAlgorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// URLEncodedBase64 はJSONでbase64url（パディング無し）として表現されるバイト列です
+// パディング付きや標準のbase64も受け付けます
+type URLEncodedBase64 []byte
+
+func (b URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *URLEncodedBase64) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := DecodeBase64(s)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// DecodeBase64 はbase64urlまたは標準のbase64の文字列をデコードします
+func DecodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// EncodeBase64 はバイト列をパディング無しのbase64urlにエンコードします
+func EncodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// RegistrationCredential は navigator.credentials.create() の結果の PublicKeyCredential のJSON表現です
+type RegistrationCredential struct {
+	ID                      string                           `json:"id"`
+	RawID                   URLEncodedBase64                 `json:"rawId"`
+	Type                    string                           `json:"type"`
+	AuthenticatorAttachment string                           `json:"authenticatorAttachment,omitempty"`
+	Response                AuthenticatorAttestationResponse `json:"response"`
+}
+
+// AuthenticatorAttestationResponse は登録時の認証器のレスポンスです
+type AuthenticatorAttestationResponse struct {
+	ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+	AttestationObject URLEncodedBase64 `json:"attestationObject"`
+	Transports        []string         `json:"transports,omitempty"`
+}
+
+// AuthenticationCredential は navigator.credentials.get() の結果の PublicKeyCredential のJSON表現です
+type AuthenticationCredential struct {
+	ID                      string                         `json:"id"`
+	RawID                   URLEncodedBase64               `json:"rawId"`
+	Type                    string                         `json:"type"`
+	AuthenticatorAttachment string                         `json:"authenticatorAttachment,omitempty"`
+	Response                AuthenticatorAssertionResponse `json:"response"`
+}
+
+// AuthenticatorAssertionResponse は認証時の認証器のレスポンスです
+type AuthenticatorAssertionResponse struct {
+	ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+	AuthenticatorData URLEncodedBase64 `json:"authenticatorData"`
+	Signature         URLEncodedBase64 `json:"signature"`
+	UserHandle        URLEncodedBase64 `json:"userHandle,omitempty"`
+}
+
+// checkCredential は PublicKeyCredential の type と id を検証します
+func checkCredential(credentialType, id string, rawID []byte) error {
+	if credentialType != "public-key" {
+		return verificationError("unexpected credential type %q", credentialType)
+	}
+	if len(rawID) == 0 {
+		return verificationError("rawId is missing")
+	}
+	if id != "" && id != EncodeBase64(rawID) {
+		return verificationError("id does not match rawId")
+	}
+	return nil
+}
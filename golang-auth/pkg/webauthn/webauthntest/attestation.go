@@ -0,0 +1,402 @@
+package webauthntest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+)
+
+// certificateAuthority はアテステーション証明書を発行するテスト用のルートCAです
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCertificateAuthority() (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Attestation Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &certificateAuthority{cert: cert, key: key}, nil
+}
+
+// issue はルートCAで署名したアテステーション証明書を発行します
+func (ca *certificateAuthority) issue(template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+	template.BasicConstraintsValid = true
+	return x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+}
+
+// AttestationRoot はアテステーション証明書を発行したルートCAの証明書を返します
+func (a *Authenticator) AttestationRoot() (*x509.Certificate, error) {
+	if a.ca == nil {
+		ca, err := newCertificateAuthority()
+		if err != nil {
+			return nil, err
+		}
+		a.ca = ca
+	}
+	return a.ca.cert, nil
+}
+
+// attest は Format の形式でアテステーションステートメントを生成します
+func (a *Authenticator) attest(credential *Credential, authData, clientDataHash []byte) (map[string]interface{}, error) {
+	if a.Format == "none" {
+		return map[string]interface{}{}, nil
+	}
+	if _, err := a.AttestationRoot(); err != nil {
+		return nil, err
+	}
+	signedData := append(append([]byte{}, authData...), clientDataHash...)
+
+	switch a.Format {
+	case "packed":
+		return a.attestPacked(credential, signedData)
+	case "fido-u2f":
+		return a.attestFIDOU2F(credential, authData, clientDataHash)
+	case "tpm":
+		return a.attestTPM(credential, signedData)
+	case "android-key":
+		return a.attestAndroidKey(credential, signedData, clientDataHash)
+	case "android-safetynet":
+		return a.attestSafetyNet(signedData)
+	case "apple":
+		return a.attestApple(credential, signedData)
+	}
+	return nil, fmt.Errorf("unsupported attestation format %q", a.Format)
+}
+
+// newAttestationKey は機種ごとのアテステーション鍵を模したP-256の鍵を生成します
+func newAttestationKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+func signES256(key *ecdsa.PrivateKey, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return ecdsa.SignASN1(rand.Reader, key, digest[:])
+}
+
+// aaguidExtension はアテステーション証明書に含めるAAGUIDの拡張です
+func (a *Authenticator) aaguidExtension() (pkix.Extension, error) {
+	value, err := asn1.Marshal(a.AAGUID)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}, Value: value}, nil
+}
+
+func (a *Authenticator) attestPacked(credential *Credential, signedData []byte) (map[string]interface{}, error) {
+	if a.SelfAttestation {
+		sig, err := credential.sign(signedData)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"alg": credential.Algorithm, "sig": sig}, nil
+	}
+
+	key, err := newAttestationKey()
+	if err != nil {
+		return nil, err
+	}
+	aaguid, err := a.aaguidExtension()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := a.ca.issue(&x509.Certificate{
+		Subject: pkix.Name{
+			Country:            []string{"JP"},
+			Organization:       []string{"Test Vendor"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "Test Authenticator",
+		},
+		ExtraExtensions: []pkix.Extension{aaguid},
+	}, key.Public())
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signES256(key, signedData)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"alg": webauthn.AlgES256, "sig": sig, "x5c": [][]byte{cert}}, nil
+}
+
+func (a *Authenticator) attestFIDOU2F(credential *Credential, authData, clientDataHash []byte) (map[string]interface{}, error) {
+	credentialKey, ok := credential.signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("fido-u2f requires an ES256 credential")
+	}
+	key, err := newAttestationKey()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := a.ca.issue(&x509.Certificate{Subject: pkix.Name{CommonName: "Test U2F Key"}}, key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{0x00}
+	data = append(data, authData[:32]...)
+	data = append(data, clientDataHash...)
+	data = append(data, credential.ID...)
+	data = append(data, 0x04)
+	data = append(data, credentialKey.X.FillBytes(make([]byte, 32))...)
+	data = append(data, credentialKey.Y.FillBytes(make([]byte, 32))...)
+	sig, err := signES256(key, data)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"sig": sig, "x5c": [][]byte{cert}}, nil
+}
+
+func (a *Authenticator) attestAndroidKey(credential *Credential, signedData, clientDataHash []byte) (map[string]interface{}, error) {
+	// teeEnforced: purpose [1] = {SIGN}, origin [702] = GENERATED
+	teeEnforced, err := asn1.Marshal(struct {
+		Purpose []int `asn1:"tag:1,explicit,set"`
+		Origin  int   `asn1:"tag:702,explicit"`
+	}{Purpose: []int{2}, Origin: 0})
+	if err != nil {
+		return nil, err
+	}
+	softwareEnforced, err := asn1.Marshal(struct{}{})
+	if err != nil {
+		return nil, err
+	}
+	description, err := asn1.Marshal(struct {
+		AttestationVersion       int
+		AttestationSecurityLevel asn1.Enumerated
+		KeymasterVersion         int
+		KeymasterSecurityLevel   asn1.Enumerated
+		AttestationChallenge     []byte
+		UniqueID                 []byte
+		SoftwareEnforced         asn1.RawValue
+		TeeEnforced              asn1.RawValue
+	}{
+		AttestationVersion:       3,
+		AttestationSecurityLevel: 1,
+		KeymasterVersion:         4,
+		KeymasterSecurityLevel:   1,
+		AttestationChallenge:     clientDataHash,
+		UniqueID:                 []byte{},
+		SoftwareEnforced:         asn1.RawValue{FullBytes: softwareEnforced},
+		TeeEnforced:              asn1.RawValue{FullBytes: teeEnforced},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := a.ca.issue(&x509.Certificate{
+		Subject:         pkix.Name{CommonName: "Android Keystore Key"},
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 1, 17}, Value: description}},
+	}, credential.signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	sig, err := credential.sign(signedData)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"alg": credential.Algorithm, "sig": sig, "x5c": [][]byte{cert}}, nil
+}
+
+func (a *Authenticator) attestApple(credential *Credential, signedData []byte) (map[string]interface{}, error) {
+	nonce := sha256.Sum256(signedData)
+	value, err := asn1.Marshal(struct {
+		Nonce []byte `asn1:"tag:1,explicit"`
+	}{Nonce: nonce[:]})
+	if err != nil {
+		return nil, err
+	}
+	cert, err := a.ca.issue(&x509.Certificate{
+		Subject:         pkix.Name{CommonName: "Apple Anonymous Attestation"},
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 8, 2}, Value: value}},
+	}, credential.signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"x5c": [][]byte{cert}}, nil
+}
+
+func (a *Authenticator) attestSafetyNet(signedData []byte) (map[string]interface{}, error) {
+	key, err := newAttestationKey()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := a.ca.issue(&x509.Certificate{
+		Subject:  pkix.Name{CommonName: "attest.android.com"},
+		DNSNames: []string{"attest.android.com"},
+	}, key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := sha256.Sum256(signedData)
+	header, _ := json.Marshal(map[string]interface{}{
+		"alg": "ES256",
+		"x5c": []string{base64.StdEncoding.EncodeToString(cert)},
+	})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"nonce":           base64.StdEncoding.EncodeToString(nonce[:]),
+		"timestampMs":     time.Now().UnixMilli(),
+		"ctsProfileMatch": true,
+		"basicIntegrity":  true,
+	})
+	signingInput := webauthn.EncodeBase64(header) + "." + webauthn.EncodeBase64(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	jws := signingInput + "." + webauthn.EncodeBase64(sig)
+	return map[string]interface{}{"ver": "14366018", "response": []byte(jws)}, nil
+}
+
+// TPMの構造体の組み立てに使う定数です
+const (
+	tpmAlgRSA    = 0x0001
+	tpmAlgSHA256 = 0x000B
+	tpmAlgNull   = 0x0010
+	tpmAlgECC    = 0x0023
+	tpmECCP256   = 0x0003
+)
+
+func appendTPM2B(b, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+// tpmPubArea はクレデンシャルの公開鍵を TPMT_PUBLIC として表現します
+func tpmPubArea(key crypto.PublicKey) ([]byte, error) {
+	var b []byte
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		b = binary.BigEndian.AppendUint16(b, tpmAlgRSA)
+		b = binary.BigEndian.AppendUint16(b, tpmAlgSHA256)
+		b = binary.BigEndian.AppendUint32(b, 0x00060472) // objectAttributes
+		b = appendTPM2B(b, nil)                          // authPolicy
+		b = binary.BigEndian.AppendUint16(b, tpmAlgNull) // symmetric
+		b = binary.BigEndian.AppendUint16(b, tpmAlgNull) // scheme
+		b = binary.BigEndian.AppendUint16(b, uint16(k.N.BitLen()))
+		b = binary.BigEndian.AppendUint32(b, 0) // 既定の指数（65537）
+		b = appendTPM2B(b, k.N.Bytes())
+	case *ecdsa.PublicKey:
+		b = binary.BigEndian.AppendUint16(b, tpmAlgECC)
+		b = binary.BigEndian.AppendUint16(b, tpmAlgSHA256)
+		b = binary.BigEndian.AppendUint32(b, 0x00060472)
+		b = appendTPM2B(b, nil)
+		b = binary.BigEndian.AppendUint16(b, tpmAlgNull)
+		b = binary.BigEndian.AppendUint16(b, tpmAlgNull)
+		b = binary.BigEndian.AppendUint16(b, tpmECCP256)
+		b = binary.BigEndian.AppendUint16(b, tpmAlgNull) // kdf
+		b = appendTPM2B(b, k.X.FillBytes(make([]byte, 32)))
+		b = appendTPM2B(b, k.Y.FillBytes(make([]byte, 32)))
+	default:
+		return nil, errors.New("tpm requires an ES256 or RS256 credential")
+	}
+	return b, nil
+}
+
+// tpmSubjectAltName はAIK証明書のサブジェクト代替名（TPMの製造元・型番・バージョン）です
+func tpmSubjectAltName() (pkix.Extension, error) {
+	name, err := asn1.Marshal(pkix.RDNSequence{
+		{{Type: asn1.ObjectIdentifier{2, 23, 133, 2, 1}, Value: "id:FFFFF1D0"}},
+		{{Type: asn1.ObjectIdentifier{2, 23, 133, 2, 2}, Value: "Test TPM"}},
+		{{Type: asn1.ObjectIdentifier{2, 23, 133, 2, 3}, Value: "id:00010000"}},
+	})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	value, err := asn1.Marshal([]asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: name}})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: value}, nil
+}
+
+func (a *Authenticator) attestTPM(credential *Credential, signedData []byte) (map[string]interface{}, error) {
+	pubArea, err := tpmPubArea(credential.signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	extraData := sha256.Sum256(signedData)
+	name := sha256.Sum256(pubArea)
+	certInfo := binary.BigEndian.AppendUint32(nil, 0xff544347) // TPM_GENERATED_VALUE
+	certInfo = binary.BigEndian.AppendUint16(certInfo, 0x8017) // TPM_ST_ATTEST_CERTIFY
+	certInfo = appendTPM2B(certInfo, nil)                      // qualifiedSigner
+	certInfo = appendTPM2B(certInfo, extraData[:])
+	certInfo = append(certInfo, make([]byte, 17+8)...) // clockInfo、firmwareVersion
+	certInfo = appendTPM2B(certInfo, append(binary.BigEndian.AppendUint16(nil, tpmAlgSHA256), name[:]...))
+	certInfo = appendTPM2B(certInfo, nil) // qualifiedName
+
+	aik, err := newAttestationKey()
+	if err != nil {
+		return nil, err
+	}
+	san, err := tpmSubjectAltName()
+	if err != nil {
+		return nil, err
+	}
+	aaguid, err := a.aaguidExtension()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := a.ca.issue(&x509.Certificate{
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{{2, 23, 133, 8, 3}},
+		ExtraExtensions:    []pkix.Extension{san, aaguid},
+	}, aik.Public())
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signES256(aik, certInfo)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"ver":      "2.0",
+		"alg":      webauthn.AlgES256,
+		"x5c":      [][]byte{cert},
+		"sig":      sig,
+		"certInfo": certInfo,
+		"pubArea":  pubArea,
+	}, nil
+}
@@ -0,0 +1,226 @@
+// Package webauthntest はテスト用の仮想認証器を提供します
+// ブラウザと認証器の代わりに、検証可能な登録・認証レスポンスを生成します
+package webauthntest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/yamakenji24/golang-auth/pkg/webauthn"
+)
+
+// Authenticator はメモリ上に鍵を持つ仮想認証器です
+type Authenticator struct {
+	RPID   string
+	Origin string
+	// Algorithm は新しいクレデンシャルの鍵のアルゴリズムです（既定は ES256）
+	Algorithm int
+	// Flags は authenticatorData に設定するフラグです（既定は UP と UV）
+	Flags  byte
+	AAGUID []byte
+	// Format は Create で生成するアテステーションの形式です（既定は "none"）
+	// packed、fido-u2f、tpm、android-key、android-safetynet、apple に対応します
+	Format string
+	// SelfAttestation は packed 形式で証明書を使わず、クレデンシャル自身の鍵で署名します
+	SelfAttestation bool
+
+	credentials map[string]*Credential
+	ca          *certificateAuthority
+}
+
+// Credential は仮想認証器が作成したクレデンシャルです
+type Credential struct {
+	ID         []byte
+	UserHandle []byte
+	Algorithm  int
+	SignCount  uint32
+	signer     crypto.Signer
+}
+
+// NewAuthenticator は指定したRP IDとオリジンで動作する仮想認証器を作成します
+func NewAuthenticator(rpID, origin string) *Authenticator {
+	return &Authenticator{
+		RPID:        rpID,
+		Origin:      origin,
+		Algorithm:   webauthn.AlgES256,
+		Flags:       webauthn.FlagUserPresent | webauthn.FlagUserVerified,
+		AAGUID:      make([]byte, 16),
+		Format:      "none",
+		credentials: make(map[string]*Credential),
+	}
+}
+
+// Credential は作成済みのクレデンシャルを返します
+func (a *Authenticator) Credential(id []byte) *Credential {
+	return a.credentials[string(id)]
+}
+
+// Create は navigator.credentials.create() と同様に、新しいクレデンシャルと Format の形式の登録レスポンスを生成します
+func (a *Authenticator) Create(challenge, userHandle []byte) (webauthn.RegistrationCredential, error) {
+	signer, err := generateKey(a.Algorithm)
+	if err != nil {
+		return webauthn.RegistrationCredential{}, err
+	}
+
+	credential := &Credential{
+		ID:         randomBytes(32),
+		UserHandle: userHandle,
+		Algorithm:  a.Algorithm,
+		signer:     signer,
+	}
+	a.credentials[string(credential.ID)] = credential
+
+	clientDataJSON := a.clientData(webauthn.ClientDataTypeCreate, challenge)
+
+	coseKey, err := EncodePublicKey(a.Algorithm, signer.Public())
+	if err != nil {
+		return webauthn.RegistrationCredential{}, err
+	}
+	attested := make([]byte, 0, 18+len(credential.ID)+len(coseKey))
+	attested = append(attested, a.AAGUID...)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(credential.ID)))
+	attested = append(attested, credential.ID...)
+	attested = append(attested, coseKey...)
+
+	authData := a.authData(a.Flags|webauthn.FlagAttestedData, credential.SignCount, attested)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	statement, err := a.attest(credential, authData, clientDataHash[:])
+	if err != nil {
+		return webauthn.RegistrationCredential{}, err
+	}
+	attestationObject, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      a.Format,
+		"attStmt":  statement,
+		"authData": authData,
+	})
+	if err != nil {
+		return webauthn.RegistrationCredential{}, err
+	}
+
+	return webauthn.RegistrationCredential{
+		ID:    webauthn.EncodeBase64(credential.ID),
+		RawID: credential.ID,
+		Type:  "public-key",
+		Response: webauthn.AuthenticatorAttestationResponse{
+			ClientDataJSON:    clientDataJSON,
+			AttestationObject: attestationObject,
+			Transports:        []string{"internal"},
+		},
+	}, nil
+}
+
+// Get は navigator.credentials.get() と同様に、作成済みのクレデンシャルで認証レスポンスを生成します
+// 署名カウンターは呼び出すたびに1増えます
+func (a *Authenticator) Get(credentialID, challenge []byte) (webauthn.AuthenticationCredential, error) {
+	credential, ok := a.credentials[string(credentialID)]
+	if !ok {
+		return webauthn.AuthenticationCredential{}, errors.New("unknown credential")
+	}
+	credential.SignCount++
+
+	clientDataJSON := a.clientData(webauthn.ClientDataTypeGet, challenge)
+	authData := a.authData(a.Flags, credential.SignCount, nil)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature, err := credential.sign(append(append([]byte{}, authData...), clientDataHash[:]...))
+	if err != nil {
+		return webauthn.AuthenticationCredential{}, err
+	}
+
+	return webauthn.AuthenticationCredential{
+		ID:    webauthn.EncodeBase64(credential.ID),
+		RawID: credential.ID,
+		Type:  "public-key",
+		Response: webauthn.AuthenticatorAssertionResponse{
+			ClientDataJSON:    clientDataJSON,
+			AuthenticatorData: authData,
+			Signature:         signature,
+			UserHandle:        credential.UserHandle,
+		},
+	}, nil
+}
+
+// sign はクレデンシャルのアルゴリズムで署名します
+func (c *Credential) sign(data []byte) ([]byte, error) {
+	if c.Algorithm == webauthn.AlgEdDSA {
+		return c.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(data)
+	return c.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// clientData はブラウザが生成する clientDataJSON を模倣します
+func (a *Authenticator) clientData(typ string, challenge []byte) []byte {
+	b, _ := json.Marshal(webauthn.CollectedClientData{
+		Type:      typ,
+		Challenge: webauthn.EncodeBase64(challenge),
+		Origin:    a.Origin,
+	})
+	return b
+}
+
+// authData は authenticatorData を組み立てます
+func (a *Authenticator) authData(flags byte, signCount uint32, rest []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := make([]byte, 0, 37+len(rest))
+	data = append(data, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	return append(data, rest...)
+}
+
+// EncodePublicKey は公開鍵をCOSE形式にエンコードします
+func EncodePublicKey(alg int, key crypto.PublicKey) ([]byte, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return cbor.Marshal(map[int]interface{}{
+			1: 2, 3: alg, -1: 1,
+			-2: k.X.FillBytes(make([]byte, 32)),
+			-3: k.Y.FillBytes(make([]byte, 32)),
+		})
+	case ed25519.PublicKey:
+		return cbor.Marshal(map[int]interface{}{1: 1, 3: alg, -1: 6, -2: []byte(k)})
+	case *rsa.PublicKey:
+		return cbor.Marshal(map[int]interface{}{
+			1: 3, 3: alg,
+			-1: k.N.Bytes(),
+			-2: big32(k.E),
+		})
+	}
+	return nil, errors.New("unsupported key type")
+}
+
+func generateKey(alg int) (crypto.Signer, error) {
+	switch alg {
+	case webauthn.AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case webauthn.AlgRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+}
+
+func big32(v int) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(v))
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}